@@ -0,0 +1,223 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package escrow implements a three-party escrow app.
+//
+// The participants of an escrow channel are the buyer, the seller and the
+// arbiter, in this order. The initial Data sets the amount that the buyer
+// escrows for the seller. The escrowed amount stays in the buyer's balance
+// until it is released to the seller. While the escrow is pending,
+// * the buyer or the arbiter can release the amount to the seller,
+// * the seller or the arbiter can refund the amount to the buyer and
+// * the buyer can refund the amount after the deadline.
+// Thus, the buyer and seller settle the escrow if they agree, and the arbiter
+// decides disputes. No other balance changes are allowed.
+//
+// The arbiter cannot force its decision. Every update needs the signatures of
+// all three participants and the app does not progress on-chain, so if the
+// buyer or seller refuses to sign a decision, the channel is settled with the
+// last state that all participants signed, in which the escrow may still be
+// pending. A pending escrow settles with the amount in the buyer's balance.
+//
+// The deadline is checked against the local clock of each participant. To not
+// have a refund rejected by participants whose clocks lag behind, Refund only
+// lets the buyer refund once the deadline passed by ClockSkew, while
+// ValidTransition accepts the refund as soon as the deadline passed.
+//
+// The client only implements the two-party channel proposal protocol, so
+// escrow channels cannot be opened with Client.ProposeChannel yet. Until
+// multi-party proposals are supported, the app can only be used with the
+// channel machines directly.
+package escrow // import "perun.network/go-perun/apps/escrow"
+
+import (
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// ClockSkew is the maximal difference between the clocks of the participants
+// that is tolerated for deadline refunds.
+const ClockSkew = time.Minute
+
+// App is the escrow app.
+type App struct {
+	Addr wallet.Address
+
+	now func() time.Time // clock for deadline checks, time.Now if nil
+}
+
+var _ channel.StateApp = (*App)(nil)
+
+// SetAppDef registers the escrow app under the given address in the app
+// registry, so that channels with this app definition use the escrow app.
+func SetAppDef(def wallet.Address) {
+	channel.RegisterApp(&App{Addr: def})
+}
+
+// Def returns the address of the escrow app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes escrow Data from the reader.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var d Data
+	return &d, d.Decode(r)
+}
+
+// ValidInit checks that the channel has three participants and that the
+// escrow is pending and covered by the buyer's balance.
+func (a *App) ValidInit(p *channel.Params, s *channel.State) error {
+	d := asData(s)
+	newError := func(msg string) error {
+		return channel.NewStateTransitionError(p.ID(), msg)
+	}
+
+	switch {
+	case len(p.Parts) != NumParts:
+		return newError("escrow needs buyer, seller and arbiter")
+	case int(d.Asset) >= len(s.Assets):
+		return newError("invalid asset")
+	case d.Amount == nil || d.Amount.Sign() <= 0:
+		return newError("escrowed amount must be positive")
+	case d.Status != Pending:
+		return newError("initial escrow must be pending")
+	case s.Balances[d.Asset][Buyer].Cmp(d.Amount) < 0:
+		return newError("buyer's balance does not cover escrowed amount")
+	}
+	return nil
+}
+
+// ValidTransition checks that the escrow terms are unchanged and that a
+// pending escrow is only released or refunded by the participants that are
+// allowed to, see the package documentation. Releasing moves the escrowed
+// amount from the buyer to the seller. No other balance changes are allowed.
+func (a *App) ValidTransition(p *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromData, toData := asData(from), asData(to)
+	newError := func(format string, args ...interface{}) error {
+		return channel.NewStateTransitionError(p.ID(), errors.Errorf(format, args...).Error())
+	}
+
+	if len(p.Parts) != NumParts {
+		return newError("escrow needs buyer, seller and arbiter")
+	}
+	if !fromData.sameTerms(toData) {
+		return newError("escrow terms changed")
+	}
+
+	bals := cloneBalances(from.Balances)
+	if fromData.Status != toData.Status {
+		if err := a.mayDecide(fromData, toData.Status, actor, 0); err != nil {
+			return channel.NewStateTransitionError(p.ID(), err.Error())
+		}
+		if toData.Status == Released {
+			release(bals, toData)
+		}
+	}
+
+	for i, asset := range bals {
+		for j, bal := range asset {
+			if bal.Cmp(to.Balances[i][j]) != 0 {
+				return newError("balance of participant %d in asset %d is %v, expected %v",
+					j, i, to.Balances[i][j], bal)
+			}
+		}
+	}
+	return nil
+}
+
+// Release releases the pending escrow of the state to the seller, which moves
+// the escrowed amount from the buyer to the seller. It returns an error if the
+// escrow is not pending or the actor may not release it. The state's version
+// is not changed.
+func (a *App) Release(s *channel.State, actor channel.Index) error {
+	d := asData(s)
+	if err := a.mayDecide(d, Released, actor, 0); err != nil {
+		return err
+	}
+	bals := cloneBalances(s.Balances)
+	release(bals, d)
+	if bals[d.Asset][Buyer].Sign() < 0 {
+		return errors.New("buyer's balance does not cover escrowed amount")
+	}
+	s.Balances = bals
+	d.Status = Released
+	return nil
+}
+
+// Refund refunds the pending escrow of the state to the buyer. It returns an
+// error if the escrow is not pending or the actor may not refund it. The buyer
+// may only refund once the deadline passed by ClockSkew. The state's version
+// is not changed.
+func (a *App) Refund(s *channel.State, actor channel.Index) error {
+	d := asData(s)
+	if err := a.mayDecide(d, Refunded, actor, ClockSkew); err != nil {
+		return err
+	}
+	d.Status = Refunded
+	return nil
+}
+
+// mayDecide checks that the escrow is pending and that the actor may set it to
+// the given status. The buyer may only refund once the deadline passed by the
+// given margin.
+func (a *App) mayDecide(d *Data, status Status, actor channel.Index, margin time.Duration) error {
+	if d.Status != Pending {
+		return errors.Errorf("escrow already %v", d.Status)
+	}
+	switch status {
+	case Released:
+		if actor != Buyer && actor != Arbiter {
+			return errors.Errorf("participant %d may not release the escrow", actor)
+		}
+	case Refunded:
+		if actor == Buyer {
+			if a.clock().Before(time.Unix(int64(d.Deadline), 0).Add(margin)) {
+				return errors.New("buyer may not refund the escrow before the deadline")
+			}
+		} else if actor != Seller && actor != Arbiter {
+			return errors.Errorf("participant %d may not refund the escrow", actor)
+		}
+	default:
+		return errors.Errorf("invalid escrow status %v", status)
+	}
+	return nil
+}
+
+// release moves the escrowed amount from the buyer to the seller.
+func release(bals [][]channel.Bal, d *Data) {
+	asset := bals[d.Asset]
+	asset[Buyer].Sub(asset[Buyer], d.Amount)
+	asset[Seller].Add(asset[Seller], d.Amount)
+}
+
+func (a *App) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+func cloneBalances(bals [][]channel.Bal) [][]channel.Bal {
+	clone := make([][]channel.Bal, len(bals))
+	for i, asset := range bals {
+		clone[i] = channel.CloneBals(asset)
+	}
+	return clone
+}
+
+func asData(s *channel.State) *Data {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		log.Panicf("escrow app must have data of type *Data, has type %T", s.Data)
+	}
+	return d
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package escrow
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// testDeadline is the deadline of the test escrows.
+var testDeadline = time.Unix(1600000000, 0)
+
+// newTestChannel creates an escrow app, whose clock is one hour before
+// testDeadline, and an escrow channel with a single asset, in which the buyer
+// escrows 60 of its 100 for the seller. Seller and arbiter hold 10 each.
+func newTestChannel(t *testing.T, rng *rand.Rand) (*App, *channel.Params, *channel.State) {
+	app := &App{
+		Addr: wallettest.NewRandomAddress(rng),
+		now:  func() time.Time { return testDeadline.Add(-time.Hour) },
+	}
+	channel.RegisterApp(app)
+	params := test.NewRandomParams(rng, test.WithNumParts(NumParts), test.WithApp(app))
+	state := test.NewRandomState(rng,
+		test.WithParams(params),
+		test.WithBalances([]channel.Bal{big.NewInt(100), big.NewInt(10), big.NewInt(10)}),
+		test.WithNumLocked(0),
+		test.WithAppData(&Data{
+			Asset:    0,
+			Amount:   big.NewInt(60),
+			Deadline: uint64(testDeadline.Unix()),
+			Status:   Pending,
+		}),
+		test.WithIsFinal(false))
+	require.NoError(t, state.Valid())
+	return app, params, state
+}
+
+// next returns a copy of the state with an incremented version.
+func next(s *channel.State) *channel.State {
+	n := s.Clone()
+	n.Version++
+	return n
+}
+
+func TestApp_Def(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	def := wallettest.NewRandomAddress(rng)
+	app := &App{Addr: def}
+	assert.True(t, def.Equals(app.Def()))
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xE5C1))
+	app, params, state := newTestChannel(t, rng)
+
+	assert.NoError(t, app.ValidInit(params, state))
+
+	invalid := []func(*Data){
+		func(d *Data) { d.Asset = 1 },
+		func(d *Data) { d.Amount = big.NewInt(0) },
+		func(d *Data) { d.Amount = nil },
+		func(d *Data) { d.Amount = big.NewInt(101) },
+		func(d *Data) { d.Status = Released },
+	}
+	for i, modify := range invalid {
+		s := state.Clone()
+		modify(asData(s))
+		assert.Errorf(t, app.ValidInit(params, s), "data %d", i)
+	}
+
+	twoParty := test.NewRandomParams(rng, test.WithNumParts(2), test.WithApp(app))
+	assert.Error(t, app.ValidInit(twoParty, state))
+
+	wrongData := state.Clone()
+	wrongData.Data = new(channel.MockOp)
+	assert.Panics(t, func() { app.ValidInit(params, wrongData) })
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xE5C2))
+	app, params, pending := newTestChannel(t, rng)
+
+	// validFor asserts that the transition is only valid for the given actors.
+	validFor := func(t *testing.T, from, to *channel.State, actors ...channel.Index) {
+		for i := range params.Parts {
+			err := app.ValidTransition(params, from, to, channel.Index(i))
+			valid := false
+			for _, a := range actors {
+				valid = valid || a == channel.Index(i)
+			}
+			if valid {
+				assert.NoErrorf(t, err, "actor %d", i)
+			} else {
+				assert.Errorf(t, err, "actor %d", i)
+			}
+		}
+	}
+
+	released := next(pending)
+	require.NoError(t, app.Release(released, Buyer))
+	refunded := next(pending)
+	require.NoError(t, app.Refund(refunded, Seller))
+
+	t.Run("release", func(t *testing.T) {
+		assert.Equal(t, big.NewInt(40), released.Balances[0][Buyer])
+		assert.Equal(t, big.NewInt(70), released.Balances[0][Seller])
+		assert.Equal(t, big.NewInt(10), released.Balances[0][Arbiter])
+		validFor(t, pending, released, Buyer, Arbiter)
+
+		unpaid := next(pending)
+		asData(unpaid).Status = Released
+		validFor(t, pending, unpaid)
+
+		assert.Error(t, app.Release(next(pending), Seller))
+		assert.Error(t, app.Release(next(released), Buyer), "already released")
+	})
+
+	t.Run("refund", func(t *testing.T) {
+		validFor(t, pending, refunded, Seller, Arbiter)
+
+		paid := refunded.Clone()
+		paid.Balances = released.Clone().Balances
+		validFor(t, pending, paid)
+
+		assert.Error(t, app.Refund(next(pending), Buyer), "before deadline")
+		assert.Error(t, app.Refund(next(released), Arbiter), "already released")
+
+		defer func(now func() time.Time) { app.now = now }(app.now)
+		app.now = func() time.Time { return testDeadline }
+		validFor(t, pending, refunded, Buyer, Seller, Arbiter)
+		assert.Error(t, app.Refund(next(pending), Buyer), "within clock skew")
+
+		app.now = func() time.Time { return testDeadline.Add(ClockSkew) }
+		assert.NoError(t, app.Refund(next(pending), Buyer))
+	})
+
+	t.Run("decided", func(t *testing.T) {
+		validFor(t, released, next(released), Buyer, Seller, Arbiter)
+		validFor(t, refunded, next(refunded), Buyer, Seller, Arbiter)
+
+		reverted := next(released)
+		asData(reverted).Status = Refunded
+		reverted.Balances = pending.Clone().Balances
+		validFor(t, released, reverted)
+
+		releasedAfterRefund := next(refunded)
+		asData(releasedAfterRefund).Status = Released
+		releasedAfterRefund.Balances = released.Clone().Balances
+		validFor(t, refunded, releasedAfterRefund)
+	})
+
+	t.Run("terms", func(t *testing.T) {
+		changed := []func(*Data){
+			func(d *Data) { d.Asset = 1 },
+			func(d *Data) { d.Amount = big.NewInt(10) },
+			func(d *Data) { d.Amount = nil },
+			func(d *Data) { d.Deadline-- },
+		}
+		for _, modify := range changed {
+			s := next(pending)
+			modify(asData(s))
+			validFor(t, pending, s)
+		}
+
+		stolen := next(pending)
+		stolen.Balances[0][Buyer].SetInt64(90)
+		stolen.Balances[0][Arbiter].SetInt64(20)
+		validFor(t, pending, stolen)
+
+		invalidStatus := next(pending)
+		asData(invalidStatus).Status = maxStatus + 1
+		validFor(t, pending, invalidStatus)
+
+		twoParty := test.NewRandomParams(rng, test.WithNumParts(2), test.WithApp(app))
+		assert.Error(t, app.ValidTransition(twoParty, pending, released, Buyer))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		to := next(pending)
+		to.Data = nil
+		assert.Panics(t, func() { app.ValidTransition(params, pending, to, Buyer) })
+	})
+}
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6E5))
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:        app,
+		Randomizer: new(Randomizer),
+		NextData:   nextData(app),
+		Opts:       []test.RandomOpt{test.WithNumParts(NumParts)},
+	})
+}
+
+// nextData returns a NextData for the generic test, which releases or refunds
+// the escrow by the actor.
+func nextData(app *App) func(*rand.Rand, *channel.State, channel.Index) channel.Data {
+	return func(rng *rand.Rand, s *channel.State, actor channel.Index) channel.Data {
+		if rng.Intn(2) == 0 {
+			app.Release(s, actor) //nolint:errcheck // an error leaves the state unchanged
+		} else {
+			app.Refund(s, actor) //nolint:errcheck
+		}
+		return s.Data
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package escrow
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// The participant indices of an escrow channel.
+const (
+	Buyer channel.Index = iota
+	Seller
+	Arbiter
+	// NumParts is the number of participants of an escrow channel.
+	NumParts = 3
+)
+
+// Status is the status of an escrow.
+type Status uint8
+
+// The statuses of an escrow. Released and Refunded are terminal.
+const (
+	Pending Status = iota
+	Released
+	Refunded
+	maxStatus = Refunded
+)
+
+// Data is the app data of an escrow channel. The terms of the escrow are set
+// in the initial state and cannot be changed. Only the Status is updated.
+type Data struct {
+	Asset    uint16      // index of the asset in the channel's allocation
+	Amount   channel.Bal // escrowed amount
+	Deadline uint64      // Unix time in seconds after which the buyer can refund
+	Status   Status
+}
+
+var _ channel.Data = (*Data)(nil)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Released:
+		return "released"
+	case Refunded:
+		return "refunded"
+	default:
+		return "unknown"
+	}
+}
+
+// sameTerms returns whether both Data have the same escrow terms.
+func (d *Data) sameTerms(e *Data) bool {
+	return d.Asset == e.Asset &&
+		e.Amount != nil && d.Amount.Cmp(e.Amount) == 0 &&
+		d.Deadline == e.Deadline
+}
+
+// Clone returns a deep copy of the Data.
+func (d *Data) Clone() channel.Data {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	if d.Amount != nil {
+		clone.Amount = new(big.Int).Set(d.Amount)
+	}
+	return &clone
+}
+
+// Encode encodes the Data into an io.Writer.
+func (d *Data) Encode(w io.Writer) error {
+	return perunio.Encode(w, d.Asset, d.Amount, d.Deadline, uint8(d.Status))
+}
+
+// Decode decodes Data from an io.Reader.
+func (d *Data) Decode(r io.Reader) error {
+	if err := perunio.Decode(r, &d.Asset, &d.Amount, &d.Deadline, (*uint8)(&d.Status)); err != nil {
+		return err
+	}
+	if d.Status > maxStatus {
+		return errors.Errorf("invalid status %d", d.Status)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package escrow
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestData_Serializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xE5C0))
+	released := NewRandomData(rng)
+	released.Status = Released
+	iotest.GenericSerializerTest(t, NewRandomData(rng), released)
+
+	invalid := NewRandomData(rng)
+	invalid.Status = maxStatus + 1
+	var buf bytes.Buffer
+	require.NoError(t, invalid.Encode(&buf))
+	assert.Error(t, new(Data).Decode(&buf), "invalid status")
+}
+
+func TestData_Clone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xC10E))
+	d := NewRandomData(rng)
+	clone := d.Clone().(*Data)
+	assert.Equal(t, d, clone)
+
+	clone.Amount.SetInt64(0)
+	assert.NotZero(t, d.Amount.Sign(), "amount must be a deep copy")
+}
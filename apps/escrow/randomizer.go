@@ -0,0 +1,45 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package escrow
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Like the HTLC app, the
+// escrow app does not set itself as the global app randomizer.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp returns an escrow app with a random address. The app is not
+// registered in the app registry, see SetAppDef.
+func (*Randomizer) NewRandomApp(rng *rand.Rand) channel.App {
+	return &App{Addr: wallettest.NewRandomAddress(rng)}
+}
+
+// NewRandomData returns random escrow Data, see NewRandomData.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	return NewRandomData(rng)
+}
+
+// NewRandomData returns the Data of a random pending escrow of the first
+// asset. The amount is between 1 and 1000 and the deadline is between one
+// minute and one week from now.
+func NewRandomData(rng *rand.Rand) *Data {
+	return &Data{
+		Asset:    0,
+		Amount:   big.NewInt(rng.Int63n(1000) + 1),
+		Deadline: uint64(time.Now().Add(time.Duration(rng.Int63n(int64(7*24*time.Hour))) + time.Minute).Unix()),
+		Status:   Pending,
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package escrow
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+)
+
+func TestRandomizer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r := new(Randomizer)
+	assert.IsType(t, &App{}, r.NewRandomApp(rng))
+	assert.IsType(t, &Data{}, r.NewRandomData(rng))
+
+	d := NewRandomData(rng)
+	assert.Equal(t, 1, d.Amount.Sign())
+	assert.Equal(t, Pending, d.Status)
+	assert.Greater(t, d.Deadline, uint64(time.Now().Unix()))
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package htlc implements the hash time-locked contract (HTLC) app.
+//
+// A participant locks an amount under a hash lock and an expiry. Before the
+// expiry, the receiver claims it by revealing the preimage of the hash lock in
+// the next update. After the expiry, only the sender can take it back, so a
+// claim cannot race a refund. Locked amounts stay in the sender's balance
+// until they are claimed, so a channel that is settled with pending locks
+// refunds them to their senders.
+package htlc // import "perun.network/go-perun/apps/htlc"
+
+import (
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// App is the HTLC app.
+type App struct {
+	Addr wallet.Address
+
+	now func() time.Time // clock for expiry checks, time.Now if nil
+}
+
+var _ channel.StateApp = (*App)(nil)
+
+// SetAppDef registers the HTLC app under the given address in the app
+// registry, so that channels with this app definition use the HTLC app.
+func SetAppDef(def wallet.Address) {
+	channel.RegisterApp(&App{Addr: def})
+}
+
+// Def returns the address of the HTLC app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes HTLC Data from the reader.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var d Data
+	return &d, d.Decode(r)
+}
+
+// ValidInit checks that the initial state has no locks and preimages.
+func (a *App) ValidInit(p *channel.Params, s *channel.State) error {
+	d := asData(s)
+	if len(d.Locks) != 0 || len(d.Preimages) != 0 {
+		return channel.NewStateTransitionError(p.ID(), "initial state must not have locks or preimages")
+	}
+	return nil
+}
+
+// ValidTransition checks that the transition only adds, claims and refunds
+// locks:
+//   - New locks are created by their sender and are not yet expired.
+//   - Locks are claimed by their receiver before their expiry by revealing the
+//     preimage in the new state. The locked amount is moved from the sender to
+//     the receiver.
+//   - Locks are refunded by their sender after their expiry.
+//   - Pending locks are not modified and are covered by the sender's balance.
+//
+// No other balance changes are allowed. Note that the locked amounts remain
+// part of the sender's balance, see the package documentation.
+func (a *App) ValidTransition(p *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromData, toData := asData(from), asData(to)
+	newError := func(format string, args ...interface{}) error {
+		return channel.NewStateTransitionError(p.ID(), errors.Errorf(format, args...).Error())
+	}
+
+	if err := validLocks(len(p.Parts), to, toData); err != nil {
+		return channel.NewStateTransitionError(p.ID(), err.Error())
+	}
+
+	now := a.clock()
+	for _, l := range toData.Locks {
+		old, ok := fromData.Lock(l.HashLock)
+		if ok {
+			if !old.Equal(l) {
+				return newError("lock %x modified", l.HashLock)
+			}
+			continue
+		}
+		if l.Sender != actor {
+			return newError("lock %x not added by its sender", l.HashLock)
+		} else if !now.Before(expiry(l)) {
+			return newError("new lock %x already expired", l.HashLock)
+		}
+	}
+
+	// Expected balances after the claims.
+	bals := cloneBalances(from.Balances)
+	for _, l := range fromData.Locks {
+		if _, ok := toData.Lock(l.HashLock); ok {
+			continue
+		}
+		if _, claimed := toData.Revealed(l.HashLock); claimed {
+			if l.Receiver != actor {
+				return newError("lock %x not claimed by its receiver", l.HashLock)
+			} else if !now.Before(expiry(l)) {
+				return newError("lock %x claimed after expiry", l.HashLock)
+			}
+			bals[l.Asset][l.Sender].Sub(bals[l.Asset][l.Sender], l.Amount)
+			bals[l.Asset][l.Receiver].Add(bals[l.Asset][l.Receiver], l.Amount)
+		} else if l.Sender != actor {
+			return newError("lock %x not refunded by its sender", l.HashLock)
+		} else if now.Before(expiry(l)) {
+			return newError("lock %x refunded before expiry", l.HashLock)
+		}
+	}
+
+	for i, asset := range bals {
+		for j, bal := range asset {
+			if bal.Cmp(to.Balances[i][j]) != 0 {
+				return newError("balance of participant %d in asset %d is %v, expected %v",
+					j, i, to.Balances[i][j], bal)
+			}
+		}
+	}
+	return nil
+}
+
+// validLocks checks that the locks of the state are well-formed, have unique
+// hash locks and are covered by the balances of their senders.
+func validLocks(numParts int, s *channel.State, d *Data) error {
+	locked := make(map[[2]uint16]*big.Int)
+	seen := make(map[HashLock]bool)
+	for _, l := range d.Locks {
+		switch {
+		case int(l.Sender) >= numParts || int(l.Receiver) >= numParts:
+			return errors.Errorf("lock %x has invalid participant", l.HashLock)
+		case l.Sender == l.Receiver:
+			return errors.Errorf("lock %x has same sender and receiver", l.HashLock)
+		case int(l.Asset) >= len(s.Assets):
+			return errors.Errorf("lock %x has invalid asset", l.HashLock)
+		case l.Amount == nil || l.Amount.Sign() <= 0:
+			return errors.Errorf("lock %x has non-positive amount", l.HashLock)
+		case seen[l.HashLock]:
+			return errors.Errorf("duplicate lock %x", l.HashLock)
+		}
+		seen[l.HashLock] = true
+
+		key := [2]uint16{l.Asset, l.Sender}
+		if locked[key] == nil {
+			locked[key] = new(big.Int)
+		}
+		locked[key].Add(locked[key], l.Amount)
+	}
+
+	for key, amount := range locked {
+		if bal := s.Balances[key[0]][key[1]]; bal.Cmp(amount) < 0 {
+			return errors.Errorf("participant %d locks %v of asset %d but only has %v",
+				key[1], amount, key[0], bal)
+		}
+	}
+	return nil
+}
+
+func (a *App) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+func expiry(l Lock) time.Time {
+	return time.Unix(int64(l.Expiry), 0)
+}
+
+func cloneBalances(bals [][]channel.Bal) [][]channel.Bal {
+	clone := make([][]channel.Bal, len(bals))
+	for i, asset := range bals {
+		clone[i] = make([]channel.Bal, len(asset))
+		for j, bal := range asset {
+			clone[i][j] = new(big.Int).Set(bal)
+		}
+	}
+	return clone
+}
+
+func asData(s *channel.State) *Data {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		log.Panicf("htlc app must have data of type *Data, has type %T", s.Data)
+	}
+	return d
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package htlc
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// testNow is the fixed time of the test apps.
+var testNow = time.Unix(1600000000, 0)
+
+// newTestChannel creates an HTLC app with a fixed clock and a two-party
+// channel with a single asset, in which both participants hold 100.
+func newTestChannel(t *testing.T, rng *rand.Rand) (*App, *channel.Params, *channel.State) {
+	app := &App{Addr: wallettest.NewRandomAddress(rng), now: func() time.Time { return testNow }}
+	channel.RegisterApp(app)
+	params := test.NewRandomParams(rng, test.WithNumParts(2), test.WithApp(app))
+	state := test.NewRandomState(rng,
+		test.WithParams(params),
+		test.WithBalances([]channel.Bal{big.NewInt(100), big.NewInt(100)}),
+		test.WithNumLocked(0),
+		test.WithAppData(new(Data)),
+		test.WithIsFinal(false))
+	require.NoError(t, state.Valid())
+	return app, params, state
+}
+
+// newTestLock returns a lock of amount from participant 0 to 1 that expires
+// one hour after testNow.
+func newTestLock(rng *rand.Rand, amount int64) (Lock, Preimage) {
+	l, p := NewRandomLock(rng)
+	l.Amount = big.NewInt(amount)
+	l.Expiry = uint64(testNow.Add(time.Hour).Unix())
+	return l, p
+}
+
+// next returns a copy of the state with an incremented version.
+func next(s *channel.State) *channel.State {
+	n := s.Clone()
+	n.Version++
+	return n
+}
+
+func data(s *channel.State) *Data {
+	return s.Data.(*Data)
+}
+
+func TestApp_Def(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	def := wallettest.NewRandomAddress(rng)
+	app := &App{Addr: def}
+	assert.True(t, def.Equals(app.Def()))
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x4711))
+	app, params, state := newTestChannel(t, rng)
+
+	assert.NoError(t, app.ValidInit(params, state))
+
+	withLock := state.Clone()
+	l, _ := newTestLock(rng, 10)
+	data(withLock).Locks = append(data(withLock).Locks, l)
+	assert.Error(t, app.ValidInit(params, withLock))
+
+	wrongData := state.Clone()
+	wrongData.Data = new(channel.MockOp)
+	assert.Panics(t, func() { app.ValidInit(params, wrongData) })
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x4712))
+	app, params, initial := newTestChannel(t, rng)
+	lock, preimage := newTestLock(rng, 30)
+
+	// validFor asserts that the transition is only valid for the given actor,
+	// or for no actor if actor is -1.
+	validFor := func(t *testing.T, from, to *channel.State, actor int) {
+		for i := range params.Parts {
+			err := app.ValidTransition(params, from, to, channel.Index(i))
+			if i == actor {
+				assert.NoErrorf(t, err, "actor %d", i)
+			} else {
+				assert.Errorf(t, err, "actor %d", i)
+			}
+		}
+	}
+
+	// locked is the state after participant 0 added the lock.
+	locked := next(initial)
+	data(locked).Locks = []Lock{lock}
+
+	t.Run("add", func(t *testing.T) {
+		validFor(t, initial, locked, 0)
+
+		expired := next(initial)
+		l := lock.Clone()
+		l.Expiry = uint64(testNow.Unix())
+		data(expired).Locks = []Lock{l}
+		validFor(t, initial, expired, -1)
+
+		uncovered := next(initial)
+		l = lock.Clone()
+		l.Amount = big.NewInt(101)
+		data(uncovered).Locks = []Lock{l}
+		validFor(t, initial, uncovered, -1)
+
+		duplicate := next(initial)
+		data(duplicate).Locks = []Lock{lock, lock}
+		validFor(t, initial, duplicate, -1)
+
+		invalidAsset := next(initial)
+		l = lock.Clone()
+		l.Asset = 1
+		data(invalidAsset).Locks = []Lock{l}
+		validFor(t, initial, invalidAsset, -1)
+
+		paid := next(locked)
+		paid.Balances[0][0].Sub(paid.Balances[0][0], lock.Amount)
+		paid.Balances[0][1].Add(paid.Balances[0][1], lock.Amount)
+		validFor(t, initial, paid, -1)
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		modified := next(locked)
+		data(modified).Locks[0].Amount = big.NewInt(20)
+		validFor(t, locked, modified, -1)
+
+		// Locked funds cannot be spent.
+		spent := next(locked)
+		spent.Balances[0][0].Sub(spent.Balances[0][0], big.NewInt(80))
+		spent.Balances[0][1].Add(spent.Balances[0][1], big.NewInt(80))
+		validFor(t, locked, spent, -1)
+	})
+
+	t.Run("claim", func(t *testing.T) {
+		claimed := next(locked)
+		data(claimed).Locks = nil
+		data(claimed).Preimages = []Preimage{preimage}
+		claimed.Balances[0][0].Sub(claimed.Balances[0][0], lock.Amount)
+		claimed.Balances[0][1].Add(claimed.Balances[0][1], lock.Amount)
+		validFor(t, locked, claimed, 1)
+
+		unpaid := next(locked)
+		data(unpaid).Locks = nil
+		data(unpaid).Preimages = []Preimage{preimage}
+		validFor(t, locked, unpaid, -1)
+
+		wrongPreimage := claimed.Clone()
+		data(wrongPreimage).Preimages[0][0]++
+		validFor(t, locked, wrongPreimage, -1)
+
+		defer func(now func() time.Time) { app.now = now }(app.now)
+		app.now = func() time.Time { return testNow.Add(time.Hour) }
+		validFor(t, locked, claimed, -1) // expired
+	})
+
+	t.Run("refund", func(t *testing.T) {
+		refunded := next(locked)
+		data(refunded).Locks = nil
+		validFor(t, locked, refunded, -1) // not yet expired
+
+		defer func(now func() time.Time) { app.now = now }(app.now)
+		app.now = func() time.Time { return testNow.Add(time.Hour) }
+		validFor(t, locked, refunded, 0)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		to := next(initial)
+		to.Data = nil
+		assert.Panics(t, func() { app.ValidTransition(params, initial, to, 0) })
+	})
+}
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6E1))
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:        app,
+		Randomizer: new(Randomizer),
+		NextData:   nextData(),
+	})
+}
+
+// nextData returns a NextData for the generic test. The actor claims a lock of
+// which it is the receiver and the preimage is known, or adds a random lock to
+// another participant. The preimages of the added locks are remembered.
+func nextData() func(*rand.Rand, *channel.State, channel.Index) channel.Data {
+	preimages := make(map[HashLock]Preimage)
+	return func(rng *rand.Rand, s *channel.State, actor channel.Index) channel.Data {
+		d := data(s)
+		d.Preimages = nil
+		for i, l := range d.Locks {
+			if p, ok := preimages[l.HashLock]; ok && l.Receiver == actor && rng.Intn(2) == 0 {
+				d.Locks = append(d.Locks[:i], d.Locks[i+1:]...)
+				d.Preimages = append(d.Preimages, p)
+				s.Balances[l.Asset][l.Sender].Sub(s.Balances[l.Asset][l.Sender], l.Amount)
+				s.Balances[l.Asset][l.Receiver].Add(s.Balances[l.Asset][l.Receiver], l.Amount)
+				return d
+			}
+		}
+
+		if numParts := s.NumParts(); numParts > 1 {
+			l, p := NewRandomLock(rng)
+			l.Sender = actor
+			l.Receiver = channel.Index((int(actor) + 1 + rng.Intn(numParts-1)) % numParts)
+			preimages[l.HashLock] = p
+			d.Locks = append(d.Locks, l)
+		}
+		return d
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package htlc
+
+import (
+	"crypto/sha256"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// MaxNumLocks is the maximum number of pending locks of a channel.
+const MaxNumLocks = 1024
+
+type (
+	// Preimage is the secret that unlocks a hash lock.
+	Preimage [32]byte
+
+	// HashLock is the SHA-256 hash of a Preimage.
+	HashLock [32]byte
+
+	// Lock is a conditional payment of Amount of the asset with index Asset
+	// from Sender to Receiver. Receiver can claim it by revealing the preimage
+	// of HashLock. After Expiry, Sender can take it back.
+	Lock struct {
+		Sender   channel.Index
+		Receiver channel.Index
+		Asset    uint16      // index of the asset in the channel's allocation
+		Amount   channel.Bal // positive amount
+		HashLock HashLock
+		Expiry   uint64 // Unix time in seconds
+	}
+
+	// Data is the app data of an HTLC channel.
+	Data struct {
+		// Locks are the pending locks. Their hash locks are unique.
+		Locks []Lock
+		// Preimages are the preimages that are revealed in the update to this
+		// state to claim locks.
+		Preimages []Preimage
+	}
+)
+
+var _ channel.Data = (*Data)(nil)
+
+// HashLock returns the hash lock of the preimage.
+func (p Preimage) HashLock() HashLock {
+	return sha256.Sum256(p[:])
+}
+
+// NewPreimage reads a new random preimage from the given entropy source.
+func NewPreimage(rng io.Reader) (p Preimage, err error) {
+	_, err = io.ReadFull(rng, p[:])
+	return p, errors.WithMessage(err, "reading preimage")
+}
+
+// Clone returns a deep copy of the Lock.
+func (l Lock) Clone() Lock {
+	if l.Amount != nil {
+		l.Amount = new(big.Int).Set(l.Amount)
+	}
+	return l
+}
+
+// Equal returns whether two locks are equal.
+func (l Lock) Equal(m Lock) bool {
+	return l.Sender == m.Sender &&
+		l.Receiver == m.Receiver &&
+		l.Asset == m.Asset &&
+		l.Amount.Cmp(m.Amount) == 0 &&
+		l.HashLock == m.HashLock &&
+		l.Expiry == m.Expiry
+}
+
+// Encode encodes a Lock into an io.Writer.
+func (l Lock) Encode(w io.Writer) error {
+	return perunio.Encode(w, l.Sender, l.Receiver, l.Asset, l.Amount, [32]byte(l.HashLock), l.Expiry)
+}
+
+// Decode decodes a Lock from an io.Reader.
+func (l *Lock) Decode(r io.Reader) error {
+	return perunio.Decode(r, &l.Sender, &l.Receiver, &l.Asset, &l.Amount, (*[32]byte)(&l.HashLock), &l.Expiry)
+}
+
+// Lock returns the pending lock with the given hash lock.
+func (d *Data) Lock(h HashLock) (Lock, bool) {
+	for _, l := range d.Locks {
+		if l.HashLock == h {
+			return l, true
+		}
+	}
+	return Lock{}, false
+}
+
+// Revealed returns the revealed preimage of the given hash lock.
+func (d *Data) Revealed(h HashLock) (Preimage, bool) {
+	for _, p := range d.Preimages {
+		if p.HashLock() == h {
+			return p, true
+		}
+	}
+	return Preimage{}, false
+}
+
+// Clone returns a deep copy of the Data.
+func (d *Data) Clone() channel.Data {
+	if d == nil {
+		return nil
+	}
+	clone := &Data{
+		Locks:     make([]Lock, len(d.Locks)),
+		Preimages: append([]Preimage(nil), d.Preimages...),
+	}
+	for i, l := range d.Locks {
+		clone.Locks[i] = l.Clone()
+	}
+	return clone
+}
+
+// Encode encodes the Data into an io.Writer.
+func (d *Data) Encode(w io.Writer) error {
+	if len(d.Locks) > MaxNumLocks || len(d.Preimages) > MaxNumLocks {
+		return errors.Errorf("at most %d locks and preimages supported", MaxNumLocks)
+	}
+	if err := perunio.Encode(w, uint16(len(d.Locks))); err != nil {
+		return errors.WithMessage(err, "encoding number of locks")
+	}
+	for i, l := range d.Locks {
+		if err := l.Encode(w); err != nil {
+			return errors.WithMessagef(err, "encoding lock %d", i)
+		}
+	}
+	if err := perunio.Encode(w, uint16(len(d.Preimages))); err != nil {
+		return errors.WithMessage(err, "encoding number of preimages")
+	}
+	for i, p := range d.Preimages {
+		if err := perunio.Encode(w, [32]byte(p)); err != nil {
+			return errors.WithMessagef(err, "encoding preimage %d", i)
+		}
+	}
+	return nil
+}
+
+// Decode decodes Data from an io.Reader.
+func (d *Data) Decode(r io.Reader) error {
+	var numLocks uint16
+	if err := perunio.Decode(r, &numLocks); err != nil {
+		return errors.WithMessage(err, "decoding number of locks")
+	}
+	if numLocks > MaxNumLocks {
+		return errors.Errorf("expected at most %d locks, got %d", MaxNumLocks, numLocks)
+	}
+	if err := perunio.CheckRemaining(r, int(numLocks)); err != nil {
+		return errors.WithMessage(err, "decoding locks")
+	}
+	d.Locks = make([]Lock, numLocks)
+	for i := range d.Locks {
+		if err := d.Locks[i].Decode(r); err != nil {
+			return errors.WithMessagef(err, "decoding lock %d", i)
+		}
+	}
+
+	var numPreimages uint16
+	if err := perunio.Decode(r, &numPreimages); err != nil {
+		return errors.WithMessage(err, "decoding number of preimages")
+	}
+	if numPreimages > MaxNumLocks {
+		return errors.Errorf("expected at most %d preimages, got %d", MaxNumLocks, numPreimages)
+	}
+	if err := perunio.CheckRemaining(r, int(numPreimages)); err != nil {
+		return errors.WithMessage(err, "decoding preimages")
+	}
+	d.Preimages = make([]Preimage, numPreimages)
+	for i := range d.Preimages {
+		if err := perunio.Decode(r, (*[32]byte)(&d.Preimages[i])); err != nil {
+			return errors.WithMessagef(err, "decoding preimage %d", i)
+		}
+	}
+	return nil
+}
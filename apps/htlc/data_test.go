@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package htlc
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	perunio "perun.network/go-perun/pkg/io"
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func newRandomData(rng *rand.Rand, numLocks int) *Data {
+	d := new(Data)
+	for i := 0; i < numLocks; i++ {
+		l, p := NewRandomLock(rng)
+		d.Locks = append(d.Locks, l)
+		d.Preimages = append(d.Preimages, p)
+	}
+	return d
+}
+
+func TestData_Serializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xDA7A))
+	l, _ := NewRandomLock(rng)
+	iotest.GenericSerializerTest(t, &l, newRandomData(rng, 1), newRandomData(rng, 3))
+
+	var buf bytes.Buffer
+	require.NoError(t, perunio.Encode(&buf, uint16(MaxNumLocks+1)))
+	assert.Error(t, new(Data).Decode(&buf), "too many locks")
+}
+
+func TestData_Clone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xC10E))
+	d := newRandomData(rng, 2)
+	clone := d.Clone().(*Data)
+	assert.Equal(t, d, clone)
+
+	clone.Locks[0].Amount.SetInt64(0)
+	clone.Preimages[0][0]++
+	assert.NotEqual(t, 0, d.Locks[0].Amount.Sign(), "amounts must be deep copies")
+	assert.NotEqual(t, d.Preimages[0], clone.Preimages[0])
+}
+
+func TestData_Lookup(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x100C))
+	d := newRandomData(rng, 3)
+	for i, l := range d.Locks {
+		found, ok := d.Lock(l.HashLock)
+		assert.True(t, ok)
+		assert.True(t, l.Equal(found))
+		p, ok := d.Revealed(l.HashLock)
+		assert.True(t, ok)
+		assert.Equal(t, d.Preimages[i], p)
+	}
+
+	var unknown HashLock
+	_, ok := d.Lock(unknown)
+	assert.False(t, ok)
+	_, ok = d.Revealed(unknown)
+	assert.False(t, ok)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package htlc
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Unlike the payment app,
+// the HTLC app does not set itself as the global app randomizer, so tests
+// have to set it with test.SetAppRandomizer.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp returns an HTLC app with a random address. The app is not
+// registered in the app registry, see SetAppDef.
+func (*Randomizer) NewRandomApp(rng *rand.Rand) channel.App {
+	return &App{Addr: wallettest.NewRandomAddress(rng)}
+}
+
+// NewRandomData returns Data with up to four random locks, see NewRandomLock.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	d := new(Data)
+	for i := rng.Intn(5); i > 0; i-- {
+		l, _ := NewRandomLock(rng)
+		d.Locks = append(d.Locks, l)
+	}
+	return d
+}
+
+// NewRandomLock returns a random lock of the first asset from participant 0
+// to participant 1 together with its preimage. The amount is between 1 and
+// 1000 and the lock expires between one minute and one day from now.
+func NewRandomLock(rng *rand.Rand) (Lock, Preimage) {
+	p, err := NewPreimage(rng)
+	if err != nil {
+		panic(err) // math/rand never fails
+	}
+	return Lock{
+		Sender:   0,
+		Receiver: 1,
+		Asset:    0,
+		Amount:   big.NewInt(rng.Int63n(1000) + 1),
+		HashLock: p.HashLock(),
+		Expiry:   uint64(time.Now().Add(time.Duration(rng.Int63n(int64(24*time.Hour))) + time.Minute).Unix()),
+	}, p
+}
@@ -26,15 +26,27 @@ func (a *App) Def() wallet.Address {
 	return a.Addr
 }
 
-// DecodeData does not read anything from the reader and returns new NoData.
-func (a *App) DecodeData(io.Reader) (channel.Data, error) {
-	return new(NoData), nil
+// DecodeData decodes NoData or Metadata from the reader. NoData is encoded as
+// no bytes, so an empty reader decodes to NoData. Non-empty data starts with
+// the Metadata type.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var typ [1]byte
+	if _, err := io.ReadFull(r, typ[:]); err == io.EOF {
+		return new(NoData), nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "decoding data type")
+	}
+	if dataType(typ[0]) != metadataType {
+		return nil, errors.Errorf("unknown payment data type %d", typ[0])
+	}
+	var m Metadata
+	return &m, m.decodeFields(r)
 }
 
 // ValidTransition checks that money flows only from the actor to the other
-// participants.
+// participants. The new state may carry Metadata about the payment.
 func (a *App) ValidTransition(_ *channel.Params, from, to *channel.State, actor channel.Index) error {
-	assertNoData(to)
+	assertData(to)
 	for i, asset := range from.Balances {
 		for j, bal := range asset {
 			if int(actor) == j && bal.Cmp(to.Balances[i][j]) == -1 {
@@ -47,20 +59,27 @@ func (a *App) ValidTransition(_ *channel.Params, from, to *channel.State, actor
 	return nil
 }
 
-// ValidInit panics if State.Data is not *NoData and returns nil otherwise. Any
-// valid allocation forms a valid initial state.
+// ValidInit panics if State.Data is neither *NoData nor *Metadata and returns
+// nil otherwise. Any valid allocation forms a valid initial state.
 func (a *App) ValidInit(_ *channel.Params, s *channel.State) error {
-	assertNoData(s)
+	assertData(s)
 	return nil
 }
 
-func assertNoData(s *channel.State) {
-	_, ok := s.Data.(*NoData)
-	if !ok {
-		log.Panicf("payment app must have no data (new(NoData)), has type %T", s.Data)
+func assertData(s *channel.State) {
+	switch s.Data.(type) {
+	case *NoData, *Metadata:
+	default:
+		log.Panicf("payment app must have data of type *NoData or *Metadata, has type %T", s.Data)
 	}
 }
 
+// dataType is the leading byte of non-empty encoded payment data.
+type dataType uint8
+
+// metadataType is the data type of Metadata.
+const metadataType dataType = 1
+
 // NoData represents empty app data.
 type NoData struct{}
 
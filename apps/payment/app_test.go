@@ -35,6 +35,8 @@ func TestApp_ValidInit(t *testing.T) {
 
 	nodata := &channel.State{Data: new(NoData)}
 	assert.Nil(app.ValidInit(nil, nodata))
+	metadata := &channel.State{Data: &Metadata{InvoiceID: "42"}}
+	assert.Nil(app.ValidInit(nil, metadata))
 }
 
 func TestApp_ValidTransition(t *testing.T) {
@@ -99,6 +101,13 @@ func TestApp_ValidTransition(t *testing.T) {
 		})
 	}
 
+	t.Run("metadata", func(t *testing.T) {
+		from := test.NewRandomState(rng, test.WithApp(app), test.WithAppData(new(NoData)), test.WithBalances(asBalances(tests[0].from...)...), test.WithNumAssets(len(tests[0].from)))
+		to := test.NewRandomState(rng, test.WithApp(app), test.WithAppData(NewRandomMetadata(rng)), test.WithBalances(asBalances(tests[0].tos[1].alloc...)...), test.WithNumAssets(len(tests[0].from)))
+		assert.NoError(t, app.ValidTransition(nil, from, to, 0))
+		assert.Error(t, app.ValidTransition(nil, from, to, 1))
+	})
+
 	t.Run("panic", func(t *testing.T) {
 		from := test.NewRandomState(rng, test.WithApp(app), test.WithBalances(asBalances(tests[0].from...)...), test.WithNumAssets(len(tests[0].from)))
 		to := from.Clone()
@@ -120,3 +129,14 @@ func asBalances(rawBals ...[]int64) [][]channel.Bal {
 	}
 	return ret
 }
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xA99))
+	app := &App{wallettest.NewRandomAddress(rng)}
+	channel.RegisterApp(app)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:         app,
+		Randomizer:  new(Randomizer),
+		PaymentLike: true,
+	})
+}
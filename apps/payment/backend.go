@@ -6,6 +6,8 @@
 package payment
 
 import (
+	"sync"
+
 	"github.com/pkg/errors"
 
 	"perun.network/go-perun/channel"
@@ -16,20 +18,23 @@ import (
 var backend *Backend
 
 // Backend is the payment app backend. The payment app's address has to be set
-// once before using the app by calling SetAppDef().
+// once before using the app by calling SetAppDef(). Backend is safe for
+// concurrent use.
 type Backend struct {
-	def wallet.Address
+	mutex sync.RWMutex
+	def   wallet.Address
 }
 
 // AppFromDefinition returns a payment app if def matches the address set
 // before and an error otherwise.
 func (b *Backend) AppFromDefinition(def wallet.Address) (channel.App, error) {
-	if b.def == nil {
+	appDef := b.AppDef()
+	if appDef == nil {
 		panic("def is nil")
 	}
 
-	if !b.def.Equals(def) {
-		return nil, errors.Errorf("payment app has address %v, not %v", b.def, def)
+	if !appDef.Equals(def) {
+		return nil, errors.Errorf("payment app has address %v, not %v", appDef, def)
 	}
 
 	return &App{def}, nil
@@ -38,14 +43,23 @@ func (b *Backend) AppFromDefinition(def wallet.Address) (channel.App, error) {
 // AppFromDefinition returns a payment app if def matches the address set
 // before and an error otherwise.
 func AppFromDefinition(def wallet.Address) (channel.App, error) {
-	if backend.def == nil {
+	if backend.AppDef() == nil {
 		panic("set the payment app's address once with SetAppDef before calling AppFromDefinition")
 	}
 	return backend.AppFromDefinition(def)
 }
 
+// isAppDef returns whether def is the address of the payment app. It is the
+// predicate of the backend's registration in the app registry.
+func (b *Backend) isAppDef(def wallet.Address) bool {
+	appDef := b.AppDef()
+	return appDef != nil && appDef.Equals(def)
+}
+
 // SetAppDef sets the address of the payment app.
 func (b *Backend) SetAppDef(def wallet.Address) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 	b.def = def
 }
 
@@ -58,13 +72,16 @@ func SetAppDef(def wallet.Address) {
 
 // AppDef gets the address of the payment app.
 func (b *Backend) AppDef() wallet.Address {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
 	return b.def
 }
 
 // AppDef gets the address of the payment app of the global app backend.
 func AppDef() wallet.Address {
-	if backend.def == nil {
+	def := backend.AppDef()
+	if def == nil {
 		panic("set the payment app's address once with SetAppDef before calling AppDef")
 	}
-	return backend.AppDef()
+	return def
 }
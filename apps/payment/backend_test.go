@@ -6,7 +6,9 @@
 package payment
 
 import (
+	"bytes"
 	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,6 +16,7 @@ import (
 
 	_ "perun.network/go-perun/backend/sim" // backend init
 	pkgtest "perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
 	"perun.network/go-perun/wallet/test"
 )
 
@@ -48,20 +51,40 @@ func TestBackend(t *testing.T) {
 func TestNoData(t *testing.T) {
 	assert := assert.New(t)
 
-	assert.NotPanics(func() {
-		data := new(NoData)
-		assert.Nil(data.Encode(nil))
-	})
+	data := new(NoData)
+	var buf bytes.Buffer
+	assert.NoError(data.Encode(&buf))
+	assert.Zero(buf.Len(), "NoData is encoded as no bytes")
 
-	assert.NotPanics(func() {
-		app := new(App)
-		data, err := app.DecodeData(nil)
-		assert.NoError(err)
-		assert.NotNil(data)
-		assert.IsType(&NoData{}, data)
-	})
+	app := new(App)
+	decoded, err := app.DecodeData(&buf)
+	assert.NoError(err)
+	assert.NotNil(decoded)
+	assert.IsType(&NoData{}, decoded)
 
-	data := new(NoData)
 	clone := data.Clone()
 	assert.IsType(data, clone)
 }
+
+func TestBackend_Concurrent(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	b := new(Backend)
+	defs := []wallet.Address{test.NewRandomAddress(rng), test.NewRandomAddress(rng)}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.SetAppDef(defs[i%2])
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.isAppDef(defs[0])
+		}
+	}()
+	wg.Wait()
+	assert.True(t, b.isAppDef(defs[1]))
+}
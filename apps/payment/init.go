@@ -12,6 +12,6 @@ import (
 
 func init() {
 	backend = new(Backend)
-	channel.SetAppBackend(backend)
+	channel.RegisterAppBackend(backend.isAppDef, backend)
 	test.SetAppRandomizer(new(Randomizer))
 }
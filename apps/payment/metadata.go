@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package payment
+
+import (
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// Metadata describes the payment of a channel update. It lets the receiver of
+// an update match the payment to an invoice or order, e.g., in an
+// UpdateHandler. The metadata is not checked by the payment app.
+type Metadata struct {
+	InvoiceID string
+	Memo      string
+	Timestamp time.Time // creation time of the payment
+}
+
+var _ channel.Data = (*Metadata)(nil)
+
+// MetadataOf returns the payment metadata of a state of a payment channel. It
+// returns false if the state has no metadata.
+func MetadataOf(s *channel.State) (*Metadata, bool) {
+	m, ok := s.Data.(*Metadata)
+	return m, ok
+}
+
+// Clone returns a copy of the Metadata.
+func (m *Metadata) Clone() channel.Data {
+	if m == nil {
+		return nil
+	}
+	clone := *m
+	return &clone
+}
+
+// Encode encodes the data type and Metadata into an io.Writer.
+func (m *Metadata) Encode(w io.Writer) error {
+	return perunio.Encode(w, uint8(metadataType), m.InvoiceID, m.Memo, m.Timestamp)
+}
+
+// Decode decodes the data type and Metadata from an io.Reader.
+func (m *Metadata) Decode(r io.Reader) error {
+	var typ uint8
+	if err := perunio.Decode(r, &typ); err != nil {
+		return errors.WithMessage(err, "decoding data type")
+	} else if dataType(typ) != metadataType {
+		return errors.Errorf("expected metadata type %d, got %d", metadataType, typ)
+	}
+	return m.decodeFields(r)
+}
+
+func (m *Metadata) decodeFields(r io.Reader) error {
+	return perunio.Decode(r, &m.InvoiceID, &m.Memo, &m.Timestamp)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package payment
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestMetadata(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xAE7A))
+	m := NewRandomMetadata(rng)
+	iotest.GenericSerializerTest(t, m)
+
+	clone := m.Clone()
+	assert.Equal(t, m, clone)
+	clone.(*Metadata).Memo = "changed"
+	assert.NotEqual(t, m, clone)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Encode(&buf))
+	data, err := new(App).DecodeData(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, m, data)
+
+	_, ok := MetadataOf(&channel.State{Data: m})
+	assert.True(t, ok)
+	_, ok = MetadataOf(&channel.State{Data: new(NoData)})
+	assert.False(t, ok)
+}
+
+func TestApp_DecodeData(t *testing.T) {
+	_, err := new(App).DecodeData(bytes.NewReader([]byte{42}))
+	assert.Error(t, err, "unknown data type")
+
+	_, err = new(App).DecodeData(bytes.NewReader([]byte{0}))
+	assert.Error(t, err, "non-empty data without metadata type")
+
+	data, err := new(App).DecodeData(bytes.NewReader(nil))
+	assert.NoError(t, err)
+	assert.IsType(t, new(NoData), data, "empty data")
+
+	assert.Error(t, new(Metadata).Decode(bytes.NewReader([]byte{0})))
+}
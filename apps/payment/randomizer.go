@@ -6,7 +6,9 @@
 package payment
 
 import (
+	"fmt"
 	"math/rand"
+	"time"
 
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/channel/test"
@@ -23,7 +25,19 @@ func (*Randomizer) NewRandomApp(*rand.Rand) channel.App {
 	return &App{AppDef()}
 }
 
-// NewRandomData returns NoData because a PaymentApp does not have data.
-func (*Randomizer) NewRandomData(*rand.Rand) channel.Data {
-	return new(NoData)
+// NewRandomData returns either NoData or random Metadata.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	if rng.Intn(2) == 0 {
+		return new(NoData)
+	}
+	return NewRandomMetadata(rng)
+}
+
+// NewRandomMetadata returns random payment Metadata.
+func NewRandomMetadata(rng *rand.Rand) *Metadata {
+	return &Metadata{
+		InvoiceID: fmt.Sprintf("inv-%d", rng.Uint32()),
+		Memo:      fmt.Sprintf("memo %x", rng.Uint64()),
+		Timestamp: time.Unix(0, rng.Int63()),
+	}
 }
@@ -27,5 +27,11 @@ func TestRandomizer(t *testing.T) {
 	r := new(Randomizer)
 	app := r.NewRandomApp(rng)
 	assert.True(t, app.Def().Equals(AppDef()))
-	assert.IsType(t, &NoData{}, r.NewRandomData(rng))
+	for i := 0; i < 10; i++ {
+		switch data := r.NewRandomData(rng).(type) {
+		case *NoData, *Metadata:
+		default:
+			t.Errorf("unexpected data type %T", data)
+		}
+	}
 }
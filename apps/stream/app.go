@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package stream implements the streaming micropayment app.
+//
+// The initial Data of a stream channel sets the payer, the payee, the asset
+// and the rate per second at which the payer pays the payee from the start
+// time on. Each update settles the payment for the elapsed time by raising
+// the total paid amount and moving the difference from the payer to the
+// payee. The payee may charge at most the amount that is due at the time of
+// the update, the payer may pay ahead. SendPayments sends these updates
+// periodically for the payer.
+package stream // import "perun.network/go-perun/apps/stream"
+
+import (
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// App is the stream app.
+type App struct {
+	Addr wallet.Address
+
+	now func() time.Time // clock for due amounts, time.Now if nil
+}
+
+var _ channel.StateApp = (*App)(nil)
+
+// SetAppDef registers the stream app under the given address in the app
+// registry, so that channels with this app definition use the stream app.
+func SetAppDef(def wallet.Address) {
+	channel.RegisterApp(&App{Addr: def})
+}
+
+// Def returns the address of the stream app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes stream Data from the reader.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var d Data
+	return &d, d.Decode(r)
+}
+
+// ValidInit checks that the stream terms are valid and nothing is paid yet.
+func (a *App) ValidInit(p *channel.Params, s *channel.State) error {
+	d := asData(s)
+	newError := func(msg string) error {
+		return channel.NewStateTransitionError(p.ID(), msg)
+	}
+
+	switch {
+	case int(d.Payer) >= len(p.Parts) || int(d.Payee) >= len(p.Parts):
+		return newError("invalid payer or payee")
+	case d.Payer == d.Payee:
+		return newError("payer and payee must differ")
+	case int(d.Asset) >= len(s.Assets):
+		return newError("invalid asset")
+	case d.Rate == nil || d.Rate.Sign() <= 0:
+		return newError("rate must be positive")
+	case d.Paid == nil || d.Paid.Sign() != 0:
+		return newError("initial paid amount must be zero")
+	}
+	return nil
+}
+
+// ValidTransition checks that the stream terms are unchanged and that the
+// paid amount does not decrease. The increase of the paid amount must be
+// moved from the payer to the payee, other balances must not change. If the
+// payee is the actor, the paid amount must not exceed the amount that is due
+// at the current time. Only the payer and payee can raise the paid amount.
+func (a *App) ValidTransition(p *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromData, toData := asData(from), asData(to)
+	newError := func(format string, args ...interface{}) error {
+		return channel.NewStateTransitionError(p.ID(), errors.Errorf(format, args...).Error())
+	}
+
+	if !fromData.sameTerms(toData) {
+		return newError("stream terms changed")
+	}
+	if toData.Paid == nil {
+		return newError("paid amount missing")
+	}
+	paid := new(big.Int).Sub(toData.Paid, fromData.Paid)
+	switch paid.Sign() {
+	case -1:
+		return newError("paid amount decreased from %v to %v", fromData.Paid, toData.Paid)
+	case 1:
+		if actor != toData.Payer && actor != toData.Payee {
+			return newError("participant %d is neither payer nor payee", actor)
+		}
+	}
+	if actor == toData.Payee {
+		if due := toData.Due(a.clock()); toData.Paid.Cmp(due) > 0 {
+			return newError("payee charges %v, but only %v is due", toData.Paid, due)
+		}
+	}
+
+	bals := cloneBalances(from.Balances)
+	if err := transfer(bals, toData, paid); err != nil {
+		return channel.NewStateTransitionError(p.ID(), err.Error())
+	}
+	for i, asset := range bals {
+		for j, bal := range asset {
+			if bal.Cmp(to.Balances[i][j]) != 0 {
+				return newError("balance of participant %d in asset %d is %v, expected %v",
+					j, i, to.Balances[i][j], bal)
+			}
+		}
+	}
+	return nil
+}
+
+// Pay sets the paid amount of the state to the amount that is due at time t
+// and moves the difference from the payer to the payee. It returns the paid
+// difference, which is zero if the state already pays for time t. It returns
+// an error if the payer's balance does not cover the difference. The state's
+// version is not changed.
+func (a *App) Pay(s *channel.State, t time.Time) (*big.Int, error) {
+	d := asData(s)
+	paid := new(big.Int).Sub(d.Due(t), d.Paid)
+	if paid.Sign() <= 0 {
+		return new(big.Int), nil
+	}
+	if err := transfer(s.Balances, d, paid); err != nil {
+		return nil, err
+	}
+	d.Paid = new(big.Int).Add(d.Paid, paid)
+	return paid, nil
+}
+
+// transfer moves amount of the stream's asset from the payer to the payee.
+func transfer(bals [][]channel.Bal, d *Data, amount *big.Int) error {
+	asset := bals[d.Asset]
+	if asset[d.Payer].Cmp(amount) < 0 {
+		return errors.Errorf("payer's balance %v does not cover %v", asset[d.Payer], amount)
+	}
+	asset[d.Payer].Sub(asset[d.Payer], amount)
+	asset[d.Payee].Add(asset[d.Payee], amount)
+	return nil
+}
+
+func (a *App) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+func cloneBalances(bals [][]channel.Bal) [][]channel.Bal {
+	clone := make([][]channel.Bal, len(bals))
+	for i, asset := range bals {
+		clone[i] = make([]channel.Bal, len(asset))
+		for j, bal := range asset {
+			clone[i][j] = new(big.Int).Set(bal)
+		}
+	}
+	return clone
+}
+
+func asData(s *channel.State) *Data {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		log.Panicf("stream app must have data of type *Data, has type %T", s.Data)
+	}
+	return d
+}
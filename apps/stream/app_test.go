@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// testStart is the start time of the test streams.
+var testStart = time.Unix(1600000000, 0)
+
+// newTestChannel creates a stream app, whose clock is 10 seconds after
+// testStart, and a three-party channel with a single asset. Participant 0
+// streams 2 per second to participant 1, everybody holds 100.
+func newTestChannel(t *testing.T, rng *rand.Rand) (*App, *channel.Params, *channel.State) {
+	app := &App{
+		Addr: wallettest.NewRandomAddress(rng),
+		now:  func() time.Time { return testStart.Add(10 * time.Second) },
+	}
+	channel.RegisterApp(app)
+	params := test.NewRandomParams(rng, test.WithNumParts(3), test.WithApp(app))
+	state := test.NewRandomState(rng,
+		test.WithParams(params),
+		test.WithBalances([]channel.Bal{big.NewInt(100), big.NewInt(100), big.NewInt(100)}),
+		test.WithNumLocked(0),
+		test.WithAppData(&Data{
+			Payer: 0,
+			Payee: 1,
+			Asset: 0,
+			Rate:  big.NewInt(2),
+			Start: uint64(testStart.Unix()),
+			Paid:  new(big.Int),
+		}),
+		test.WithIsFinal(false))
+	require.NoError(t, state.Valid())
+	return app, params, state
+}
+
+// next returns a copy of the state with an incremented version.
+func next(s *channel.State) *channel.State {
+	n := s.Clone()
+	n.Version++
+	return n
+}
+
+func TestApp_Def(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	def := wallettest.NewRandomAddress(rng)
+	app := &App{Addr: def}
+	assert.True(t, def.Equals(app.Def()))
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x57E1))
+	app, params, state := newTestChannel(t, rng)
+
+	assert.NoError(t, app.ValidInit(params, state))
+
+	invalid := []func(*Data){
+		func(d *Data) { d.Payee = 0 },
+		func(d *Data) { d.Payee = 3 },
+		func(d *Data) { d.Asset = 1 },
+		func(d *Data) { d.Rate = big.NewInt(0) },
+		func(d *Data) { d.Rate = nil },
+		func(d *Data) { d.Paid = big.NewInt(1) },
+	}
+	for i, modify := range invalid {
+		s := state.Clone()
+		modify(asData(s))
+		assert.Errorf(t, app.ValidInit(params, s), "data %d", i)
+	}
+
+	wrongData := state.Clone()
+	wrongData.Data = new(channel.MockOp)
+	assert.Panics(t, func() { app.ValidInit(params, wrongData) })
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x57E2))
+	app, params, initial := newTestChannel(t, rng)
+
+	// validFor asserts that the transition is only valid for the given actors.
+	validFor := func(t *testing.T, from, to *channel.State, actors ...int) {
+		for i := range params.Parts {
+			err := app.ValidTransition(params, from, to, channel.Index(i))
+			valid := false
+			for _, a := range actors {
+				valid = valid || a == i
+			}
+			if valid {
+				assert.NoErrorf(t, err, "actor %d", i)
+			} else {
+				assert.Errorf(t, err, "actor %d", i)
+			}
+		}
+	}
+
+	// pay returns the next state, in which amount more is paid.
+	pay := func(s *channel.State, amount int64) *channel.State {
+		n := next(s)
+		d := asData(n)
+		d.Paid = new(big.Int).Add(d.Paid, big.NewInt(amount))
+		n.Balances[0][0].Sub(n.Balances[0][0], big.NewInt(amount))
+		n.Balances[0][1].Add(n.Balances[0][1], big.NewInt(amount))
+		return n
+	}
+
+	t.Run("pay", func(t *testing.T) {
+		// 20 are due after 10 seconds.
+		validFor(t, initial, pay(initial, 5), 0, 1)
+		validFor(t, initial, pay(initial, 20), 0, 1)
+		validFor(t, initial, pay(initial, 21), 0)    // overcharge by payee
+		validFor(t, initial, pay(initial, 101))      // uncovered
+		validFor(t, initial, next(initial), 0, 1, 2) // nothing paid
+		validFor(t, pay(initial, 5), pay(pay(initial, 5), 15), 0, 1)
+
+		decreased := pay(initial, 10)
+		validFor(t, decreased, pay(decreased, -5))
+
+		wrongBalance := pay(initial, 10)
+		wrongBalance.Balances[0][2].Add(wrongBalance.Balances[0][2], big.NewInt(1))
+		wrongBalance.Balances[0][1].Sub(wrongBalance.Balances[0][1], big.NewInt(1))
+		validFor(t, initial, wrongBalance)
+
+		unpaid := next(initial)
+		unpaid.Balances[0][0].SetInt64(90)
+		unpaid.Balances[0][1].SetInt64(110)
+		validFor(t, initial, unpaid)
+	})
+
+	t.Run("terms", func(t *testing.T) {
+		changed := []func(*Data){
+			func(d *Data) { d.Payer = 2 },
+			func(d *Data) { d.Payee = 2 },
+			func(d *Data) { d.Asset = 1 },
+			func(d *Data) { d.Rate = big.NewInt(3) },
+			func(d *Data) { d.Rate = nil },
+			func(d *Data) { d.Start-- },
+		}
+		for _, modify := range changed {
+			s := next(initial)
+			modify(asData(s))
+			validFor(t, initial, s)
+		}
+
+		noPaid := next(initial)
+		asData(noPaid).Paid = nil
+		validFor(t, initial, noPaid)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		to := next(initial)
+		to.Data = nil
+		assert.Panics(t, func() { app.ValidTransition(params, initial, to, 0) })
+	})
+}
+
+func TestApp_Pay(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x57E3))
+	app, params, initial := newTestChannel(t, rng)
+
+	s := next(initial)
+	paid, err := app.Pay(s, testStart.Add(3*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(6), paid)
+	assert.Equal(t, big.NewInt(6), asData(s).Paid)
+	assert.Equal(t, big.NewInt(94), s.Balances[0][0])
+	assert.Equal(t, big.NewInt(106), s.Balances[0][1])
+	assert.NoError(t, app.ValidTransition(params, initial, s, 1))
+
+	paid, err = app.Pay(s, testStart.Add(3500*time.Millisecond))
+	require.NoError(t, err)
+	assert.Zero(t, paid.Sign(), "nothing due")
+
+	_, err = app.Pay(s, testStart.Add(51*time.Second))
+	assert.Error(t, err, "payer's balance does not cover 102")
+	assert.Equal(t, big.NewInt(6), asData(s).Paid, "state unchanged on error")
+}
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6E4))
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:        app,
+		Randomizer: new(Randomizer),
+		NextData:   nextData(app),
+	})
+}
+
+// nextData returns a NextData for the generic test, which pays the amount that
+// is due now.
+func nextData(app *App) func(*rand.Rand, *channel.State, channel.Index) channel.Data {
+	return func(_ *rand.Rand, s *channel.State, _ channel.Index) channel.Data {
+		app.Pay(s, app.clock()) //nolint:errcheck // an error leaves the state unchanged
+		return s.Data
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/client"
+)
+
+// SendPayments periodically pays for the elapsed time of the stream in the
+// channel, in which the caller must be the payer. Every interval, it proposes
+// an update that pays the amount that is due at that time, see App.Pay.
+// Nothing is sent if nothing is due.
+//
+// SendPayments blocks until the context is done, in which case it returns
+// nil, or until an update fails. In particular, it fails if the payer's
+// balance does not cover the due amount anymore. It fails right away if the
+// interval is not positive or the payer's balance does not cover the amount
+// of one interval.
+func SendPayments(ctx context.Context, ch *client.Channel, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.Errorf("payment interval must be positive, is %v", interval)
+	}
+	app, ok := ch.Params().App.(*App)
+	if !ok {
+		return errors.Errorf("channel runs app of type %T, not the stream app", ch.Params().App)
+	}
+	state := ch.State()
+	d := asData(state)
+	if d.Payer != ch.Idx() {
+		return errors.Errorf("participant %d is not the payer %d", ch.Idx(), d.Payer)
+	}
+	if amount, bal := intervalAmount(d, interval), state.Balances[d.Asset][d.Payer]; bal.Cmp(amount) < 0 {
+		return errors.Errorf("payer's balance %v does not cover the amount %v of one interval", bal, amount)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := sendPayment(ctx, ch, app); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// intervalAmount returns the amount that is due for one interval, which is
+// paid for at least one full second.
+func intervalAmount(d *Data, interval time.Duration) *big.Int {
+	secs := int64(interval / time.Second)
+	if interval%time.Second != 0 {
+		secs++
+	}
+	return new(big.Int).Mul(d.Rate, big.NewInt(secs))
+}
+
+// sendPayment proposes an update that pays the amount that is due now, if
+// any. The update is computed and validated before it is proposed, so that
+// nothing is proposed if the payment fails.
+func sendPayment(ctx context.Context, ch *client.Channel, app *App) error {
+	state := ch.State().Clone()
+	if paid, err := app.Pay(state, app.clock()); err != nil {
+		return err
+	} else if paid.Sign() == 0 {
+		return nil
+	}
+	state.Version++
+
+	err := ch.Update(ctx, client.ChannelUpdate{
+		State:    state,
+		ActorIdx: ch.Idx(),
+	})
+	return errors.WithMessage(err, "sending payment")
+}
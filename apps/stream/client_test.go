@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/stream"
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wiretest "perun.network/go-perun/wire/test"
+)
+
+const timeout = 5 * time.Second
+
+type (
+	// player is a client that takes part in a stream channel.
+	player struct {
+		*client.Client
+		id     wire.Account
+		wallet wtest.Wallet
+		done   sync.WaitGroup
+	}
+
+	// mockFunder funds all channels immediately.
+	mockFunder struct{}
+
+	// mockAdjudicator registers and withdraws all states immediately.
+	mockAdjudicator struct{}
+)
+
+func (mockFunder) Fund(context.Context, channel.FundingReq) error { return nil }
+
+func (mockAdjudicator) Register(_ context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	return &channel.RegisteredEvent{
+		ID:      req.Params.ID(),
+		Version: req.Tx.Version,
+		Timeout: &channel.ElapsedTimeout{},
+	}, nil
+}
+
+func (mockAdjudicator) Withdraw(context.Context, channel.AdjudicatorReq) error { return nil }
+
+func (mockAdjudicator) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
+	return nil, nil
+}
+
+// newPlayer creates a client on the hub that accepts all updates and handles
+// proposals with the handler returned by ph.
+func newPlayer(t *testing.T, rng *rand.Rand, hub *wiretest.ConnHub, ph func(*player) client.ProposalHandler) *player {
+	p := &player{id: wtest.NewRandomAccount(rng), wallet: wtest.NewWallet()}
+	p.Client = client.New(p.id, hub.NewNetDialer(), mockFunder{}, mockAdjudicator{}, p.wallet)
+	acceptAll := client.UpdateHandlerFunc(func(up client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		t.Logf("Player %d moved:\n%v", up.ActorIdx, up.State.Data)
+		assert.NoError(t, res.Accept(ctx))
+	})
+
+	listener := hub.NewNetListener(p.id.Address())
+	p.done.Add(2)
+	go func() { defer p.done.Done(); p.Listen(listener) }()
+	go func() { defer p.done.Done(); p.Handle(ph(p), acceptAll) }()
+	return p
+}
+
+func (p *player) close(t *testing.T) {
+	assert.NoError(t, p.Close())
+	p.done.Wait()
+}
+
+func TestSendPayments(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x57E4))
+	app := &stream.App{Addr: wtest.NewRandomAddress(rng)}
+	stream.SetAppDef(app.Addr)
+
+	var hub wiretest.ConnHub
+	payer := newPlayer(t, rng, &hub, func(*player) client.ProposalHandler {
+		return client.ProposalHandlerFunc(
+			func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				assert.NoError(t, res.Reject(ctx, "payer does not accept proposals"))
+			})
+	})
+	defer payer.close(t)
+	payeeChans := make(chan *client.Channel, 1)
+	payee := newPlayer(t, rng, &hub, func(payee *player) client.ProposalHandler {
+		return client.ProposalHandlerFunc(
+			func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				ch, err := res.Accept(ctx, client.ProposalAcc{
+					Participant: payee.wallet.NewRandomAccount(rng).Address(),
+				})
+				assert.NoError(t, err)
+				payeeChans <- ch
+			})
+	})
+	defer payee.close(t)
+
+	// The stream started ten seconds ago, so 30 are due right away.
+	start := time.Now().Add(-10 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ch, err := payer.ProposeChannel(ctx, &client.ChannelProposal{
+		Backend:           wallet.DefaultBackend(),
+		ChallengeDuration: 60,
+		Nonce:             big.NewInt(rng.Int63()),
+		ParticipantAddr:   payer.wallet.NewRandomAccount(rng).Address(),
+		AppDef:            app.Def(),
+		InitData: &stream.Data{
+			Payer: 0,
+			Payee: 1,
+			Asset: 0,
+			Rate:  big.NewInt(3),
+			Start: uint64(start.Unix()),
+			Paid:  new(big.Int),
+		},
+		InitBals: &channel.Allocation{
+			Assets:   []channel.Asset{chtest.NewRandomAsset(rng)},
+			Balances: [][]channel.Bal{{big.NewInt(1000), big.NewInt(0)}},
+		},
+		PeerAddrs: []wire.Address{payer.id.Address(), payee.id.Address()},
+	})
+	require.NoError(t, err)
+	payeeCh := <-payeeChans
+	require.NotNil(t, payeeCh)
+
+	assert.Error(t, stream.SendPayments(ctx, payeeCh, time.Millisecond), "payee is not the payer")
+	assert.Error(t, stream.SendPayments(ctx, ch, 0), "zero interval")
+	assert.Error(t, stream.SendPayments(ctx, ch, time.Hour), "balance does not cover one interval")
+
+	streamCtx, stop := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer stop()
+	assert.NoError(t, stream.SendPayments(streamCtx, ch, 10*time.Millisecond))
+	end := time.Now()
+
+	for _, c := range []*client.Channel{ch, payeeCh} {
+		s := c.State()
+		paid := s.Data.(*stream.Data).Paid
+		assert.GreaterOrEqual(t, paid.Int64(), int64(30))
+		assert.LessOrEqual(t, paid.Int64(), 3*(end.Unix()-start.Unix()))
+		assert.Equal(t, 1000-paid.Int64(), s.Balances[0][0].Int64())
+		assert.Equal(t, paid.Int64(), s.Balances[0][1].Int64())
+	}
+}
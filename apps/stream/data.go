@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream
+
+import (
+	"io"
+	"math/big"
+	"time"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// Data is the app data of a stream channel. The terms of the stream are set
+// in the initial state and cannot be changed. Only Paid is updated.
+type Data struct {
+	Payer channel.Index
+	Payee channel.Index
+	Asset uint16      // index of the asset in the channel's allocation
+	Rate  channel.Bal // amount per second
+	Start uint64      // Unix time in seconds at which the stream starts
+	Paid  channel.Bal // total amount that was paid so far
+}
+
+var _ channel.Data = (*Data)(nil)
+
+// Due returns the total amount that is due at time t, which is the rate times
+// the number of full seconds since the start.
+func (d *Data) Due(t time.Time) *big.Int {
+	if int64(d.Start) < 0 { // start too far in the future
+		return new(big.Int)
+	}
+	elapsed := t.Unix() - int64(d.Start)
+	if elapsed <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(d.Rate, big.NewInt(elapsed))
+}
+
+// sameTerms returns whether both Data have the same stream terms.
+func (d *Data) sameTerms(e *Data) bool {
+	return d.Payer == e.Payer &&
+		d.Payee == e.Payee &&
+		d.Asset == e.Asset &&
+		e.Rate != nil && d.Rate.Cmp(e.Rate) == 0 &&
+		d.Start == e.Start
+}
+
+// Clone returns a deep copy of the Data.
+func (d *Data) Clone() channel.Data {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	if d.Rate != nil {
+		clone.Rate = new(big.Int).Set(d.Rate)
+	}
+	if d.Paid != nil {
+		clone.Paid = new(big.Int).Set(d.Paid)
+	}
+	return &clone
+}
+
+// Encode encodes the Data into an io.Writer.
+func (d *Data) Encode(w io.Writer) error {
+	return perunio.Encode(w, d.Payer, d.Payee, d.Asset, d.Rate, d.Start, d.Paid)
+}
+
+// Decode decodes Data from an io.Reader.
+func (d *Data) Decode(r io.Reader) error {
+	return perunio.Decode(r, &d.Payer, &d.Payee, &d.Asset, &d.Rate, &d.Start, &d.Paid)
+}
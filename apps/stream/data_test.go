@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestData_Serializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x57EA))
+	d := NewRandomData(rng)
+	d.Paid.SetInt64(1234)
+	iotest.GenericSerializerTest(t, NewRandomData(rng), d)
+}
+
+func TestData_Clone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xC10E))
+	d := NewRandomData(rng)
+	clone := d.Clone().(*Data)
+	assert.Equal(t, d, clone)
+
+	clone.Rate.SetInt64(0)
+	clone.Paid.SetInt64(1)
+	assert.NotZero(t, d.Rate.Sign(), "rate must be a deep copy")
+	assert.Zero(t, d.Paid.Sign(), "paid amount must be a deep copy")
+}
+
+func TestData_Due(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	d := &Data{Rate: big.NewInt(3), Start: uint64(start.Unix()), Paid: new(big.Int)}
+
+	assert.Zero(t, d.Due(start.Add(-time.Hour)).Sign())
+	assert.Zero(t, d.Due(start).Sign())
+	assert.Zero(t, d.Due(start.Add(999*time.Millisecond)).Sign())
+	assert.Equal(t, big.NewInt(3), d.Due(start.Add(time.Second)))
+	assert.Equal(t, big.NewInt(30), d.Due(start.Add(10500*time.Millisecond)))
+
+	d.Start = math.MaxUint64
+	assert.Zero(t, d.Due(start).Sign())
+}
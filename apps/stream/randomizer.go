@@ -0,0 +1,47 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package stream
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Like the HTLC app, the
+// stream app does not set itself as the global app randomizer.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp returns a stream app with a random address. The app is not
+// registered in the app registry, see SetAppDef.
+func (*Randomizer) NewRandomApp(rng *rand.Rand) channel.App {
+	return &App{Addr: wallettest.NewRandomAddress(rng)}
+}
+
+// NewRandomData returns random stream Data, see NewRandomData.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	return NewRandomData(rng)
+}
+
+// NewRandomData returns the Data of a random stream of the first asset from
+// participant 0 to participant 1. The rate is between 1 and 100 per second and
+// the stream started up to one hour ago. Nothing is paid yet.
+func NewRandomData(rng *rand.Rand) *Data {
+	return &Data{
+		Payer: 0,
+		Payee: 1,
+		Asset: 0,
+		Rate:  big.NewInt(rng.Int63n(100) + 1),
+		Start: uint64(time.Now().Add(-time.Duration(rng.Int63n(int64(time.Hour)))).Unix()),
+		Paid:  new(big.Int),
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package swap implements the atomic swap app for multi-asset channels.
+//
+// A participant offers to exchange an amount of one asset for an amount of
+// another asset with a counterparty. The offer is stored in the app data
+// without changing the balances. The counterparty accepts the offer by
+// executing both legs of the swap in the next update, which removes the
+// offer. Alternatively, the proposer or the counterparty cancel the offer by
+// removing it without changing the balances. Thus, a swap is either executed
+// completely or not at all.
+package swap // import "perun.network/go-perun/apps/swap"
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// App is the swap app.
+type App struct {
+	Addr wallet.Address
+}
+
+var _ channel.StateApp = (*App)(nil)
+
+// SetAppDef registers the swap app under the given address in the app
+// registry, so that channels with this app definition use the swap app.
+func SetAppDef(def wallet.Address) {
+	channel.RegisterApp(&App{Addr: def})
+}
+
+// Def returns the address of the swap app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes swap Data from the reader.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var d Data
+	return &d, d.Decode(r)
+}
+
+// ValidInit checks that the initial state has no pending offer.
+func (a *App) ValidInit(p *channel.Params, s *channel.State) error {
+	if asData(s).Offer != nil {
+		return channel.NewStateTransitionError(p.ID(), "initial state must not have an offer")
+	}
+	return nil
+}
+
+// ValidTransition checks that the transition either
+// * adds a valid offer by its proposer,
+// * executes the pending offer by its counterparty, which removes the offer,
+// * cancels the pending offer by the proposer or counterparty, or
+// * keeps the pending offer or the absence of an offer.
+// The balances only change when an offer is executed, and then exactly by
+// both legs of the swap.
+func (a *App) ValidTransition(p *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromOffer, toOffer := asData(from).Offer, asData(to).Offer
+	newError := func(format string, args ...interface{}) error {
+		return channel.NewStateTransitionError(p.ID(), errors.Errorf(format, args...).Error())
+	}
+	unchanged := equalBalances(from.Balances, to.Balances)
+
+	switch {
+	case fromOffer.Equal(toOffer):
+		if !unchanged {
+			return newError("balances changed without a swap")
+		}
+	case fromOffer == nil: // new offer
+		if toOffer.Proposer != actor {
+			return newError("offer not made by its proposer")
+		} else if err := validOffer(len(p.Parts), to, toOffer); err != nil {
+			return channel.NewStateTransitionError(p.ID(), err.Error())
+		} else if !unchanged {
+			return newError("balances changed by offer")
+		}
+	case toOffer != nil:
+		return newError("pending offer replaced")
+	case unchanged: // canceled
+		if actor != fromOffer.Proposer && actor != fromOffer.Counterparty {
+			return newError("offer canceled by participant %d, who is not part of it", actor)
+		}
+	default: // executed
+		if actor != fromOffer.Counterparty {
+			return newError("offer executed by participant %d instead of counterparty %d",
+				actor, fromOffer.Counterparty)
+		}
+		bals := cloneBalances(from.Balances)
+		if err := execute(bals, fromOffer); err != nil {
+			return channel.NewStateTransitionError(p.ID(), err.Error())
+		}
+		if !equalBalances(bals, to.Balances) {
+			return newError("balances do not match the executed swap")
+		}
+	}
+	return nil
+}
+
+// Propose adds the offer to the state. It returns an error if the state
+// already has a pending offer or the offer is invalid. The state's version is
+// not changed.
+func (a *App) Propose(s *channel.State, o Offer) error {
+	d := asData(s)
+	if d.Offer != nil {
+		return errors.New("state already has a pending offer")
+	}
+	if err := validOffer(s.NumParts(), s, &o); err != nil {
+		return err
+	}
+	d.Offer = o.Clone()
+	return nil
+}
+
+// Accept executes the pending offer of the state: both legs of the swap are
+// applied to the balances and the offer is removed. It returns an error if
+// there is no pending offer or the actor is not its counterparty. The state's
+// version is not changed.
+func (a *App) Accept(s *channel.State, actor channel.Index) error {
+	d := asData(s)
+	if d.Offer == nil {
+		return errors.New("no pending offer")
+	} else if actor != d.Offer.Counterparty {
+		return errors.Errorf("participant %d is not the counterparty of the offer", actor)
+	}
+	bals := cloneBalances(s.Balances)
+	if err := execute(bals, d.Offer); err != nil {
+		return err
+	}
+	s.Balances = bals
+	d.Offer = nil
+	return nil
+}
+
+// Cancel removes the pending offer of the state. It returns an error if there
+// is no pending offer. The state's version is not changed.
+func (a *App) Cancel(s *channel.State) error {
+	d := asData(s)
+	if d.Offer == nil {
+		return errors.New("no pending offer")
+	}
+	d.Offer = nil
+	return nil
+}
+
+// validOffer checks that the offer is well-formed and that the proposer holds
+// the amount it gives.
+func validOffer(numParts int, s *channel.State, o *Offer) error {
+	switch {
+	case int(o.Proposer) >= numParts || int(o.Counterparty) >= numParts:
+		return errors.New("offer has invalid participant")
+	case o.Proposer == o.Counterparty:
+		return errors.New("offer has same proposer and counterparty")
+	case int(o.Give.Asset) >= len(s.Assets) || int(o.Take.Asset) >= len(s.Assets):
+		return errors.New("offer has invalid asset")
+	case o.Give.Asset == o.Take.Asset:
+		return errors.New("offer swaps an asset for itself")
+	case o.Give.Amount == nil || o.Give.Amount.Sign() <= 0 ||
+		o.Take.Amount == nil || o.Take.Amount.Sign() <= 0:
+		return errors.New("offer has non-positive amount")
+	}
+	if bal := s.Balances[o.Give.Asset][o.Proposer]; bal.Cmp(o.Give.Amount) < 0 {
+		return errors.Errorf("proposer gives %v but only has %v", o.Give, bal)
+	}
+	return nil
+}
+
+// execute applies both legs of the offer to the balances. It returns an error
+// if a participant does not hold the amount it gives.
+func execute(bals [][]channel.Bal, o *Offer) error {
+	give, take := bals[o.Give.Asset], bals[o.Take.Asset]
+	if give[o.Proposer].Cmp(o.Give.Amount) < 0 {
+		return errors.Errorf("proposer gives %v but only has %v", o.Give, give[o.Proposer])
+	} else if take[o.Counterparty].Cmp(o.Take.Amount) < 0 {
+		return errors.Errorf("counterparty gives %v but only has %v", o.Take, take[o.Counterparty])
+	}
+	give[o.Proposer].Sub(give[o.Proposer], o.Give.Amount)
+	give[o.Counterparty].Add(give[o.Counterparty], o.Give.Amount)
+	take[o.Counterparty].Sub(take[o.Counterparty], o.Take.Amount)
+	take[o.Proposer].Add(take[o.Proposer], o.Take.Amount)
+	return nil
+}
+
+func equalBalances(a, b [][]channel.Bal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j].Cmp(b[i][j]) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func cloneBalances(bals [][]channel.Bal) [][]channel.Bal {
+	clone := make([][]channel.Bal, len(bals))
+	for i, asset := range bals {
+		clone[i] = make([]channel.Bal, len(asset))
+		for j, bal := range asset {
+			clone[i][j] = new(big.Int).Set(bal)
+		}
+	}
+	return clone
+}
+
+func asData(s *channel.State) *Data {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		log.Panicf("swap app must have data of type *Data, has type %T", s.Data)
+	}
+	return d
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package swap
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// newTestChannel creates a swap app and a two-party channel with two assets.
+// Participant 0 holds 100 of asset 0, participant 1 holds 50 of asset 1.
+func newTestChannel(t *testing.T, rng *rand.Rand) (*App, *channel.Params, *channel.State) {
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	params := test.NewRandomParams(rng, test.WithNumParts(2), test.WithApp(app))
+	state := test.NewRandomState(rng,
+		test.WithParams(params),
+		test.WithBalances(
+			[]channel.Bal{big.NewInt(100), big.NewInt(0)},
+			[]channel.Bal{big.NewInt(0), big.NewInt(50)}),
+		test.WithNumLocked(0),
+		test.WithAppData(new(Data)),
+		test.WithIsFinal(false))
+	require.NoError(t, state.Valid())
+	return app, params, state
+}
+
+// newTestOffer returns an offer of participant 0 to swap 60 of asset 0 for
+// 40 of asset 1.
+func newTestOffer() Offer {
+	return Offer{
+		Proposer:     0,
+		Counterparty: 1,
+		Give:         Leg{Asset: 0, Amount: big.NewInt(60)},
+		Take:         Leg{Asset: 1, Amount: big.NewInt(40)},
+	}
+}
+
+// next returns a copy of the state with an incremented version.
+func next(s *channel.State) *channel.State {
+	n := s.Clone()
+	n.Version++
+	return n
+}
+
+func TestApp_Def(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	def := wallettest.NewRandomAddress(rng)
+	app := &App{Addr: def}
+	assert.True(t, def.Equals(app.Def()))
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5A91))
+	app, params, state := newTestChannel(t, rng)
+
+	assert.NoError(t, app.ValidInit(params, state))
+
+	withOffer := state.Clone()
+	o := newTestOffer()
+	asData(withOffer).Offer = &o
+	assert.Error(t, app.ValidInit(params, withOffer))
+
+	wrongData := state.Clone()
+	wrongData.Data = new(channel.MockOp)
+	assert.Panics(t, func() { app.ValidInit(params, wrongData) })
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5A92))
+	app, params, initial := newTestChannel(t, rng)
+
+	// validFor asserts that the transition is only valid for the given actors.
+	validFor := func(t *testing.T, from, to *channel.State, actors ...int) {
+		for i := range params.Parts {
+			err := app.ValidTransition(params, from, to, channel.Index(i))
+			valid := false
+			for _, a := range actors {
+				valid = valid || a == i
+			}
+			if valid {
+				assert.NoErrorf(t, err, "actor %d", i)
+			} else {
+				assert.Errorf(t, err, "actor %d", i)
+			}
+		}
+	}
+
+	offered := next(initial)
+	require.NoError(t, app.Propose(offered, newTestOffer()))
+
+	t.Run("offer", func(t *testing.T) {
+		validFor(t, initial, offered, 0)
+		validFor(t, initial, next(initial), 0, 1) // no offer
+		validFor(t, offered, next(offered), 0, 1) // offer kept
+
+		invalid := []func(*Offer){
+			func(o *Offer) { o.Counterparty = 0 },
+			func(o *Offer) { o.Counterparty = 2 },
+			func(o *Offer) { o.Take.Asset = 0 },
+			func(o *Offer) { o.Take.Asset = 2 },
+			func(o *Offer) { o.Take.Amount = big.NewInt(0) },
+			func(o *Offer) { o.Give.Amount = big.NewInt(101) }, // uncovered
+		}
+		for i, modify := range invalid {
+			o := newTestOffer()
+			modify(&o)
+			s := next(initial)
+			asData(s).Offer = &o
+			validFor(t, initial, s)
+			assert.Errorf(t, app.Propose(initial.Clone(), o), "offer %d", i)
+		}
+
+		paid := offered.Clone()
+		paid.Balances[0][0].SetInt64(40)
+		paid.Balances[0][1].SetInt64(60)
+		validFor(t, initial, paid)
+
+		replaced := next(offered)
+		asData(replaced).Offer.Take.Amount = big.NewInt(1)
+		validFor(t, offered, replaced)
+
+		assert.Error(t, app.Propose(offered.Clone(), newTestOffer()), "pending offer")
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		executed := next(offered)
+		assert.Error(t, app.Accept(executed.Clone(), 0), "not the counterparty")
+		require.NoError(t, app.Accept(executed, 1))
+		assert.Nil(t, asData(executed).Offer)
+		assert.Equal(t, [][]int64{{40, 60}, {40, 10}}, int64Bals(executed))
+		validFor(t, offered, executed, 1)
+
+		// A half-executed swap is invalid.
+		half := next(offered)
+		asData(half).Offer = nil
+		half.Balances[0][0].SetInt64(40)
+		half.Balances[0][1].SetInt64(60)
+		validFor(t, offered, half)
+
+		// The counterparty must hold the amount it gives.
+		poor := offered.Clone()
+		poor.Balances[1][1].SetInt64(30)
+		poor.Balances[1][0].SetInt64(20)
+		assert.Error(t, app.Accept(poor.Clone(), 1))
+		poorExecuted := next(poor)
+		asData(poorExecuted).Offer = nil
+		poorExecuted.Balances = [][]channel.Bal{
+			{big.NewInt(40), big.NewInt(60)},
+			{big.NewInt(60), big.NewInt(-10)},
+		}
+		validFor(t, poor, poorExecuted)
+
+		assert.Error(t, app.Accept(next(initial), 1), "no pending offer")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		canceled := next(offered)
+		require.NoError(t, app.Cancel(canceled))
+		validFor(t, offered, canceled, 0, 1)
+		assert.Error(t, app.Cancel(canceled), "no pending offer")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		to := next(initial)
+		to.Data = nil
+		assert.Panics(t, func() { app.ValidTransition(params, initial, to, 0) })
+	})
+}
+
+func int64Bals(s *channel.State) [][]int64 {
+	bals := make([][]int64, len(s.Balances))
+	for i, asset := range s.Balances {
+		for _, bal := range asset {
+			bals[i] = append(bals[i], bal.Int64())
+		}
+	}
+	return bals
+}
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6E3))
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:        app,
+		Randomizer: new(Randomizer),
+		NextData:   nextData(app),
+	})
+}
+
+// nextData returns a NextData for the generic test. If there is a pending
+// offer, the actor accepts or cancels it. Otherwise, the actor proposes a
+// random offer to another participant.
+func nextData(app *App) func(*rand.Rand, *channel.State, channel.Index) channel.Data {
+	return func(rng *rand.Rand, s *channel.State, actor channel.Index) channel.Data {
+		switch numParts := s.NumParts(); {
+		case asData(s).Offer != nil && rng.Intn(2) == 0:
+			app.Accept(s, actor) //nolint:errcheck // an error leaves the state unchanged
+		case asData(s).Offer != nil:
+			app.Cancel(s) //nolint:errcheck
+		case numParts > 1:
+			o := NewRandomOffer(rng)
+			o.Proposer = actor
+			o.Counterparty = channel.Index((int(actor) + 1 + rng.Intn(numParts-1)) % numParts)
+			app.Propose(s, o) //nolint:errcheck
+		}
+		return s.Data
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package swap
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+type (
+	// Leg is one side of a swap: an amount of the asset with index Asset.
+	Leg struct {
+		Asset  uint16      // index of the asset in the channel's allocation
+		Amount channel.Bal // positive amount
+	}
+
+	// Offer is the offer of Proposer to give the Give leg to Counterparty in
+	// exchange for the Take leg.
+	Offer struct {
+		Proposer     channel.Index
+		Counterparty channel.Index
+		Give         Leg
+		Take         Leg
+	}
+
+	// Data is the app data of a swap channel. It holds at most one pending
+	// offer.
+	Data struct {
+		Offer *Offer // nil if there is no pending offer
+	}
+)
+
+var _ channel.Data = (*Data)(nil)
+
+// Clone returns a deep copy of the Leg.
+func (l Leg) Clone() Leg {
+	if l.Amount != nil {
+		l.Amount = new(big.Int).Set(l.Amount)
+	}
+	return l
+}
+
+// Equal returns whether two legs are equal.
+func (l Leg) Equal(m Leg) bool {
+	return l.Asset == m.Asset && l.Amount.Cmp(m.Amount) == 0
+}
+
+// String returns the amount and asset index of the leg.
+func (l Leg) String() string {
+	return fmt.Sprintf("%v of asset %d", l.Amount, l.Asset)
+}
+
+// Clone returns a deep copy of the Offer.
+func (o *Offer) Clone() *Offer {
+	if o == nil {
+		return nil
+	}
+	return &Offer{
+		Proposer:     o.Proposer,
+		Counterparty: o.Counterparty,
+		Give:         o.Give.Clone(),
+		Take:         o.Take.Clone(),
+	}
+}
+
+// Equal returns whether two offers are equal.
+func (o *Offer) Equal(p *Offer) bool {
+	if o == nil || p == nil {
+		return o == p
+	}
+	return o.Proposer == p.Proposer &&
+		o.Counterparty == p.Counterparty &&
+		o.Give.Equal(p.Give) &&
+		o.Take.Equal(p.Take)
+}
+
+// Encode encodes an Offer into an io.Writer.
+func (o *Offer) Encode(w io.Writer) error {
+	return perunio.Encode(w, o.Proposer, o.Counterparty,
+		o.Give.Asset, o.Give.Amount, o.Take.Asset, o.Take.Amount)
+}
+
+// Decode decodes an Offer from an io.Reader.
+func (o *Offer) Decode(r io.Reader) error {
+	return perunio.Decode(r, &o.Proposer, &o.Counterparty,
+		&o.Give.Asset, &o.Give.Amount, &o.Take.Asset, &o.Take.Amount)
+}
+
+// Clone returns a deep copy of the Data.
+func (d *Data) Clone() channel.Data {
+	if d == nil {
+		return nil
+	}
+	return &Data{Offer: d.Offer.Clone()}
+}
+
+// Encode encodes the Data into an io.Writer. It writes whether there is a
+// pending offer, followed by the offer.
+func (d *Data) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, d.Offer != nil); err != nil {
+		return errors.WithMessage(err, "encoding offer flag")
+	}
+	if d.Offer == nil {
+		return nil
+	}
+	return errors.WithMessage(d.Offer.Encode(w), "encoding offer")
+}
+
+// Decode decodes Data from an io.Reader.
+func (d *Data) Decode(r io.Reader) error {
+	var hasOffer bool
+	if err := perunio.Decode(r, &hasOffer); err != nil {
+		return errors.WithMessage(err, "decoding offer flag")
+	}
+	if !hasOffer {
+		d.Offer = nil
+		return nil
+	}
+	d.Offer = new(Offer)
+	return errors.WithMessage(d.Offer.Decode(r), "decoding offer")
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package swap
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestData_Serializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5A9))
+	o := NewRandomOffer(rng)
+	iotest.GenericSerializerTest(t, new(Data), &Data{Offer: &o}, &o)
+}
+
+func TestData_Clone(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xC10E))
+	o := NewRandomOffer(rng)
+	d := &Data{Offer: &o}
+	clone := d.Clone().(*Data)
+	assert.Equal(t, d, clone)
+	assert.True(t, d.Offer.Equal(clone.Offer))
+
+	clone.Offer.Give.Amount.SetInt64(0)
+	assert.False(t, d.Offer.Equal(clone.Offer), "amounts must be deep copies")
+
+	assert.Nil(t, new(Data).Clone().(*Data).Offer)
+	assert.True(t, (*Offer)(nil).Equal(nil))
+	assert.False(t, (*Offer)(nil).Equal(&o))
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package swap
+
+import (
+	"math/big"
+	"math/rand"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Like the HTLC app, the
+// swap app does not set itself as the global app randomizer.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp returns a swap app with a random address. The app is not
+// registered in the app registry, see SetAppDef.
+func (*Randomizer) NewRandomApp(rng *rand.Rand) channel.App {
+	return &App{Addr: wallettest.NewRandomAddress(rng)}
+}
+
+// NewRandomData returns Data without offer or with a random offer, see
+// NewRandomOffer.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	if rng.Intn(2) == 0 {
+		return new(Data)
+	}
+	o := NewRandomOffer(rng)
+	return &Data{Offer: &o}
+}
+
+// NewRandomOffer returns a random offer between participants 0 and 1 that
+// swaps between 1 and 1000 of the first two assets in random direction.
+func NewRandomOffer(rng *rand.Rand) Offer {
+	proposer := channel.Index(rng.Intn(2))
+	give := uint16(rng.Intn(2))
+	return Offer{
+		Proposer:     proposer,
+		Counterparty: proposer ^ 1,
+		Give:         Leg{Asset: give, Amount: big.NewInt(rng.Int63n(1000) + 1)},
+		Take:         Leg{Asset: give ^ 1, Amount: big.NewInt(rng.Int63n(1000) + 1)},
+	}
+}
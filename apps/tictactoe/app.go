@@ -0,0 +1,183 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package tictactoe implements a two-party tic-tac-toe app.
+//
+// Both players deposit their stake into the channel. They take turns in
+// setting one field of the grid each, beginning with the participant that is
+// set as NextActor in the initial Data. A move that ends the game makes the
+// state final. The winner receives the whole balance of the channel, a draw
+// leaves the balances unchanged.
+//
+// The package is meant as a reference for writing apps with non-trivial app
+// data. Moves are made with App.Set, e.g., in the update function of
+// client.Channel.UpdateBy.
+package tictactoe // import "perun.network/go-perun/apps/tictactoe"
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
+)
+
+// App is the tic-tac-toe app.
+type App struct {
+	Addr wallet.Address
+}
+
+var _ channel.StateApp = (*App)(nil)
+
+// SetAppDef registers the tic-tac-toe app under the given address in the app
+// registry, so that channels with this app definition use the tic-tac-toe app.
+func SetAppDef(def wallet.Address) {
+	channel.RegisterApp(&App{Addr: def})
+}
+
+// Def returns the address of the tic-tac-toe app.
+func (a *App) Def() wallet.Address {
+	return a.Addr
+}
+
+// DecodeData decodes tic-tac-toe Data from the reader.
+func (a *App) DecodeData(r io.Reader) (channel.Data, error) {
+	var d Data
+	return &d, d.Decode(r)
+}
+
+// ValidInit checks that the channel has two participants, that the grid is
+// empty and that the next actor is one of the players.
+func (a *App) ValidInit(p *channel.Params, s *channel.State) error {
+	d := asData(s)
+	if len(p.Parts) != NumPlayers {
+		return channel.NewStateTransitionError(p.ID(), "tic-tac-toe needs two participants")
+	}
+	if d.NextActor >= NumPlayers {
+		return channel.NewStateTransitionError(p.ID(), "invalid next actor")
+	}
+	for _, v := range d.Grid {
+		if v != Free {
+			return channel.NewStateTransitionError(p.ID(), "grid must be empty")
+		}
+	}
+	return nil
+}
+
+// ValidTransition checks that the actor is the next actor of the old state and
+// set exactly one free field to its value. The turn has to pass to the other
+// player. The new state must be final if and only if the game is over, and
+// its balances must be the payout of the game: the winner receives the whole
+// balance of each asset. Otherwise, the balances must not change.
+func (a *App) ValidTransition(p *channel.Params, from, to *channel.State, actor channel.Index) error {
+	fromData, toData := asData(from), asData(to)
+	newError := func(format string, args ...interface{}) error {
+		return channel.NewStateTransitionError(p.ID(), errors.Errorf(format, args...).Error())
+	}
+
+	if len(p.Parts) != NumPlayers {
+		return newError("tic-tac-toe needs two participants")
+	}
+	if actor != fromData.NextActor {
+		return newError("participant %d moved, but it is participant %d's turn", actor, fromData.NextActor)
+	}
+	if toData.NextActor != nextActor(actor) {
+		return newError("next actor is %d, expected %d", toData.NextActor, nextActor(actor))
+	}
+
+	changed := 0
+	for i := range toData.Grid {
+		if fromData.Grid[i] == toData.Grid[i] {
+			continue
+		}
+		changed++
+		if fromData.Grid[i] != Free {
+			return newError("field %d is not free", i)
+		} else if toData.Grid[i] != playerValue(actor) {
+			return newError("field %d set to %v, expected %v", i, toData.Grid[i], playerValue(actor))
+		}
+	}
+	if changed != 1 {
+		return newError("%d fields changed, expected exactly one", changed)
+	}
+
+	isFinal, winner := toData.CheckFinal()
+	if to.IsFinal != isFinal {
+		return newError("state final flag is %t, but game over is %t", to.IsFinal, isFinal)
+	}
+
+	bals := make([][]channel.Bal, len(from.Balances))
+	for i, asset := range from.Balances {
+		bals[i] = channel.CloneBals(asset)
+	}
+	if winner != nil {
+		payout(bals, *winner)
+	}
+	for i, asset := range bals {
+		for j, bal := range asset {
+			if bal.Cmp(to.Balances[i][j]) != 0 {
+				return newError("balance of participant %d in asset %d is %v, expected %v",
+					j, i, to.Balances[i][j], bal)
+			}
+		}
+	}
+	return nil
+}
+
+// Set sets the field in column x and row y of the state to the actor's value
+// and passes the turn to the other player. If the move ends the game, the
+// state is made final and the winner receives the whole balance of each
+// asset. Set returns an error if it is not the actor's turn or the field is
+// not free. The state's version is not changed.
+func (a *App) Set(s *channel.State, x, y int, actor channel.Index) error {
+	d := asData(s)
+	switch {
+	case s.IsFinal:
+		return errors.New("game is over")
+	case actor != d.NextActor:
+		return errors.Errorf("it is participant %d's turn", d.NextActor)
+	case x < 0 || x >= GridSize || y < 0 || y >= GridSize:
+		return errors.Errorf("field (%d, %d) out of grid", x, y)
+	case d.Field(x, y) != Free:
+		return errors.Errorf("field (%d, %d) is not free", x, y)
+	}
+
+	d.Grid[y*GridSize+x] = playerValue(actor)
+	d.NextActor = nextActor(actor)
+
+	isFinal, winner := d.CheckFinal()
+	s.IsFinal = isFinal
+	if winner != nil {
+		payout(s.Balances, *winner)
+	}
+	return nil
+}
+
+// payout moves the balances of all players to the winner.
+func payout(bals [][]channel.Bal, winner channel.Index) {
+	for _, asset := range bals {
+		total := new(big.Int)
+		for _, bal := range asset {
+			total.Add(total, bal)
+			bal.SetInt64(0)
+		}
+		asset[winner].Set(total)
+	}
+}
+
+func nextActor(actor channel.Index) channel.Index {
+	return (actor + 1) % NumPlayers
+}
+
+func asData(s *channel.State) *Data {
+	d, ok := s.Data.(*Data)
+	if !ok {
+		log.Panicf("tic-tac-toe app must have data of type *Data, has type %T", s.Data)
+	}
+	return d
+}
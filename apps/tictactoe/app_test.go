@@ -0,0 +1,211 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// newTestGame creates a tic-tac-toe app and a two-party channel with two
+// assets, in which both players hold 10 of each asset.
+func newTestGame(t *testing.T, rng *rand.Rand) (*App, *channel.Params, *channel.State) {
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	params := test.NewRandomParams(rng, test.WithNumParts(NumPlayers), test.WithApp(app))
+	bals := []channel.Bal{big.NewInt(10), big.NewInt(10)}
+	state := test.NewRandomState(rng,
+		test.WithParams(params),
+		test.WithBalances(bals, channel.CloneBals(bals)),
+		test.WithNumLocked(0),
+		test.WithAppData(new(Data)),
+		test.WithIsFinal(false))
+	require.NoError(t, state.Valid())
+	return app, params, state
+}
+
+// play applies the moves (x, y) of alternating players to a copy of the state.
+func play(t *testing.T, app *App, s *channel.State, moves ...[2]int) *channel.State {
+	s = s.Clone()
+	for _, m := range moves {
+		require.NoError(t, app.Set(s, m[0], m[1], asData(s).NextActor))
+		s.Version++
+	}
+	return s
+}
+
+func TestApp_Def(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	def := wallettest.NewRandomAddress(rng)
+	app := &App{Addr: def}
+	assert.True(t, def.Equals(app.Def()))
+}
+
+func TestApp_ValidInit(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x1417))
+	app, params, state := newTestGame(t, rng)
+
+	assert.NoError(t, app.ValidInit(params, state))
+
+	second := state.Clone()
+	asData(second).NextActor = 1
+	assert.NoError(t, app.ValidInit(params, second))
+
+	played := state.Clone()
+	asData(played).Grid[4] = Player1
+	assert.Error(t, app.ValidInit(params, played))
+
+	invalidActor := state.Clone()
+	asData(invalidActor).NextActor = 2
+	assert.Error(t, app.ValidInit(params, invalidActor))
+
+	threeParty := test.NewRandomParams(rng, test.WithNumParts(3), test.WithApp(app))
+	assert.Error(t, app.ValidInit(threeParty, state))
+
+	wrongData := state.Clone()
+	wrongData.Data = new(channel.MockOp)
+	assert.Panics(t, func() { app.ValidInit(params, wrongData) })
+}
+
+func TestApp_ValidTransition(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x1418))
+	app, params, initial := newTestGame(t, rng)
+
+	// validFor asserts that the transition is only valid for the given actor,
+	// or for no actor if actor is -1.
+	validFor := func(t *testing.T, from, to *channel.State, actor int) {
+		for i := range params.Parts {
+			err := app.ValidTransition(params, from, to, channel.Index(i))
+			if i == actor {
+				assert.NoErrorf(t, err, "actor %d", i)
+			} else {
+				assert.Errorf(t, err, "actor %d", i)
+			}
+		}
+	}
+
+	t.Run("move", func(t *testing.T) {
+		first := play(t, app, initial, [2]int{1, 1})
+		validFor(t, initial, first, 0)
+		second := play(t, app, first, [2]int{0, 0})
+		validFor(t, first, second, 1)
+
+		noTurnChange := first.Clone()
+		asData(noTurnChange).NextActor = 0
+		validFor(t, initial, noTurnChange, -1)
+
+		twoFields := first.Clone()
+		asData(twoFields).Grid[0] = Player1
+		validFor(t, initial, twoFields, -1)
+
+		otherValue := initial.Clone()
+		asData(otherValue).Grid[0] = Player2
+		asData(otherValue).NextActor = 1
+		validFor(t, initial, otherValue, -1)
+
+		overwrite := first.Clone()
+		asData(overwrite).Grid[4] = Player2
+		asData(overwrite).NextActor = 0
+		validFor(t, first, overwrite, -1)
+
+		validFor(t, initial, initial, -1) // no move
+
+		paid := first.Clone()
+		paid.Balances[0][0].SetInt64(15)
+		paid.Balances[0][1].SetInt64(5)
+		validFor(t, initial, paid, -1)
+
+		final := first.Clone()
+		final.IsFinal = true
+		validFor(t, initial, final, -1)
+	})
+
+	t.Run("win", func(t *testing.T) {
+		// X wins with the top row.
+		before := play(t, app, initial, [2]int{0, 0}, [2]int{0, 1}, [2]int{1, 0}, [2]int{1, 1})
+		won := play(t, app, before, [2]int{2, 0})
+		require.True(t, won.IsFinal)
+		for _, asset := range won.Balances {
+			assert.Equal(t, int64(20), asset[0].Int64())
+			assert.Equal(t, int64(0), asset[1].Int64())
+		}
+		validFor(t, before, won, 0)
+
+		notFinal := won.Clone()
+		notFinal.IsFinal = false
+		validFor(t, before, notFinal, -1)
+
+		noPayout := won.Clone()
+		noPayout.Balances = before.Clone().Balances
+		validFor(t, before, noPayout, -1)
+
+		assert.Error(t, app.Set(won.Clone(), 2, 2, 1), "game over")
+	})
+
+	t.Run("draw", func(t *testing.T) {
+		// X O X
+		// X O O
+		// O X X
+		before := play(t, app, initial,
+			[2]int{0, 0}, [2]int{1, 0}, [2]int{2, 0}, [2]int{1, 1}, [2]int{0, 1},
+			[2]int{2, 1}, [2]int{1, 2}, [2]int{0, 2})
+		draw := play(t, app, before, [2]int{2, 2})
+		require.True(t, draw.IsFinal)
+		assert.Equal(t, initial.Balances, draw.Balances)
+		validFor(t, before, draw, 0)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		to := initial.Clone()
+		to.Data = nil
+		assert.Panics(t, func() { app.ValidTransition(params, initial, to, 0) })
+	})
+}
+
+func TestApp_Set(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x1419))
+	app, _, initial := newTestGame(t, rng)
+
+	s := initial.Clone()
+	assert.Error(t, app.Set(s, 0, 0, 1), "not the actor's turn")
+	assert.Error(t, app.Set(s, 3, 0, 0), "out of grid")
+	assert.Error(t, app.Set(s, 0, -1, 0), "out of grid")
+	require.NoError(t, app.Set(s, 2, 1, 0))
+	assert.Equal(t, Player1, asData(s).Field(2, 1))
+	assert.Equal(t, channel.Index(1), asData(s).NextActor)
+	assert.Error(t, app.Set(s, 2, 1, 1), "field not free")
+	assert.Equal(t, initial.Version, s.Version)
+}
+
+func TestApp_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6E2))
+	app := &App{Addr: wallettest.NewRandomAddress(rng)}
+	SetAppDef(app.Addr)
+	test.GenericStateAppTest(t, rng, &test.StateAppSetup{
+		App:        app,
+		Randomizer: new(Randomizer),
+		NextData:   nextData(app),
+		Opts:       []test.RandomOpt{test.WithNumParts(NumPlayers)},
+	})
+}
+
+// nextData returns a NextData for the generic test, in which the actor sets a
+// random field.
+func nextData(app *App) func(*rand.Rand, *channel.State, channel.Index) channel.Data {
+	return func(rng *rand.Rand, s *channel.State, actor channel.Index) channel.Data {
+		app.Set(s, rng.Intn(GridSize), rng.Intn(GridSize), actor) //nolint:errcheck // an error leaves the state unchanged
+		return s.Data
+	}
+}
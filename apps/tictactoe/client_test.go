@@ -0,0 +1,179 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/apps/tictactoe"
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	chtest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/client"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wiretest "perun.network/go-perun/wire/test"
+)
+
+const timeout = 5 * time.Second
+
+type (
+	// player is a client that plays tic-tac-toe in a channel.
+	player struct {
+		*client.Client
+		id     wire.Account
+		wallet wtest.Wallet
+		done   sync.WaitGroup
+	}
+
+	// mockFunder funds all channels immediately.
+	mockFunder struct{}
+
+	// mockAdjudicator registers and withdraws all states immediately.
+	mockAdjudicator struct{}
+)
+
+func (mockFunder) Fund(context.Context, channel.FundingReq) error { return nil }
+
+func (mockAdjudicator) Register(_ context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	return &channel.RegisteredEvent{
+		ID:      req.Params.ID(),
+		Version: req.Tx.Version,
+		Timeout: &channel.ElapsedTimeout{},
+	}, nil
+}
+
+func (mockAdjudicator) Withdraw(context.Context, channel.AdjudicatorReq) error { return nil }
+
+func (mockAdjudicator) SubscribeRegistered(context.Context, *channel.Params) (channel.RegisteredSubscription, error) {
+	return nil, nil
+}
+
+// newPlayer creates a client on the hub that accepts all updates and handles
+// proposals with the handler returned by ph.
+func newPlayer(t *testing.T, rng *rand.Rand, hub *wiretest.ConnHub, ph func(*player) client.ProposalHandler) *player {
+	p := &player{id: wtest.NewRandomAccount(rng), wallet: wtest.NewWallet()}
+	p.Client = client.New(p.id, hub.NewNetDialer(), mockFunder{}, mockAdjudicator{}, p.wallet)
+	acceptAll := client.UpdateHandlerFunc(func(up client.ChannelUpdate, res *client.UpdateResponder) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		t.Logf("Player %d moved:\n%v", up.ActorIdx, up.State.Data)
+		assert.NoError(t, res.Accept(ctx))
+	})
+
+	listener := hub.NewNetListener(p.id.Address())
+	p.done.Add(2)
+	go func() { defer p.done.Done(); p.Listen(listener) }()
+	go func() { defer p.done.Done(); p.Handle(ph(p), acceptAll) }()
+	return p
+}
+
+func (p *player) close(t *testing.T) {
+	assert.NoError(t, p.Close())
+	p.done.Wait()
+}
+
+func TestTicTacToe(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7171))
+	app := &tictactoe.App{Addr: wtest.NewRandomAddress(rng)}
+	tictactoe.SetAppDef(app.Addr)
+
+	var hub wiretest.ConnHub
+	alice := newPlayer(t, rng, &hub, func(*player) client.ProposalHandler {
+		return client.ProposalHandlerFunc(
+			func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				assert.NoError(t, res.Reject(ctx, "alice does not accept proposals"))
+			})
+	})
+	defer alice.close(t)
+	// Bob accepts all proposals.
+	bobChans := make(chan *client.Channel, 1)
+	bob := newPlayer(t, rng, &hub, func(bob *player) client.ProposalHandler {
+		return client.ProposalHandlerFunc(
+			func(_ *client.ChannelProposal, res *client.ProposalResponder) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				ch, err := res.Accept(ctx, client.ProposalAcc{
+					Participant: bob.wallet.NewRandomAccount(rng).Address(),
+				})
+				assert.NoError(t, err)
+				bobChans <- ch
+			})
+	})
+	defer bob.close(t)
+
+	// Both players deposit 10.
+	stake := big.NewInt(10)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	aliceCh, err := alice.ProposeChannel(ctx, &client.ChannelProposal{
+		Backend:           wallet.DefaultBackend(),
+		ChallengeDuration: 60,
+		Nonce:             big.NewInt(rng.Int63()),
+		ParticipantAddr:   alice.wallet.NewRandomAccount(rng).Address(),
+		AppDef:            app.Def(),
+		InitData:          &tictactoe.Data{NextActor: 0},
+		InitBals: &channel.Allocation{
+			Assets:   []channel.Asset{chtest.NewRandomAsset(rng)},
+			Balances: [][]channel.Bal{{stake, stake}},
+		},
+		PeerAddrs: []wire.Address{alice.id.Address(), bob.id.Address()},
+	})
+	require.NoError(t, err)
+	bobCh := <-bobChans
+	require.NotNil(t, bobCh)
+	chans := [2]*client.Channel{aliceCh, bobCh}
+
+	// Bob cannot move first.
+	assert.Error(t, bobCh.UpdateBy(ctx, func(s *channel.State) {
+		data := s.Data.(*tictactoe.Data)
+		data.Grid[4], data.NextActor = tictactoe.Player2, 0
+	}))
+
+	// Alice wins with the diagonal.
+	moves := []struct {
+		player int
+		x, y   int
+	}{
+		{0, 0, 0}, {1, 1, 0}, {0, 1, 1}, {1, 2, 0}, {0, 2, 2},
+	}
+	for _, m := range moves {
+		ch := chans[m.player]
+		var moveErr error
+		err := ch.UpdateBy(ctx, func(s *channel.State) {
+			moveErr = app.Set(s, m.x, m.y, ch.Idx())
+		})
+		require.NoError(t, moveErr)
+		require.NoError(t, err)
+	}
+
+	// No moves are possible after the game is over.
+	assert.Error(t, bobCh.UpdateBy(ctx, func(s *channel.State) {
+		s.Data.(*tictactoe.Data).Grid[8] = tictactoe.Player2
+	}))
+
+	for i, ch := range chans {
+		s := ch.State()
+		assert.True(t, s.IsFinal)
+		_, winner := s.Data.(*tictactoe.Data).CheckFinal()
+		require.NotNil(t, winner)
+		assert.Equal(t, channel.Index(0), *winner)
+		assert.Equal(t, int64(20), s.Balances[0][0].Int64(), "player %d", i)
+		assert.Equal(t, int64(0), s.Balances[0][1].Int64(), "player %d", i)
+		assert.NoError(t, ch.Settle(ctx))
+	}
+}
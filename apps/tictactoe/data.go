@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+const (
+	// NumPlayers is the number of participants of a tic-tac-toe channel.
+	NumPlayers = 2
+	// GridSize is the number of fields in each row and column of the grid.
+	GridSize = 3
+)
+
+// FieldValue is the value of a field of the grid.
+type FieldValue uint8
+
+// The values of a field. The field of a player has the value of its
+// participant index plus one.
+const (
+	Free FieldValue = iota
+	Player1
+	Player2
+	maxFieldValue = Player2
+)
+
+// Data is the app data of a tic-tac-toe channel.
+type Data struct {
+	// NextActor is the index of the participant whose turn it is.
+	NextActor channel.Index
+	// Grid holds the fields in row-major order.
+	Grid [GridSize * GridSize]FieldValue
+}
+
+var _ channel.Data = (*Data)(nil)
+
+// playerValue returns the field value of the participant with the given index.
+func playerValue(idx channel.Index) FieldValue {
+	return FieldValue(idx + 1)
+}
+
+// String returns the symbol of the field value: "X" for Player1, "O" for
+// Player2 and " " for a free field.
+func (v FieldValue) String() string {
+	switch v {
+	case Free:
+		return " "
+	case Player1:
+		return "X"
+	case Player2:
+		return "O"
+	default:
+		return "?"
+	}
+}
+
+// Field returns the value of the field in column x and row y.
+func (d *Data) Field(x, y int) FieldValue {
+	return d.Grid[y*GridSize+x]
+}
+
+// CheckFinal returns whether the game is over. If a player has three fields
+// in a row, column or diagonal, it is returned as the winner. If the game
+// ended in a draw, winner is nil.
+func (d *Data) CheckFinal() (isFinal bool, winner *channel.Index) {
+	lines := [][GridSize]int{
+		{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // rows
+		{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // columns
+		{0, 4, 8}, {2, 4, 6}, // diagonals
+	}
+	for _, l := range lines {
+		v := d.Grid[l[0]]
+		if v != Free && v == d.Grid[l[1]] && v == d.Grid[l[2]] {
+			idx := channel.Index(v - 1)
+			return true, &idx
+		}
+	}
+
+	for _, v := range d.Grid {
+		if v == Free {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// String returns the grid with one row per line.
+func (d *Data) String() string {
+	var b strings.Builder
+	for y := 0; y < GridSize; y++ {
+		if y > 0 {
+			b.WriteString("\n-+-+-\n")
+		}
+		for x := 0; x < GridSize; x++ {
+			if x > 0 {
+				b.WriteByte('|')
+			}
+			b.WriteString(d.Field(x, y).String())
+		}
+	}
+	return fmt.Sprintf("Next: %d\n%s", d.NextActor, b.String())
+}
+
+// Clone returns a copy of the Data.
+func (d *Data) Clone() channel.Data {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	return &clone
+}
+
+// Encode encodes the Data into an io.Writer.
+func (d *Data) Encode(w io.Writer) error {
+	var grid [GridSize * GridSize]byte
+	for i, v := range d.Grid {
+		grid[i] = byte(v)
+	}
+	return perunio.Encode(w, d.NextActor, grid[:])
+}
+
+// Decode decodes Data from an io.Reader.
+func (d *Data) Decode(r io.Reader) error {
+	grid := make([]byte, GridSize*GridSize)
+	if err := perunio.Decode(r, &d.NextActor, &grid); err != nil {
+		return err
+	}
+	if d.NextActor >= NumPlayers {
+		return errors.Errorf("invalid next actor %d", d.NextActor)
+	}
+	for i, v := range grid {
+		if FieldValue(v) > maxFieldValue {
+			return errors.Errorf("invalid value %d of field %d", v, i)
+		}
+		d.Grid[i] = FieldValue(v)
+	}
+	return nil
+}
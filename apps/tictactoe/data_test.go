@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"perun.network/go-perun/channel"
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+// grid creates Data from a grid of symbols in row-major order.
+func grid(next channel.Index, fields string) *Data {
+	d := &Data{NextActor: next}
+	for i, c := range fields {
+		switch c {
+		case 'X':
+			d.Grid[i] = Player1
+		case 'O':
+			d.Grid[i] = Player2
+		}
+	}
+	return d
+}
+
+func TestData_Serializer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7070))
+	iotest.GenericSerializerTest(t, new(Data), NewRandomData(rng), grid(1, "XOX OX  O"))
+
+	invalid := [][]byte{
+		{0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0}, // next actor
+		{0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0}, // field value
+	}
+	for _, b := range invalid {
+		assert.Error(t, new(Data).Decode(bytes.NewReader(b)))
+	}
+}
+
+func TestData_CheckFinal(t *testing.T) {
+	one := channel.Index(1)
+	zero := channel.Index(0)
+	tests := []struct {
+		fields  string
+		isFinal bool
+		winner  *channel.Index
+	}{
+		{"         ", false, nil},
+		{"XO XO    ", false, nil},
+		{"XXX OO   ", true, &zero},
+		{"X XOOOX  ", true, &one},
+		{"OX OX O  ", true, &one},
+		{"X OXO O X", true, &one},
+		{"XOXXOOOXX", true, nil},
+	}
+	for _, tt := range tests {
+		isFinal, winner := grid(0, tt.fields).CheckFinal()
+		assert.Equalf(t, tt.isFinal, isFinal, "grid %q", tt.fields)
+		assert.Equalf(t, tt.winner, winner, "grid %q", tt.fields)
+	}
+}
+
+func TestData_Clone(t *testing.T) {
+	d := grid(1, "XO X     ")
+	clone := d.Clone().(*Data)
+	assert.Equal(t, d, clone)
+	clone.Grid[8] = Player2
+	assert.Equal(t, Free, d.Grid[8])
+}
+
+func TestData_String(t *testing.T) {
+	assert.Equal(t, "Next: 1\nX|O| \n-+-+-\n |X| \n-+-+-\n | | ", grid(1, "XO  X    ").String())
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe
+
+import (
+	"math/rand"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+// Randomizer implements channel.test.AppRandomizer. Like the HTLC app, the
+// tic-tac-toe app does not set itself as the global app randomizer.
+type Randomizer struct{}
+
+var _ test.AppRandomizer = (*Randomizer)(nil)
+
+// NewRandomApp returns a tic-tac-toe app with a random address. The app is not
+// registered in the app registry, see SetAppDef.
+func (*Randomizer) NewRandomApp(rng *rand.Rand) channel.App {
+	return &App{Addr: wallettest.NewRandomAddress(rng)}
+}
+
+// NewRandomData returns the Data of a random game that is not over yet.
+func (*Randomizer) NewRandomData(rng *rand.Rand) channel.Data {
+	return NewRandomData(rng)
+}
+
+// NewRandomData plays a random number of random moves, starting with a random
+// player, and returns the Data before the game is over.
+func NewRandomData(rng *rand.Rand) *Data {
+	d := &Data{NextActor: channel.Index(rng.Intn(NumPlayers))}
+	for moves := rng.Intn(len(d.Grid)); moves > 0; moves-- {
+		next := *d
+		free := rng.Perm(len(d.Grid))
+		for _, i := range free {
+			if next.Grid[i] == Free {
+				next.Grid[i] = playerValue(next.NextActor)
+				break
+			}
+		}
+		next.NextActor = nextActor(next.NextActor)
+		if isFinal, _ := next.CheckFinal(); isFinal {
+			break
+		}
+		*d = next
+	}
+	return d
+}
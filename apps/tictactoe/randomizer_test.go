@@ -0,0 +1,28 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tictactoe
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+)
+
+func TestRandomizer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r := new(Randomizer)
+	assert.IsType(t, &App{}, r.NewRandomApp(rng))
+
+	for i := 0; i < 100; i++ {
+		d, ok := r.NewRandomData(rng).(*Data)
+		assert.True(t, ok)
+		isFinal, _ := d.CheckFinal()
+		assert.False(t, isFinal, "random game must not be over")
+	}
+}
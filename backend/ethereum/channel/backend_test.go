@@ -88,12 +88,14 @@ func TestChannelID(t *testing.T) {
 			bob := newAddressFromString(tt.bobAddr)
 			app := newAddressFromString(tt.appAddr)
 			params := channel.Params{
+				Backend:           wallet.BackendID,
 				ChallengeDuration: tt.challengDur,
 				Nonce:             nonce,
 				Parts:             []perunwallet.Address{alice, bob},
 				App:               channel.NewMockApp(app),
 			}
-			cID := channel.CalcID(&params)
+			cID, err := channel.CalcID(&params)
+			assert.NoError(t, err, "CalcID should not return an error")
 			preCalc, err := hex.DecodeString(tt.channelID)
 			assert.NoError(t, err, "Decoding the channelID should not error")
 			assert.Equal(t, preCalc, cID[:], "ChannelID should match the testcase")
@@ -6,9 +6,10 @@
 package channel
 
 import (
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
 	"perun.network/go-perun/channel"
 )
 
 func init() {
-	channel.SetBackend(new(Backend))
+	channel.RegisterBackend(ethwallet.BackendID, new(Backend))
 }
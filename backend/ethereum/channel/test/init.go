@@ -6,9 +6,12 @@
 package test
 
 import (
+	"perun.network/go-perun/channel"
 	"perun.network/go-perun/channel/test"
 )
 
 func init() {
 	test.SetRandomizer(new(randomizer))
+	// resolve the random MockApps of the test randomizer
+	channel.RegisterDefaultAppBackend(new(channel.MockAppBackend))
 }
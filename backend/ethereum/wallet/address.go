@@ -7,11 +7,13 @@ package wallet
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 
+	perunio "perun.network/go-perun/pkg/io"
 	"perun.network/go-perun/wallet"
 )
 
@@ -73,3 +75,22 @@ func AsEthAddr(a wallet.Address) common.Address {
 func AsWalletAddr(addr common.Address) *Address {
 	return (*Address)(&addr)
 }
+
+// MarshalJSON encodes the address as hex string of its binary encoding, which
+// is the same as the JSON encoding of common.Address.
+func (a *Address) MarshalJSON() ([]byte, error) {
+	s, err := perunio.EncodeHex(a)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON decodes an address from the hex string of its binary encoding.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(err, "unmarshaling address")
+	}
+	return perunio.DecodeHex(s, a.Decode)
+}
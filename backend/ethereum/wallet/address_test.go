@@ -6,11 +6,14 @@
 package wallet
 
 import (
+	"encoding/json"
 	"math/rand"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
+
+	iotest "perun.network/go-perun/pkg/io/test"
 )
 
 func TestAsWalletAddr(t *testing.T) {
@@ -49,3 +52,17 @@ func TestAddressOrdering(t *testing.T) {
 		require.Equal(t, c.expected, c.addr[0].Cmp(&c.addr[1]))
 	}
 }
+
+func TestAddress_JSON(t *testing.T) {
+	rng := rand.New(rand.NewSource(1930))
+	var commonAddr common.Address
+	rng.Read(commonAddr[:])
+	addr := AsWalletAddr(commonAddr)
+	iotest.GenericJSONTest(t, addr)
+
+	data, err := json.Marshal(addr)
+	require.NoError(t, err)
+	commonData, err := json.Marshal(commonAddr)
+	require.NoError(t, err)
+	require.Equal(t, commonData, data, "same encoding as common.Address")
+}
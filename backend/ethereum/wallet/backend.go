@@ -14,6 +14,9 @@ import (
 	"perun.network/go-perun/wallet"
 )
 
+// BackendID is the ID under which the Ethereum backend is registered.
+const BackendID wallet.BackendID = 1
+
 // Backend implements the utility interface defined in the wallet package.
 type Backend struct{}
 
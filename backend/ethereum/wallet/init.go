@@ -10,5 +10,5 @@ import (
 )
 
 func init() {
-	wallet.SetBackend(new(Backend))
+	wallet.RegisterBackend(BackendID, new(Backend))
 }
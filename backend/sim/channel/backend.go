@@ -13,6 +13,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	simwallet "perun.network/go-perun/backend/sim/wallet"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/log"
 	perunio "perun.network/go-perun/pkg/io"
@@ -93,7 +94,7 @@ func (b *backend) Verify(addr wallet.Address, params *channel.Params, state *cha
 		log.Panic("bufio flush")
 	}
 
-	return wallet.VerifySignature(buff.Bytes(), sig, addr)
+	return new(simwallet.Backend).VerifySignature(buff.Bytes(), sig, addr)
 }
 
 // encodeState packs all fields of a State into a []byte
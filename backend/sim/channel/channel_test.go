@@ -6,10 +6,17 @@
 package channel
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	simwallet "perun.network/go-perun/backend/sim/wallet"
+	"perun.network/go-perun/channel"
 	chtest "perun.network/go-perun/channel/test"
+	perunio "perun.network/go-perun/pkg/io"
 	"perun.network/go-perun/wallet"
 	wtest "perun.network/go-perun/wallet/test"
 )
@@ -34,3 +41,43 @@ func newChannelSetup() *chtest.Setup {
 		RandomAddress: func() wallet.Address { return wtest.NewRandomAddress(rng) },
 	}
 }
+
+func TestMultipleBackends(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xB4C))
+	// The simulated backend is registered a second time under another ID.
+	const id wallet.BackendID = 0x5151
+	wallet.RegisterBackend(id, new(simwallet.Backend))
+	channel.RegisterBackend(id, new(backend))
+
+	params, state := chtest.NewRandomParamsAndState(rng, chtest.WithBackend(id), chtest.WithNumLocked(0))
+	require.Equal(t, id, params.Backend)
+	require.Equal(t, id, state.Backend)
+
+	t.Run("encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, perunio.Encode(&buf, params, state))
+		params2, state2 := new(channel.Params), new(channel.State)
+		require.NoError(t, perunio.Decode(&buf, params2, state2))
+		assert.Equal(t, id, params2.Backend)
+		assert.Equal(t, params.ID(), params2.ID())
+		assert.NoError(t, state.Equal(state2))
+	})
+
+	t.Run("signing", func(t *testing.T) {
+		acc := wtest.NewRandomAccount(rng)
+		sig, err := channel.Sign(acc, params, state)
+		require.NoError(t, err)
+		ok, err := channel.Verify(acc.Address(), params, state, sig)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := channel.NewParams(id+1, params.ChallengeDuration, params.Parts, params.App.Def(), params.Nonce)
+		assert.Error(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, perunio.Encode(&buf, id+1))
+		assert.Error(t, new(channel.Params).Decode(&buf))
+	})
+}
@@ -8,11 +8,14 @@
 package channel
 
 import (
+	simwallet "perun.network/go-perun/backend/sim/wallet"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/channel/test"
 )
 
 func init() {
-	channel.SetBackend(new(backend))
+	channel.RegisterBackend(simwallet.BackendID, new(backend))
 	test.SetRandomizer(new(randomizer))
+	// resolve the random MockApps of the test randomizer
+	channel.RegisterDefaultAppBackend(new(channel.MockAppBackend))
 }
@@ -10,10 +10,10 @@ package channel
 import (
 	"testing"
 
-	_ "perun.network/go-perun/backend/sim/wallet" // backend init
+	simwallet "perun.network/go-perun/backend/sim/wallet"
 	"perun.network/go-perun/channel"
 )
 
-func TestSetBackend(t *testing.T) {
-	channel.SetBackendTest(t)
+func TestRegisterBackend(t *testing.T) {
+	channel.RegisterBackendTest(t, simwallet.BackendID)
 }
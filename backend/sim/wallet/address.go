@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"io"
 	"math/big"
 
@@ -124,3 +125,21 @@ func (a *Address) Decode(r io.Reader) error {
 
 	return nil
 }
+
+// MarshalJSON encodes the address as hex string of its binary encoding.
+func (a *Address) MarshalJSON() ([]byte, error) {
+	s, err := perunio.EncodeHex(a)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON decodes an address from the hex string of its binary encoding.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(err, "unmarshaling address")
+	}
+	return perunio.DecodeHex(s, a.Decode)
+}
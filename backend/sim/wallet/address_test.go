@@ -6,10 +6,15 @@
 package wallet
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	perunio "perun.network/go-perun/pkg/io"
+	iotest "perun.network/go-perun/pkg/io/test"
 	"perun.network/go-perun/pkg/test"
 )
 
@@ -112,3 +117,16 @@ func CloneAddr(addr *Address) *Address {
 		Y:     cloneY,
 	}
 }
+
+func TestAddress_JSON(t *testing.T) {
+	rng := test.Prng(t)
+	addr := NewRandomAddress(rng)
+	iotest.GenericJSONTest(t, addr)
+
+	data, err := json.Marshal(addr)
+	require.NoError(t, err)
+	s, err := perunio.EncodeHex(addr)
+	require.NoError(t, err)
+	assert.Equal(t, `"`+s+`"`, string(data))
+	assert.Error(t, json.Unmarshal([]byte(`"0x0102"`), new(Address)))
+}
@@ -20,6 +20,9 @@ import (
 
 var curve = elliptic.P256()
 
+// BackendID is the ID under which the simulated backend is registered.
+const BackendID wallet.BackendID = 0
+
 // Backend implements the utility interface defined in the wallet package.
 type Backend struct{}
 
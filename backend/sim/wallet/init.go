@@ -13,6 +13,6 @@ import (
 )
 
 func init() {
-	wallet.SetBackend(new(Backend))
+	wallet.RegisterBackend(BackendID, new(Backend))
 	test.SetRandomizer(newRandomizer())
 }
@@ -15,6 +15,6 @@ import (
 )
 
 func TestSetBackend(t *testing.T) {
-	wallet.SetBackendTest(t)
+	wallet.RegisterBackendTest(t, BackendID)
 	test.SetRandomizerTest(t)
 }
@@ -10,6 +10,7 @@ import (
 	"math/big"
 
 	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
 
 	"github.com/pkg/errors"
 )
@@ -154,8 +155,15 @@ func (a Allocation) Encode(w io.Writer) error {
 	return nil
 }
 
-// Decode decodes an allocation from an io.Reader.
+// Decode decodes an allocation from an io.Reader. The assets are decoded with
+// the default backend.
 func (a *Allocation) Decode(r io.Reader) error {
+	return a.DecodeOf(wallet.DefaultBackend(), r)
+}
+
+// DecodeOf decodes an allocation from an io.Reader. The assets are decoded
+// with the backend of the given ID.
+func (a *Allocation) DecodeOf(backend wallet.BackendID, r io.Reader) error {
 	// decode dimensions
 	var numAssets, numParts, numLocked Index
 	if err := perunio.Decode(r, &numAssets, &numParts, &numLocked); err != nil {
@@ -164,10 +172,15 @@ func (a *Allocation) Decode(r io.Reader) error {
 	if numAssets > MaxNumAssets || numParts > MaxNumParts || numLocked > MaxNumSubAllocations {
 		return errors.New("numAssets, numParts or numLocked too big")
 	}
+	// every asset, balance and suballocation takes at least one byte
+	numElems := int(numAssets) + int(numAssets)*int(numParts) + int(numLocked)
+	if err := perunio.CheckRemaining(r, numElems); err != nil {
+		return errors.WithMessage(err, "decoding allocation")
+	}
 	// decode assets
 	a.Assets = make([]Asset, numAssets)
 	for i := range a.Assets {
-		asset, err := DecodeAsset(r)
+		asset, err := DecodeAssetOf(backend, r)
 		if err != nil {
 			return errors.WithMessagef(err, "decoding asset %d", i)
 		}
@@ -392,6 +405,9 @@ func (s *SubAlloc) Decode(r io.Reader) error {
 	if numAssets > MaxNumAssets {
 		return errors.Errorf("numAssets too big, got: %d max: %d", numAssets, MaxNumAssets)
 	}
+	if err := perunio.CheckRemaining(r, int(numAssets)); err != nil {
+		return errors.WithMessage(err, "decoding sub-allocation balances")
+	}
 	// decode bals
 	s.Bals = make([]Bal, numAssets)
 	for i := range s.Bals {
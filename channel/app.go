@@ -6,12 +6,18 @@
 package channel
 
 import (
+	"bytes"
 	"io"
 
+	"github.com/pkg/errors"
+
 	perunio "perun.network/go-perun/pkg/io"
 	"perun.network/go-perun/wallet"
 )
 
+// MaxDataSize is the maximum size of encoded app data.
+const MaxDataSize = 1 << 20
+
 type (
 	// An App is an abstract interface for an app definition. Either a StateApp or
 	// ActionApp should be implemented.
@@ -24,7 +30,8 @@ type (
 		// DecodeData decodes data specific to this application. This has to be
 		// defined on an application-level because every app can have completely
 		// different data; during decoding the application needs to be known to
-		// know how to decode the data.
+		// know how to decode the data. The reader only contains the encoded
+		// data, see EncodeData, so an app can encode empty data as no bytes.
 		DecodeData(io.Reader) (Data, error)
 	}
 
@@ -91,13 +98,12 @@ type (
 	Action = perunio.Encoder
 
 	// AppBackend provides functionality to create an App from an Address.
-	// The AppBackend needs to be implemented for every state channel application.
+	// App packages register their AppBackend in the app registry with
+	// RegisterAppBackend, so that several apps can be used alongside each other.
 	AppBackend interface {
 		// AppFromDefinition creates an app from its defining address. It is
-		// possible that multiple apps are in use, which is why creation happens
-		// over a central AppFromDefinition function.  One possible implementation
-		// is that the app is just read from an app registry, mapping addresses to
-		// apps.
+		// called by the app registry for all definitions that the predicate of
+		// the AppBackend's registration matches.
 		AppFromDefinition(wallet.Address) (App, error)
 	}
 )
@@ -114,27 +120,41 @@ func IsActionApp(app App) bool {
 	return ok
 }
 
-// appBackend stores the AppBackend globally for the channel package.
-var appBackend AppBackend = &MockAppBackend{}
-
-// isAppBackendSet whether the appBackend was already set with `SetAppBackend`
-var isAppBackendSet bool
-
-// SetAppBackend sets the channel package's app backend. This is more specific
-// than the blockchain backend, so it has to be set separately.
-// The app backend is set to the MockAppBackend by default. Because the MockApp is in
-// package channel, we cannot set it through the usual init.go idiom.
-// The app backend can be changed once by another app (by a SetAppBackend call
-// of the app package's init() function).
-func SetAppBackend(b AppBackend) {
-	if isAppBackendSet {
-		panic("app backend already set")
+// EncodeData encodes app data prefixed with the length of its encoding, so
+// that the app can decode it from a reader that ends with the data.
+func EncodeData(w io.Writer, d Data) error {
+	var buf bytes.Buffer
+	if err := d.Encode(&buf); err != nil {
+		return errors.WithMessage(err, "encoding app data")
+	}
+	if buf.Len() > MaxDataSize {
+		return errors.Errorf("app data too big: %d bytes, max %d", buf.Len(), MaxDataSize)
 	}
-	isAppBackendSet = true
-	appBackend = b
+	return perunio.Encode(w, uint32(buf.Len()), buf.Bytes())
 }
 
-// AppFromDefinition is a global wrapper call to the app backend function.
-func AppFromDefinition(def wallet.Address) (App, error) {
-	return appBackend.AppFromDefinition(def)
+// DecodeData decodes app data that was encoded with EncodeData with the given
+// app. All bytes of the encoding have to be consumed by the app.
+func DecodeData(r io.Reader, app App) (Data, error) {
+	var size uint32
+	if err := perunio.Decode(r, &size); err != nil {
+		return nil, errors.WithMessage(err, "decoding app data size")
+	}
+	if size > MaxDataSize {
+		return nil, errors.Errorf("app data too big: %d bytes, max %d", size, MaxDataSize)
+	}
+	enc := make([]byte, size)
+	if _, err := io.ReadFull(r, enc); err != nil {
+		return nil, errors.Wrap(err, "reading app data")
+	}
+
+	buf := bytes.NewReader(enc)
+	d, err := app.DecodeData(buf)
+	if err != nil {
+		return nil, err
+	}
+	if buf.Len() != 0 {
+		return nil, errors.Errorf("app data has %d bytes left", buf.Len())
+	}
+	return d, nil
 }
@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package channel
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/wallet"
+)
+
+// AppDefPredicate decides whether an app definition is handled by an
+// AppBackend in the app registry.
+type AppDefPredicate func(wallet.Address) bool
+
+// appRegistry maps app definitions to apps. Apps that are registered by their
+// definition take precedence over registered AppBackends, which are checked in
+// the order of their registration. The default AppBackend, if set, resolves
+// all remaining definitions.
+type appRegistry struct {
+	mutex    sync.RWMutex
+	apps     map[wallet.AddrKey]App
+	backends []predicatedAppBackend
+	fallback AppBackend
+}
+
+type predicatedAppBackend struct {
+	pred    AppDefPredicate
+	backend AppBackend
+}
+
+// appReg is the global app registry of the channel package.
+var appReg = newAppRegistry()
+
+func newAppRegistry() *appRegistry {
+	return &appRegistry{apps: make(map[wallet.AddrKey]App)}
+}
+
+// RegisterApp registers an app in the app registry under its definition. A
+// previously registered app with the same definition is replaced.
+func RegisterApp(app App) {
+	appReg.mutex.Lock()
+	defer appReg.mutex.Unlock()
+	appReg.apps[wallet.Key(app.Def())] = app
+}
+
+// RegisterAppBackend registers an AppBackend in the app registry that
+// resolves all app definitions that pred matches and that are not registered
+// with RegisterApp. If several predicates match, the AppBackend that was
+// registered first is used.
+func RegisterAppBackend(pred AppDefPredicate, b AppBackend) {
+	appReg.mutex.Lock()
+	defer appReg.mutex.Unlock()
+	appReg.backends = append(appReg.backends, predicatedAppBackend{pred, b})
+}
+
+// RegisterDefaultAppBackend sets the AppBackend that resolves all app
+// definitions that no other registration matches. A previously set default
+// AppBackend is replaced. No default AppBackend is set initially, so that
+// unknown definitions are refused. Tests can set the MockAppBackend as
+// default to resolve all unknown definitions to MockApps.
+func RegisterDefaultAppBackend(b AppBackend) {
+	appReg.mutex.Lock()
+	defer appReg.mutex.Unlock()
+	appReg.fallback = b
+}
+
+// AppFromDefinition resolves an app definition to an app using the app
+// registry.
+func AppFromDefinition(def wallet.Address) (App, error) {
+	return appReg.appFromDefinition(def)
+}
+
+func (r *appRegistry) appFromDefinition(def wallet.Address) (App, error) {
+	if def == nil {
+		return nil, errors.New("nil app definition")
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if app, ok := r.apps[wallet.Key(def)]; ok {
+		return app, nil
+	}
+	for _, b := range r.backends {
+		if b.pred(def) {
+			return b.backend.AppFromDefinition(def)
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback.AppFromDefinition(def)
+	}
+	return nil, errors.Errorf("no app registered for definition %v", def)
+}
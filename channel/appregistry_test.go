@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package channel
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+)
+
+// withAppRegistry runs f with a fresh global app registry.
+func withAppRegistry(f func()) {
+	old := appReg
+	appReg = newAppRegistry()
+	defer func() { appReg = old }()
+	f()
+}
+
+func TestAppRegistry(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xA99))
+	withAppRegistry(func() {
+		single, matched, unknown := wtest.NewRandomAddress(rng),
+			wtest.NewRandomAddress(rng), wtest.NewRandomAddress(rng)
+
+		_, err := AppFromDefinition(unknown)
+		assert.Error(t, err, "unknown definition without default backend")
+		_, err = AppFromDefinition(nil)
+		assert.Error(t, err)
+
+		app := NewMockApp(single)
+		RegisterApp(app)
+		res, err := AppFromDefinition(single)
+		require.NoError(t, err)
+		assert.Same(t, app, res)
+
+		RegisterAppBackend(func(def wallet.Address) bool { return def.Equals(matched) }, new(MockAppBackend))
+		res, err = AppFromDefinition(matched)
+		require.NoError(t, err)
+		assert.True(t, res.Def().Equals(matched))
+		_, err = AppFromDefinition(unknown)
+		assert.Error(t, err)
+
+		// Later registrations do not shadow earlier ones.
+		RegisterAppBackend(func(wallet.Address) bool { return true }, new(MockAppBackend))
+		res, err = AppFromDefinition(single)
+		require.NoError(t, err)
+		assert.Same(t, app, res)
+
+		res, err = AppFromDefinition(unknown)
+		require.NoError(t, err)
+		assert.True(t, res.Def().Equals(unknown))
+	})
+}
+
+func TestAppRegistry_Default(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xA9A))
+	withAppRegistry(func() {
+		RegisterDefaultAppBackend(new(MockAppBackend))
+		def := wtest.NewRandomAddress(rng)
+		app, err := AppFromDefinition(def)
+		require.NoError(t, err)
+		assert.IsType(t, (*MockApp)(nil), app)
+		assert.True(t, app.Def().Equals(def))
+	})
+}
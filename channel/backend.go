@@ -6,15 +6,15 @@
 package channel
 
 import (
+	"fmt"
 	"io"
+	"sync"
+
+	"github.com/pkg/errors"
 
 	"perun.network/go-perun/wallet"
 )
 
-// backend is set to the global channel backend. Must not be set directly but
-// through importing the needed backend.
-var backend Backend
-
 // Backend is an interface that needs to be implemented for every blockchain.
 // It provides basic functionalities to the framework.
 type Backend interface {
@@ -41,31 +41,79 @@ type Backend interface {
 	DecodeAsset(io.Reader) (Asset, error)
 }
 
-// SetBackend sets the global channel backend. Must not be called directly but
-// through importing the needed backend.
-func SetBackend(b Backend) {
-	if backend != nil {
-		panic("channel backend already set")
+// backends holds the channel backends by the IDs of their wallet backends.
+var backends = struct {
+	sync.RWMutex
+	m map[wallet.BackendID]Backend
+}{m: make(map[wallet.BackendID]Backend)}
+
+// RegisterBackend registers a channel backend under the ID of its wallet
+// backend. Must not be called directly but through importing the needed
+// backend. It panics if a backend is already registered under the ID.
+func RegisterBackend(id wallet.BackendID, b Backend) {
+	if b == nil {
+		panic("nil channel backend")
+	}
+	backends.Lock()
+	defer backends.Unlock()
+	if _, ok := backends.m[id]; ok {
+		panic(fmt.Sprintf("channel backend %d already registered", id))
 	}
-	backend = b
+	backends.m[id] = b
 }
 
-// CalcID calculates the CalcID.
-func CalcID(p *Params) ID {
-	return backend.CalcID(p)
+// BackendOf returns the channel backend registered under the given ID.
+func BackendOf(id wallet.BackendID) (Backend, error) {
+	backends.RLock()
+	defer backends.RUnlock()
+	b, ok := backends.m[id]
+	if !ok {
+		return nil, errors.Errorf("unknown channel backend %d", id)
+	}
+	return b, nil
 }
 
-// Sign creates a signature from the account a on state s.
+// CalcID calculates the channel ID with the backend of the parameters. It
+// returns an error if the backend of the parameters is not registered.
+func CalcID(p *Params) (ID, error) {
+	b, err := BackendOf(p.Backend)
+	if err != nil {
+		return ID{}, err
+	}
+	return b.CalcID(p), nil
+}
+
+// Sign creates a signature from the account a on state s with the backend of
+// the parameters.
 func Sign(a wallet.Account, p *Params, s *State) (wallet.Sig, error) {
-	return backend.Sign(a, p, s)
+	b, err := BackendOf(p.Backend)
+	if err != nil {
+		return nil, err
+	}
+	return b.Sign(a, p, s)
 }
 
-// Verify verifies that a signature was a valid signature from addr on a state.
+// Verify verifies that a signature was a valid signature from addr on a state
+// with the backend of the parameters.
 func Verify(addr wallet.Address, params *Params, state *State, sig wallet.Sig) (bool, error) {
-	return backend.Verify(addr, params, state, sig)
+	b, err := BackendOf(params.Backend)
+	if err != nil {
+		return false, err
+	}
+	return b.Verify(addr, params, state, sig)
 }
 
-// DecodeAsset decodes an Asset from an io.Reader.
+// DecodeAsset decodes an Asset from an io.Reader with the default backend.
 func DecodeAsset(r io.Reader) (Asset, error) {
-	return backend.DecodeAsset(r)
+	return DecodeAssetOf(wallet.DefaultBackend(), r)
+}
+
+// DecodeAssetOf decodes an Asset from an io.Reader with the backend of the
+// given ID.
+func DecodeAssetOf(id wallet.BackendID, r io.Reader) (Asset, error) {
+	b, err := BackendOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.DecodeAsset(r)
 }
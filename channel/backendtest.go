@@ -10,11 +10,15 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/wallet"
 )
 
-// SetBackendTest is a generic backend test.
-func SetBackendTest(t *testing.T) {
-	assert.Panics(t, func() { SetBackend(nil) }, "nil backend set should panic")
-	require.NotNil(t, backend, "backend should be already set by init()")
-	assert.Panics(t, func() { SetBackend(backend) }, "setting a backend twice should panic")
+// RegisterBackendTest is a generic test to test that the channel backend with
+// the given ID is registered correctly.
+func RegisterBackendTest(t *testing.T, id wallet.BackendID) {
+	assert.Panics(t, func() { RegisterBackend(id, nil) }, "registering a nil backend should panic")
+	b, err := BackendOf(id)
+	require.NoError(t, err, "backend should be already registered by init()")
+	assert.Panics(t, func() { RegisterBackend(id, b) }, "registering a backend twice should panic")
 }
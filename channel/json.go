@@ -0,0 +1,362 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package channel
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// The JSON encodings of Allocation, SubAlloc, Params, State and Transaction
+// are stable and look as follows. Balances and nonces are decimal strings and
+// channel IDs are hex strings. Participants, app definitions, assets, app data
+// and signatures are hex strings of their binary encoding, see
+// perunio.EncodeHex. They are decoded by the backend of the channel and the
+// app data by the app. Missing signatures are empty strings.
+//
+//	Allocation:  {"assets": ["0x…"], "balances": [["10", "20"]], "locked": [SubAlloc]}
+//	SubAlloc:    {"id": "0x…", "bals": ["5"]}
+//	Params:      {"id": "0x…", "backend": 0, "challengeDuration": 60,
+//	              "parts": ["0x…", "0x…"], "app": "0x…", "nonce": "42"}
+//	State:       {"id": "0x…", "backend": 0, "version": 1, "app": "0x…",
+//	              "allocation": Allocation, "data": "0x…", "isFinal": false}
+//	Transaction: {"state": State, "sigs": ["0x…", ""]}
+type (
+	jsonAllocation struct {
+		Assets   []string       `json:"assets"`
+		Balances [][]string     `json:"balances"`
+		Locked   []jsonSubAlloc `json:"locked"`
+	}
+
+	jsonSubAlloc struct {
+		ID   string   `json:"id"`
+		Bals []string `json:"bals"`
+	}
+
+	jsonParams struct {
+		ID                string           `json:"id"`
+		Backend           wallet.BackendID `json:"backend"`
+		ChallengeDuration uint64           `json:"challengeDuration"`
+		Parts             []string         `json:"parts"`
+		App               string           `json:"app"`
+		Nonce             string           `json:"nonce"`
+	}
+
+	jsonState struct {
+		ID         string           `json:"id"`
+		Backend    wallet.BackendID `json:"backend"`
+		Version    uint64           `json:"version"`
+		App        string           `json:"app"`
+		Allocation jsonAllocation   `json:"allocation"`
+		Data       string           `json:"data"`
+		IsFinal    bool             `json:"isFinal"`
+	}
+
+	jsonTransaction struct {
+		State *State   `json:"state"`
+		Sigs  []string `json:"sigs"`
+	}
+)
+
+var (
+	_ json.Marshaler   = Allocation{}
+	_ json.Unmarshaler = (*Allocation)(nil)
+	_ json.Marshaler   = SubAlloc{}
+	_ json.Unmarshaler = (*SubAlloc)(nil)
+	_ json.Marshaler   = (*Params)(nil)
+	_ json.Unmarshaler = (*Params)(nil)
+	_ json.Marshaler   = State{}
+	_ json.Unmarshaler = (*State)(nil)
+	_ json.Marshaler   = Transaction{}
+	_ json.Unmarshaler = (*Transaction)(nil)
+)
+
+// MarshalJSON encodes the allocation as JSON.
+func (a Allocation) MarshalJSON() ([]byte, error) {
+	j, err := a.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes an allocation from JSON. The assets are decoded with
+// the default backend.
+func (a *Allocation) UnmarshalJSON(data []byte) error {
+	return a.UnmarshalJSONOf(wallet.DefaultBackend(), data)
+}
+
+// UnmarshalJSONOf decodes an allocation from JSON. The assets are decoded
+// with the backend of the given ID.
+func (a *Allocation) UnmarshalJSONOf(backend wallet.BackendID, data []byte) error {
+	var j jsonAllocation
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling allocation")
+	}
+	return a.fromJSON(backend, &j)
+}
+
+func (a Allocation) toJSON() (j jsonAllocation, err error) {
+	j.Assets = make([]string, len(a.Assets))
+	for i, asset := range a.Assets {
+		if j.Assets[i], err = perunio.EncodeHex(asset); err != nil {
+			return j, errors.WithMessagef(err, "encoding asset %d", i)
+		}
+	}
+	j.Balances = make([][]string, len(a.Balances))
+	for i, bals := range a.Balances {
+		j.Balances[i] = balsToJSON(bals)
+	}
+	j.Locked = make([]jsonSubAlloc, len(a.Locked))
+	for i, sub := range a.Locked {
+		j.Locked[i] = sub.toJSON()
+	}
+	return j, nil
+}
+
+func (a *Allocation) fromJSON(backend wallet.BackendID, j *jsonAllocation) (err error) {
+	a.Assets = make([]Asset, len(j.Assets))
+	for i, s := range j.Assets {
+		if err := perunio.DecodeHex(s, func(r io.Reader) (err error) {
+			a.Assets[i], err = DecodeAssetOf(backend, r)
+			return err
+		}); err != nil {
+			return errors.WithMessagef(err, "decoding asset %d", i)
+		}
+	}
+	a.Balances = make([][]Bal, len(j.Balances))
+	for i, bals := range j.Balances {
+		if a.Balances[i], err = balsFromJSON(bals); err != nil {
+			return errors.WithMessagef(err, "decoding balances of asset %d", i)
+		}
+	}
+	a.Locked = make([]SubAlloc, len(j.Locked))
+	for i := range j.Locked {
+		if err := a.Locked[i].fromJSON(&j.Locked[i]); err != nil {
+			return errors.WithMessagef(err, "decoding suballocation %d", i)
+		}
+	}
+	return a.Valid()
+}
+
+// MarshalJSON encodes the sub-allocation as JSON.
+func (s SubAlloc) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+// UnmarshalJSON decodes a sub-allocation from JSON.
+func (s *SubAlloc) UnmarshalJSON(data []byte) error {
+	var j jsonSubAlloc
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling sub-allocation")
+	}
+	return s.fromJSON(&j)
+}
+
+func (s SubAlloc) toJSON() jsonSubAlloc {
+	return jsonSubAlloc{ID: idToJSON(s.ID), Bals: balsToJSON(s.Bals)}
+}
+
+func (s *SubAlloc) fromJSON(j *jsonSubAlloc) (err error) {
+	if s.ID, err = idFromJSON(j.ID); err != nil {
+		return err
+	}
+	if s.Bals, err = balsFromJSON(j.Bals); err != nil {
+		return err
+	}
+	return s.Valid()
+}
+
+// MarshalJSON encodes the parameters as JSON.
+func (p *Params) MarshalJSON() ([]byte, error) {
+	j := jsonParams{
+		ID:                idToJSON(p.id),
+		Backend:           p.Backend,
+		ChallengeDuration: p.ChallengeDuration,
+		Parts:             make([]string, len(p.Parts)),
+	}
+	var err error
+	for i, part := range p.Parts {
+		if j.Parts[i], err = perunio.EncodeHex(part); err != nil {
+			return nil, errors.WithMessagef(err, "encoding participant %d", i)
+		}
+	}
+	if j.App, err = perunio.EncodeHex(p.App.Def()); err != nil {
+		return nil, errors.WithMessage(err, "encoding app definition")
+	}
+	if p.Nonce != nil {
+		j.Nonce = p.Nonce.String()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes parameters from JSON. The parameters are validated
+// like in NewParams and the ID must match the calculated channel ID.
+func (p *Params) UnmarshalJSON(data []byte) error {
+	var j jsonParams
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling params")
+	}
+
+	parts := make([]wallet.Address, len(j.Parts))
+	for i, s := range j.Parts {
+		var err error
+		if parts[i], err = wallet.DecodeAddressHexOf(j.Backend, s); err != nil {
+			return errors.WithMessagef(err, "decoding participant %d", i)
+		}
+	}
+	appDef, err := wallet.DecodeAddressHexOf(j.Backend, j.App)
+	if err != nil {
+		return errors.WithMessage(err, "decoding app definition")
+	}
+	nonce, ok := new(big.Int).SetString(j.Nonce, 10)
+	if !ok {
+		return errors.Errorf("invalid nonce %q", j.Nonce)
+	}
+	id, err := idFromJSON(j.ID)
+	if err != nil {
+		return errors.WithMessage(err, "decoding channel ID")
+	}
+
+	params, err := NewParams(j.Backend, j.ChallengeDuration, parts, appDef, nonce)
+	if err != nil {
+		return err
+	}
+	if params.id != id {
+		return errors.Errorf("channel ID %x does not match the parameters, expected %x", id, params.id)
+	}
+	*p = *params
+	return nil
+}
+
+// MarshalJSON encodes the state as JSON.
+func (s State) MarshalJSON() ([]byte, error) {
+	alloc, err := s.Allocation.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	j := jsonState{
+		ID:         idToJSON(s.ID),
+		Backend:    s.Backend,
+		Version:    s.Version,
+		Allocation: alloc,
+		IsFinal:    s.IsFinal,
+	}
+	if j.App, err = perunio.EncodeHex(s.App.Def()); err != nil {
+		return nil, errors.WithMessage(err, "encoding app definition")
+	}
+	if j.Data, err = perunio.EncodeHex(s.Data); err != nil {
+		return nil, errors.WithMessage(err, "encoding app data")
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a state from JSON. The app data is decoded by the app
+// of the app definition.
+func (s *State) UnmarshalJSON(data []byte) (err error) {
+	var j jsonState
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling state")
+	}
+
+	if s.ID, err = idFromJSON(j.ID); err != nil {
+		return errors.WithMessage(err, "decoding channel ID")
+	}
+	s.Backend, s.Version, s.IsFinal = j.Backend, j.Version, j.IsFinal
+	if err := s.Allocation.fromJSON(j.Backend, &j.Allocation); err != nil {
+		return errors.WithMessage(err, "decoding allocation")
+	}
+	def, err := wallet.DecodeAddressHexOf(j.Backend, j.App)
+	if err != nil {
+		return errors.WithMessage(err, "decoding app definition")
+	}
+	if s.App, err = AppFromDefinition(def); err != nil {
+		return errors.WithMessage(err, "app from definition")
+	}
+	err = perunio.DecodeHex(j.Data, func(r io.Reader) (err error) {
+		s.Data, err = s.App.DecodeData(r)
+		return err
+	})
+	return errors.WithMessage(err, "decoding app data")
+}
+
+// MarshalJSON encodes the transaction as JSON.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	j := jsonTransaction{State: t.State, Sigs: make([]string, len(t.Sigs))}
+	for i, sig := range t.Sigs {
+		var err error
+		if j.Sigs[i], err = wallet.EncodeSigHex(sig); err != nil {
+			return nil, errors.WithMessagef(err, "encoding signature %d", i)
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a transaction from JSON. There must be a signature,
+// or an empty string, for each participant of the state. The signatures are
+// decoded by the backend of the state.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	var j jsonTransaction
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling transaction")
+	}
+
+	t.State, t.Sigs = j.State, nil
+	if t.State == nil {
+		if len(j.Sigs) != 0 {
+			return errors.New("signatures without state")
+		}
+		return nil
+	}
+	if len(j.Sigs) != t.State.NumParts() {
+		return errors.Errorf("got %d signatures, expected %d", len(j.Sigs), t.State.NumParts())
+	}
+	t.Sigs = make([]wallet.Sig, len(j.Sigs))
+	for i, s := range j.Sigs {
+		var err error
+		if t.Sigs[i], err = wallet.DecodeSigHexOf(t.State.Backend, s); err != nil {
+			return errors.WithMessagef(err, "decoding signature %d", i)
+		}
+	}
+	return nil
+}
+
+func idToJSON(id ID) string {
+	s, _ := perunio.EncodeHex(perunio.ByteSlice(id[:])) // cannot fail
+	return s
+}
+
+func idFromJSON(s string) (id ID, err error) {
+	b := perunio.ByteSlice(id[:])
+	err = perunio.DecodeHex(s, b.Decode)
+	return id, err
+}
+
+func balsToJSON(bals []Bal) []string {
+	strs := make([]string, len(bals))
+	for i, bal := range bals {
+		strs[i] = bal.String()
+	}
+	return strs
+}
+
+func balsFromJSON(strs []string) ([]Bal, error) {
+	bals := make([]Bal, len(strs))
+	for i, s := range strs {
+		bal, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, errors.Errorf("invalid balance %q", s)
+		}
+		bals[i] = bal
+	}
+	return bals, nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package channel_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	iotest "perun.network/go-perun/pkg/io/test"
+	"perun.network/go-perun/wallet"
+)
+
+func TestJSON(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x150))
+	for i := 0; i < 10; i++ {
+		params, state := test.NewRandomParamsAndState(rng, test.WithNumLocked(int(rng.Int31n(4))))
+		sigMask := make([]bool, len(params.Parts))
+		for j := range sigMask {
+			sigMask[j] = rng.Intn(2) == 0
+		}
+		tx := test.NewRandomTransaction(rng, sigMask)
+		iotest.GenericJSONTest(t, params, state, &state.Allocation, &tx)
+		for j := range state.Locked {
+			iotest.GenericJSONTest(t, &state.Locked[j])
+		}
+	}
+	iotest.GenericJSONTest(t, new(channel.Transaction))
+}
+
+func TestJSON_Format(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x151))
+	state := test.NewRandomState(rng,
+		test.WithNumAssets(1),
+		test.WithBalances([]channel.Bal{big.NewInt(10), big.NewInt(20)}),
+		test.WithNumLocked(0))
+
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	var j map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &j))
+	assert.Equal(t, []interface{}{[]interface{}{"10", "20"}},
+		j["allocation"].(map[string]interface{})["balances"], "decimal balances")
+	assert.Regexp(t, "^0x[0-9a-f]{64}$", j["id"])
+	assert.Regexp(t, "^0x[0-9a-f]+$", j["app"])
+}
+
+func TestJSON_Invalid(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x152))
+	params, state := test.NewRandomParamsAndState(rng)
+
+	t.Run("params", func(t *testing.T) {
+		var j map[string]interface{}
+		data, err := json.Marshal(params)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &j))
+
+		invalid := map[string]interface{}{
+			"id":                "0x00",
+			"nonce":             "0xff",
+			"challengeDuration": 0,
+			"parts":             []string{},
+			"app":               "0x",
+		}
+		for field, value := range invalid {
+			modified := make(map[string]interface{})
+			for k, v := range j {
+				modified[k] = v
+			}
+			modified[field] = value
+			data, err := json.Marshal(modified)
+			require.NoError(t, err)
+			assert.Errorf(t, json.Unmarshal(data, new(channel.Params)), "invalid %s", field)
+		}
+
+		otherID := make(map[string]interface{})
+		for k, v := range j {
+			otherID[k] = v
+		}
+		otherID["nonce"] = new(big.Int).Add(params.Nonce, big.NewInt(1)).String()
+		data, err = json.Marshal(otherID)
+		require.NoError(t, err)
+		assert.Error(t, json.Unmarshal(data, new(channel.Params)), "ID mismatch")
+	})
+
+	t.Run("allocation", func(t *testing.T) {
+		invalid := []string{
+			`{"assets": [], "balances": [], "locked": []}`,
+			`{"assets": ["0x"], "balances": [["1", "2"]], "locked": []}`,
+			`{"balances": [["1", "x"]]}`,
+			`{"balances": [["1", "-2"]]}`,
+		}
+		for _, data := range invalid {
+			assert.Error(t, json.Unmarshal([]byte(data), new(channel.Allocation)), data)
+		}
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		tx := channel.Transaction{State: state, Sigs: make([]wallet.Sig, 1)}
+		data, err := json.Marshal(tx)
+		require.NoError(t, err)
+		assert.Error(t, json.Unmarshal(data, new(channel.Transaction)), "wrong number of signatures")
+	})
+}
@@ -439,7 +439,7 @@ func (m *machine) expect(tr PhaseTransition) error {
 
 // validTransition checks that the transition from the current to the provided
 // state is valid. The following checks are run:
-// * matching channel ids
+// * matching channel ids and backends
 // * no transition from final state
 // * version increase by 1
 // * preservation of balances
@@ -449,6 +449,9 @@ func (m *machine) validTransition(to *State) error {
 	if to.ID != m.params.id {
 		return errors.New("new state's ID doesn't match")
 	}
+	if to.Backend != m.params.Backend {
+		return errors.New("new state's backend doesn't match")
+	}
 	if !m.params.App.Def().Equals(to.App.Def()) {
 		return errors.New("new state's App dosen't match")
 	}
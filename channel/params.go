@@ -35,6 +35,8 @@ var _ io.Serializer = (*Params)(nil)
 type Params struct {
 	// ChannelID is the channel ID as calculated by the backend
 	id ID
+	// Backend is the ID of the backend of the channel's blockchain.
+	Backend wallet.BackendID
 	// ChallengeDuration in seconds during disputes
 	ChallengeDuration uint64
 	// Parts are the channel participants
@@ -53,11 +55,14 @@ func (p *Params) ID() ID {
 // NewParams creates Params from the given data and performs sanity checks. The
 // channel id is also calculated here and persisted because it probably is an
 // expensive hash operation.
-func NewParams(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) (*Params, error) {
+func NewParams(backend wallet.BackendID, challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) (*Params, error) {
+	if _, err := BackendOf(backend); err != nil {
+		return nil, errors.WithMessage(err, "invalid backend for NewParams")
+	}
 	if err := ValidateParameters(challengeDuration, len(parts), appDef, nonce); err != nil {
 		return nil, errors.WithMessage(err, "invalid parameter for NewParams")
 	}
-	return NewParamsUnsafe(challengeDuration, parts, appDef, nonce), nil
+	return NewParamsUnsafe(backend, challengeDuration, parts, appDef, nonce), nil
 }
 
 // ValidateParameters checks that the arguments form valid Params:
@@ -91,19 +96,22 @@ func ValidateParameters(challengeDuration uint64, numParts int, appDef wallet.Ad
 // NewParamsUnsafe creates Params from the given data and does NOT perform sanity checks.
 // The channel id is also calculated here and persisted because it probably is an
 // expensive hash operation.
-func NewParamsUnsafe(challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) *Params {
+func NewParamsUnsafe(backend wallet.BackendID, challengeDuration uint64, parts []wallet.Address, appDef wallet.Address, nonce *big.Int) *Params {
 	app, err := AppFromDefinition(appDef)
 	if err != nil {
 		log.Panic("AppFromDefinition on validated parameters returned error")
 	}
 	p := &Params{
+		Backend:           backend,
 		ChallengeDuration: challengeDuration,
 		Parts:             parts,
 		App:               app,
 		Nonce:             nonce,
 	}
 	// probably an expensive hash operation, do it only once during creation.
-	p.id = CalcID(p)
+	if p.id, err = CalcID(p); err != nil {
+		log.Panic("CalcID on validated parameters returned error")
+	}
 	return p
 }
 
@@ -114,7 +122,7 @@ func (p *Params) Clone() *Params {
 		var buff bytes.Buffer
 		v.Encode(&buff)
 
-		addr, err := wallet.DecodeAddress(&buff)
+		addr, err := wallet.DecodeAddressOf(p.Backend, &buff)
 		if err != nil {
 			panic("Could not clone params' addresses")
 		}
@@ -123,6 +131,7 @@ func (p *Params) Clone() *Params {
 
 	return &Params{
 		id:                p.ID(),
+		Backend:           p.Backend,
 		ChallengeDuration: p.ChallengeDuration,
 		Parts:             clonedParts,
 		App:               p.App,
@@ -132,6 +141,7 @@ func (p *Params) Clone() *Params {
 // Encode uses the pkg/io module to serialize a params instance.
 func (p *Params) Encode(w stdio.Writer) error {
 	return io.Encode(w,
+		p.Backend,
 		p.id,
 		p.ChallengeDuration,
 		wallet.AddressesWithLen(p.Parts),
@@ -139,17 +149,38 @@ func (p *Params) Encode(w stdio.Writer) error {
 		p.Nonce)
 }
 
-// Decode uses the pkg/io module to deserialize a params instance.
+// Decode uses the pkg/io module to deserialize a params instance. The
+// participants and app definition are decoded with the backend whose ID is
+// encoded first.
 func (p *Params) Decode(r stdio.Reader) error {
-	var appDef wallet.Address
-	err := io.Decode(r,
-		&p.id,
-		&p.ChallengeDuration,
-		(*wallet.AddressesWithLen)(&p.Parts),
-		wallet.AddressDec{Addr: &appDef},
-		&p.Nonce)
+	if err := io.Decode(r, &p.Backend, &p.id, &p.ChallengeDuration); err != nil {
+		return errors.WithMessage(err, "decode backend, id or challenge duration")
+	}
+	if _, err := BackendOf(p.Backend); err != nil {
+		return err
+	}
+
+	var numParts uint16
+	if err := io.Decode(r, &numParts); err != nil {
+		return errors.WithMessage(err, "decode number of participants")
+	}
+	if err := io.CheckRemaining(r, int(numParts)); err != nil {
+		return errors.WithMessage(err, "decode participants")
+	}
+	p.Parts = make([]wallet.Address, numParts)
+	for i := range p.Parts {
+		var err error
+		if p.Parts[i], err = wallet.DecodeAddressOf(p.Backend, r); err != nil {
+			return errors.WithMessagef(err, "decode participant %d", i)
+		}
+	}
+
+	appDef, err := wallet.DecodeAddressOf(p.Backend, r)
 	if err != nil {
-		return errors.WithMessage(err, "decode fields")
+		return errors.WithMessage(err, "decode app definition")
+	}
+	if err := io.Decode(r, &p.Nonce); err != nil {
+		return errors.WithMessage(err, "decode nonce")
 	}
 
 	p.App, err = AppFromDefinition(appDef)
@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	ptest "perun.network/go-perun/channel/persistence/test"
+	ctest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	sktest "perun.network/go-perun/pkg/sortedkv/test"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+// TestPersistRestorer_Crash crashes the database at every write of a channel's
+// lifecycle and checks that the restored channel is either in the state before
+// or after the interrupted Persister call.
+func TestPersistRestorer_Crash(t *testing.T) {
+	writes := runCrashWorkload(t, -1)
+	require.NotZero(t, writes)
+	for crashAt := 0; crashAt < writes; crashAt++ {
+		require.Equal(t, crashAt, runCrashWorkload(t, crashAt), "crash at write %d", crashAt)
+	}
+}
+
+// runCrashWorkload runs a channel's lifecycle over a database that crashes at
+// write crashAt and returns the number of successful writes.
+func runCrashWorkload(t *testing.T, crashAt int) int {
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	// Set up the schema before faults are injected.
+	_, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+	cdb := sktest.NewCrashingDatabase(db, crashAt)
+	pr, err := NewPersistRestorer(cdb)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(0xC7A5))
+	accs, parts := wtest.NewRandomAccounts(rng, 2)
+	params := ctest.NewRandomParams(rng, ctest.WithParts(parts...))
+	csm, err := channel.NewStateMachine(accs[0], *params)
+	require.NoError(t, err)
+	sm := persistence.FromStateMachine(csm, pr)
+	peers := wtest.NewRandomAddresses(rng, 2)
+
+	update := func(final bool) error {
+		state := sm.State().Clone()
+		state.Version++
+		state.IsFinal = final
+		return sm.Update(ctx, state, sm.Idx())
+	}
+	sign := func() error {
+		_, err := sm.Sig(ctx)
+		return err
+	}
+	addSig := func() error {
+		sig, err := channel.Sign(accs[1], params, sm.StagingState())
+		require.NoError(t, err)
+		return sm.AddSig(ctx, 1, sig)
+	}
+	steps := []func() error{
+		func() error { return pr.ChannelCreated(ctx, sm, peers) },
+		func() error {
+			alloc := *ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+			return sm.Init(ctx, alloc, channel.NewMockOp(channel.OpValid))
+		},
+		sign, addSig,
+		func() error { return sm.EnableInit(ctx) },
+		func() error { return sm.SetFunded(ctx) },
+		func() error { return update(false) },
+		sign, addSig,
+		func() error { return sm.EnableUpdate(ctx) },
+		func() error { return update(true) },
+		sign, addSig,
+		func() error { return sm.EnableFinal(ctx) },
+		func() error { return sm.SetRegistering(ctx) },
+		func() error {
+			return sm.SetRegistered(ctx, &channel.RegisteredEvent{
+				ID:      sm.ID(),
+				Version: sm.State().Version,
+				Timeout: new(channel.ElapsedTimeout),
+			})
+		},
+		func() error { return sm.SetWithdrawing(ctx) },
+		func() error { return sm.SetWithdrawn(ctx) },
+		func() error { return pr.ChannelRemoved(ctx, sm.ID()) },
+	}
+
+	var before *persistence.Channel // nil while the channel is not persisted.
+	for i, step := range steps {
+		err := step()
+		var after *persistence.Channel
+		if i < len(steps)-1 {
+			after = persistence.CloneSource(sm)
+		}
+		if err != nil {
+			require.Equal(t, sktest.ErrCrashed, errors.Cause(err), "step %d", i)
+			requireCrashConsistent(t, db, sm.ID(), peers, before, after)
+			return cdb.Writes()
+		}
+		before = after
+	}
+	require.False(t, cdb.Crashed())
+	return cdb.Writes()
+}
+
+// requireCrashConsistent restores the channel from db after a crash and
+// checks that it is in one of the expected states, where nil means that the
+// channel does not exist.
+func requireCrashConsistent(
+	t *testing.T,
+	db sortedkv.Database,
+	id channel.ID,
+	peers []wire.Address,
+	before, after *persistence.Channel,
+) {
+	ctx := context.Background()
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+
+	ch, err := pr.RestoreChannel(ctx, id)
+	if err != nil {
+		require.True(t, before == nil || after == nil, "restoring channel: %v", err)
+	} else {
+		require.True(t,
+			(before != nil && ptest.EqualSource(before, ch)) ||
+				(after != nil && ptest.EqualSource(after, ch)),
+			"restored channel neither in state before nor after the crash")
+	}
+
+	// The channel must only be registered for its peers if it exists.
+	for _, peer := range peers {
+		it, err := pr.RestorePeer(peer)
+		require.NoError(t, err)
+		require.Equal(t, ch != nil, it.Next(ctx), "peer channels")
+		require.NoError(t, it.Close())
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"context"
+	stdsync "sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/wire"
+)
+
+var (
+	_ persistence.Pruner             = (*GroupCommitter)(nil)
+	_ persistence.ProposalPersister  = (*GroupCommitter)(nil)
+	_ persistence.TombstonePersister = (*GroupCommitter)(nil)
+)
+
+// GroupCommitter is a PersistRestorer decorator that collects the writes of
+// many channels in one batch, which is applied to the database in a single
+// write.
+//
+// Staged, Enabled, PhaseChanged and SigAdded for signatures of other
+// participants return as soon as their writes are added to the batch, which
+// is applied at the latest after the flush interval. All other Persister calls
+// wait until the batch containing their writes is applied. In particular,
+// SigAdded for the own signature waits, so a state and the own signature on
+// it are always durable before the signature is sent to any peer. If the node
+// crashes, only updates of up to the last flush interval that were not signed
+// by us can be lost.
+//
+// If a batch cannot be applied, all following calls fail with the same error
+// because the database may be missing channel updates.
+type GroupCommitter struct {
+	pr       *PersistRestorer
+	interval time.Duration
+
+	mutex   stdsync.Mutex
+	batch   sortedkv.Batch // pending writes
+	pending *commit        // commit of the pending writes, nil if none
+	err     error          // sticky error of a failed commit
+
+	flush   chan struct{} // requests a flush, buffered
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// commit is the result of applying a batch.
+type commit struct {
+	done  chan struct{} // closed after the batch was applied
+	err   error
+	timer *time.Timer
+}
+
+// NewGroupCommitter creates a GroupCommitter that writes to the given
+// PersistRestorer. Asynchronous writes are applied at the latest after the
+// given flush interval.
+func NewGroupCommitter(pr *PersistRestorer, interval time.Duration) *GroupCommitter {
+	g := &GroupCommitter{
+		pr:       pr,
+		interval: interval,
+		batch:    pr.db.NewBatch(),
+		flush:    make(chan struct{}, 1),
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	go g.flushLoop()
+	return g
+}
+
+// ChannelCreated adds the channel to the batch and waits until it is applied.
+func (g *GroupCommitter) ChannelCreated(ctx context.Context, s channel.Source, peers []wire.Address) error {
+	return g.write(ctx, true, func(batch sortedkv.Batch) error {
+		return channelCreated(batch, s, peers)
+	})
+}
+
+// ChannelRemoved adds the removal of the channel to the batch and waits until
+// it is applied. The removal is ordered after all writes of the channel that
+// were added before, so that they cannot re-create keys of the channel.
+func (g *GroupCommitter) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	// The params and peers are read from the database. They are written by
+	// ChannelCreated, which only returns after they are applied.
+	return g.write(ctx, true, func(batch sortedkv.Batch) error {
+		return g.pr.removeChannel(batch, id)
+	})
+}
+
+// Staged adds the staging state to the batch.
+func (g *GroupCommitter) Staged(ctx context.Context, s channel.Source) error {
+	return g.write(ctx, false, func(batch sortedkv.Batch) error { return staged(batch, s) })
+}
+
+// SigAdded adds the signature to the batch. If it is the own signature, it
+// waits until the batch is applied.
+func (g *GroupCommitter) SigAdded(ctx context.Context, s channel.Source, idx channel.Index) error {
+	return g.write(ctx, idx == s.Idx(), func(batch sortedkv.Batch) error {
+		return sigAdded(batch, s, idx)
+	})
+}
+
+// Enabled adds the new current state to the batch.
+func (g *GroupCommitter) Enabled(ctx context.Context, s channel.Source) error {
+	return g.write(ctx, false, func(batch sortedkv.Batch) error { return enabled(batch, s) })
+}
+
+// PhaseChanged adds the new phase to the batch.
+func (g *GroupCommitter) PhaseChanged(ctx context.Context, s channel.Source) error {
+	return g.write(ctx, false, func(batch sortedkv.Batch) error { return phaseChanged(batch, s) })
+}
+
+// Flush applies all pending writes and waits until they are durable.
+func (g *GroupCommitter) Flush(ctx context.Context) error {
+	return g.write(ctx, true, func(sortedkv.Batch) error { return nil })
+}
+
+// Close applies all pending writes and closes the underlying PersistRestorer.
+func (g *GroupCommitter) Close() error {
+	g.mutex.Lock()
+	select {
+	case <-g.closing:
+		g.mutex.Unlock()
+		return errors.New("already closed")
+	default:
+	}
+	close(g.closing)
+	g.mutex.Unlock()
+
+	<-g.closed
+	err := g.err
+	if cerr := g.pr.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ActivePeers applies all pending writes and returns all peers with which a
+// channel is persisted.
+func (g *GroupCommitter) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	if err := g.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return g.pr.ActivePeers(ctx)
+}
+
+// RestoreAll applies all pending writes and returns an iterator over all
+// persisted channels.
+func (g *GroupCommitter) RestoreAll() (persistence.ChannelIterator, error) {
+	if err := g.Flush(context.Background()); err != nil {
+		return nil, err
+	}
+	return g.pr.RestoreAll()
+}
+
+// RestorePeer applies all pending writes and returns an iterator over all
+// persisted channels with the given peer.
+func (g *GroupCommitter) RestorePeer(peer wire.Address) (persistence.ChannelIterator, error) {
+	if err := g.Flush(context.Background()); err != nil {
+		return nil, err
+	}
+	return g.pr.RestorePeer(peer)
+}
+
+// RestoreChannel applies all pending writes and restores the requested
+// channel.
+func (g *GroupCommitter) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	if err := g.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return g.pr.RestoreChannel(ctx, id)
+}
+
+// Prune applies all pending writes and prunes the withdrawn channels.
+func (g *GroupCommitter) Prune(ctx context.Context, before time.Time) (int, error) {
+	if err := g.Flush(ctx); err != nil {
+		return 0, err
+	}
+	return g.pr.Prune(ctx, before)
+}
+
+// ProposalUpdated persists the proposal directly.
+func (g *GroupCommitter) ProposalUpdated(ctx context.Context, prop *persistence.Proposal) error {
+	return g.pr.ProposalUpdated(ctx, prop)
+}
+
+// ProposalRemoved removes the proposal directly.
+func (g *GroupCommitter) ProposalRemoved(ctx context.Context, sessID [32]byte) error {
+	return g.pr.ProposalRemoved(ctx, sessID)
+}
+
+// RestoreProposals returns all persisted proposals.
+func (g *GroupCommitter) RestoreProposals(ctx context.Context) ([]*persistence.Proposal, error) {
+	return g.pr.RestoreProposals(ctx)
+}
+
+// TombstoneAdded persists the tombstone directly.
+func (g *GroupCommitter) TombstoneAdded(ctx context.Context, id channel.ID, version uint64) error {
+	return g.pr.TombstoneAdded(ctx, id, version)
+}
+
+// RestoreTombstones returns all persisted tombstones.
+func (g *GroupCommitter) RestoreTombstones(ctx context.Context) (map[channel.ID]uint64, error) {
+	return g.pr.RestoreTombstones(ctx)
+}
+
+// write adds the writes to the pending batch. If wait is set, it requests an
+// immediate flush and waits until the batch is applied.
+func (g *GroupCommitter) write(ctx context.Context, wait bool, writes func(sortedkv.Batch) error) error {
+	// Buffer the writes first so that a failing call does not leave partial
+	// writes in the shared batch.
+	var cb callBatch
+	if err := writes(&cb); err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	select {
+	case <-g.closing:
+		g.mutex.Unlock()
+		return errors.New("group committer closed")
+	default:
+	}
+	if g.err != nil {
+		g.mutex.Unlock()
+		return g.err
+	}
+	cb.target = g.batch
+	if err := cb.Apply(); err != nil {
+		g.mutex.Unlock()
+		return err
+	}
+	if g.pending == nil {
+		g.pending = &commit{done: make(chan struct{})}
+		g.pending.timer = time.AfterFunc(g.interval, g.requestFlush)
+	}
+	c := g.pending
+	g.mutex.Unlock()
+
+	if !wait {
+		return nil
+	}
+	g.requestFlush()
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return errors.WithMessage(ctx.Err(), "waiting for commit")
+	}
+}
+
+func (g *GroupCommitter) requestFlush() {
+	select {
+	case g.flush <- struct{}{}:
+	default: // A flush is already requested.
+	}
+}
+
+// flushLoop applies the pending batch whenever a flush is requested. Writes
+// that are added while a batch is applied are collected in the next batch.
+func (g *GroupCommitter) flushLoop() {
+	defer close(g.closed)
+	for {
+		select {
+		case <-g.flush:
+			g.commit()
+		case <-g.closing:
+			g.commit()
+			return
+		}
+	}
+}
+
+// commit applies the pending batch and notifies all waiting writers.
+func (g *GroupCommitter) commit() {
+	g.mutex.Lock()
+	batch, c := g.batch, g.pending
+	if c == nil {
+		g.mutex.Unlock()
+		return
+	}
+	g.batch, g.pending = g.pr.db.NewBatch(), nil
+	failed := g.err
+	g.mutex.Unlock()
+
+	c.timer.Stop()
+	// Later writes may depend on the writes of a failed batch, so they are
+	// not applied either.
+	if c.err = failed; c.err == nil {
+		c.err = errors.WithMessage(batch.Apply(), "applying group batch")
+	}
+	if c.err != nil {
+		g.mutex.Lock()
+		g.err = c.err
+		g.mutex.Unlock()
+	}
+	close(c.done)
+}
+
+// callBatch buffers the writes of a single Persister call until they are
+// applied to the target.
+type callBatch struct {
+	target sortedkv.Writer
+	writes []func(sortedkv.Writer) error
+}
+
+func (b *callBatch) Put(key, value string) error {
+	b.writes = append(b.writes, func(w sortedkv.Writer) error { return w.Put(key, value) })
+	return nil
+}
+
+func (b *callBatch) PutBytes(key string, value []byte) error {
+	b.writes = append(b.writes, func(w sortedkv.Writer) error { return w.PutBytes(key, value) })
+	return nil
+}
+
+func (b *callBatch) Delete(key string) error {
+	b.writes = append(b.writes, func(w sortedkv.Writer) error { return w.Delete(key) })
+	return nil
+}
+
+// Apply writes all buffered writes to the target.
+func (b *callBatch) Apply() error {
+	for _, write := range b.writes {
+		if err := write(b.target); err != nil {
+			return errors.WithMessage(err, "adding write to group batch")
+		}
+	}
+	b.Reset()
+	return nil
+}
+
+func (b *callBatch) Reset() {
+	b.writes = nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	ptest "perun.network/go-perun/channel/persistence/test"
+	ctest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	sktest "perun.network/go-perun/pkg/sortedkv/test"
+	pkgtest "perun.network/go-perun/pkg/test"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+func newGroupCommitter(t *testing.T, db sortedkv.Database, interval time.Duration) (*PersistRestorer, *GroupCommitter) {
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+	return pr, NewGroupCommitter(pr, interval)
+}
+
+func TestGroupCommitter_Generic(t *testing.T) {
+	_, g := newGroupCommitter(t, memorydb.NewDatabase(), 10*time.Millisecond)
+	defer func() { require.NoError(t, g.Close()) }()
+
+	ptest.GenericPersistRestorerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x6C01)),
+		g,
+		4,
+		16)
+}
+
+func TestGroupCommitter_Prune(t *testing.T) {
+	_, g := newGroupCommitter(t, memorydb.NewDatabase(), 10*time.Millisecond)
+	defer func() { require.NoError(t, g.Close()) }()
+
+	ptest.GenericPrunerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x6C02)),
+		g,
+		8)
+}
+
+func TestGroupCommitter_OwnSigDurable(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6C03))
+	ctx := context.Background()
+	// The interval is long so that only synchronous writes are applied.
+	pr, g := newGroupCommitter(t, memorydb.NewDatabase(), time.Hour)
+	defer func() { require.NoError(t, g.Close()) }()
+
+	ch := ptest.NewRandomChannel(ctx, t, g, 0, wtest.NewRandomAddresses(rng, 2), rng)
+	persisted := func() bool {
+		restored, err := pr.RestoreChannel(ctx, ch.ID())
+		require.NoError(t, err)
+		return ptest.EqualSource(ch, restored)
+	}
+	require.True(t, persisted(), "ChannelCreated must be synchronous")
+
+	alloc := ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+	require.NoError(t, ch.StateMachine.Init(ctx, *alloc, channel.NewMockOp(channel.OpValid)))
+	assert.False(t, persisted(), "Staged should be asynchronous")
+
+	_, err := ch.StateMachine.Sig(ctx)
+	require.NoError(t, err)
+	assert.True(t, persisted(), "state must be durable with own signature")
+}
+
+func TestGroupCommitter_Interval(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6C04))
+	ctx := context.Background()
+	pr, g := newGroupCommitter(t, memorydb.NewDatabase(), 50*time.Millisecond)
+	defer func() { require.NoError(t, g.Close()) }()
+
+	ch := ptest.NewRandomChannel(ctx, t, g, 0, wtest.NewRandomAddresses(rng, 2), rng)
+	alloc := ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+	require.NoError(t, ch.StateMachine.Init(ctx, *alloc, channel.NewMockOp(channel.OpValid)))
+
+	pkgtest.Within1s.Eventually(t, func(t pkgtest.T) {
+		restored, err := pr.RestoreChannel(ctx, ch.ID())
+		require.NoError(t, err)
+		require.True(t, ptest.EqualSource(ch, restored))
+	})
+}
+
+func TestGroupCommitter_Failure(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6C05))
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	// Set up the schema before faults are injected.
+	_, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+	// Only ChannelCreated succeeds.
+	_, g := newGroupCommitter(t, sktest.NewCrashingDatabase(db, 1), time.Hour)
+
+	ch := ptest.NewRandomChannel(ctx, t, g, 0, wtest.NewRandomAddresses(rng, 2), rng)
+	alloc := ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+	require.NoError(t, ch.StateMachine.Init(ctx, *alloc, channel.NewMockOp(channel.OpValid)))
+	_, err = ch.StateMachine.Sig(ctx)
+	assert.True(t, errors.Is(err, sktest.ErrCrashed))
+
+	// All following calls fail.
+	assert.True(t, errors.Is(g.PhaseChanged(ctx, ch), sktest.ErrCrashed))
+	_, err = g.RestoreChannel(ctx, ch.ID())
+	assert.True(t, errors.Is(err, sktest.ErrCrashed))
+	assert.True(t, errors.Is(g.Close(), sktest.ErrCrashed))
+}
+
+func TestGroupCommitter_ChannelRemoved(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x6C06))
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	_, g := newGroupCommitter(t, db, time.Hour)
+	defer func() { require.NoError(t, g.Close()) }()
+
+	ch := ptest.NewRandomChannel(ctx, t, g, 0, wtest.NewRandomAddresses(rng, 2), rng)
+	alloc := ctest.NewRandomAllocation(rng, ctest.WithNumParts(2))
+	// Staged is pending in the batch when the channel is removed.
+	require.NoError(t, ch.StateMachine.Init(ctx, *alloc, channel.NewMockOp(channel.OpValid)))
+	require.NoError(t, g.ChannelRemoved(ctx, ch.ID()))
+	require.NoError(t, g.Flush(ctx))
+
+	it := sortedkv.NewTable(db, prefix.ChannelDB).NewIterator()
+	assert.False(t, it.Next(), "no keys of the removed channel must remain")
+	require.NoError(t, it.Close())
+}
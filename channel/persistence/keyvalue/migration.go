@@ -0,0 +1,228 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+// SchemaVersion is the version of the database layout that is written by this
+// implementation. Version 1 is the unversioned layout of go-perun v0.3.0.
+const SchemaVersion uint32 = 4
+
+// schemaKey is the key under which the schema version is stored.
+const schemaKey = "Schema:version"
+
+// A migration upgrades a database from one schema version to the next. All
+// changes are written to the batch, which is applied together with the new
+// schema version.
+type migration func(*PersistRestorer, sortedkv.Batch) error
+
+// migrations[v] upgrades a database from schema version v to v+1.
+var migrations = map[uint32]migration{
+	1: migrateNetworkPeers,
+	2: migrateChannelBackends,
+	3: migrateDataLengths,
+}
+
+// SchemaVersionError is returned when a database was written by a newer
+// version of go-perun and its schema is not supported.
+type SchemaVersionError struct {
+	Version uint32 // The schema version of the database.
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf(
+		"database schema version %d is newer than supported version %d, upgrade go-perun",
+		e.Version, SchemaVersion)
+}
+
+// IsSchemaVersionError returns true if the error was a SchemaVersionError.
+func IsSchemaVersionError(err error) bool {
+	_, ok := errors.Cause(err).(*SchemaVersionError)
+	return ok
+}
+
+// migrate upgrades the database to the current schema version. Empty
+// databases are marked with the current version, databases without version
+// are assumed to be of version 1. Each migration is applied atomically
+// together with its version so that an interrupted upgrade resumes where it
+// stopped.
+func (p *PersistRestorer) migrate() error {
+	version, err := p.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return errors.WithStack(&SchemaVersionError{Version: version})
+	}
+
+	for ; version < SchemaVersion; version++ {
+		batch := p.db.NewBatch()
+		if err := migrations[version](p, batch); err != nil {
+			return errors.WithMessagef(err, "migrating schema version %d", version)
+		}
+		if err := dbPut(batch, schemaKey, version+1); err != nil {
+			return err
+		}
+		if err := batch.Apply(); err != nil {
+			return errors.WithMessagef(err, "applying migration of version %d", version)
+		}
+	}
+	// Marks fresh databases.
+	if has, err := p.db.Has(schemaKey); err != nil || has {
+		return errors.WithMessage(err, "looking up schema version")
+	}
+	return dbPut(p.db, schemaKey, SchemaVersion)
+}
+
+// schemaVersion reads the schema version of the database.
+func (p *PersistRestorer) schemaVersion() (uint32, error) {
+	if has, err := p.db.Has(schemaKey); err != nil {
+		return 0, errors.WithMessage(err, "looking up schema version")
+	} else if !has {
+		it := p.db.NewIterator()
+		empty := !it.Next()
+		if err := it.Close(); err != nil {
+			return 0, errors.WithMessage(err, "iterating database")
+		}
+		if empty {
+			return SchemaVersion, nil
+		}
+		return 1, nil
+	}
+
+	b, err := p.db.GetBytes(schemaKey)
+	if err != nil {
+		return 0, errors.WithMessage(err, "getting schema version")
+	}
+	var version uint32
+	return version, errors.WithMessage(
+		perunio.Decode(bytes.NewReader(b), &version),
+		"decoding schema version")
+}
+
+// migrateNetworkPeers upgrades from version 1 to 2. Version 1 stored the
+// channel participants instead of the network peers in a channel's "peers"
+// key. The network peers are recovered from the "Peer" table.
+func migrateNetworkPeers(p *PersistRestorer, batch sortedkv.Batch) error {
+	peers := make(map[channel.ID][]wire.Address)
+	it := sortedkv.NewTable(p.db, prefix.PeerDB).NewIterator()
+	for it.Next() {
+		peer, id, err := decodePeerChanID(it.Key())
+		if err != nil {
+			it.Close()
+			return errors.WithMessage(err, "decoding peer channel key")
+		}
+		peers[id] = append(peers[id], peer)
+	}
+	if err := it.Close(); err != nil {
+		return errors.WithMessage(err, "iterating peer table")
+	}
+
+	for id, chPeers := range peers {
+		if err := dbPut(channelBatch(batch, id), prefix.Peers, wire.AddressesWithLen(chPeers)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateChannelBackends upgrades from version 2 to 3. Version 2 stored the
+// params and states of a channel without the ID of the channel's backend. All
+// existing channels belong to the default backend, whose ID is inserted in
+// front of the encoded params and states.
+func migrateChannelBackends(p *PersistRestorer, batch sortedkv.Batch) error {
+	var backend bytes.Buffer
+	if err := wallet.DefaultBackend().Encode(&backend); err != nil {
+		return errors.WithMessage(err, "encoding default backend")
+	}
+	withBackend := func(v []byte) []byte {
+		return append(append([]byte{}, backend.Bytes()...), v...)
+	}
+
+	chandb := sortedkv.NewTableBatch(batch, prefix.ChannelDB)
+	it := sortedkv.NewTable(p.db, prefix.ChannelDB).NewIterator()
+	for it.Next() {
+		key, v := it.Key(), it.ValueBytes()
+		if len(key) <= len(channel.ID{})+1 {
+			it.Close()
+			return errors.Errorf("invalid channel key %x", key)
+		}
+
+		var migrated []byte
+		switch key[len(channel.ID{})+1:] {
+		case "params":
+			migrated = withBackend(v)
+		case "staging:state":
+			if len(v) == 0 { // no staging state
+				continue
+			}
+			migrated = withBackend(v)
+		case "current":
+			// A transaction starts with a byte that tells whether it has a state.
+			if len(v) == 0 || v[0] == 0 {
+				continue
+			}
+			migrated = append([]byte{v[0]}, withBackend(v[1:])...)
+		default:
+			continue
+		}
+		if err := chandb.PutBytes(key, migrated); err != nil {
+			it.Close()
+			return errors.WithMessage(err, "putting migrated channel data")
+		}
+	}
+	return errors.WithMessage(it.Close(), "iterating channel table")
+}
+
+// migrateDataLengths upgrades from version 3 to 4. Version 3 stored the app
+// data of the staging state and the current transaction without its length,
+// which is inserted in front of it, see channel.EncodeData.
+func migrateDataLengths(p *PersistRestorer, batch sortedkv.Batch) error {
+	chandb := sortedkv.NewTableBatch(batch, prefix.ChannelDB)
+	it := sortedkv.NewTable(p.db, prefix.ChannelDB).NewIterator()
+	for it.Next() {
+		key, v := it.Key(), it.ValueBytes()
+		if len(key) <= len(channel.ID{})+1 {
+			it.Close()
+			return errors.Errorf("invalid channel key %x", key)
+		}
+
+		var migrated []byte
+		var err error
+		switch key[len(channel.ID{})+1:] {
+		case "staging:state":
+			if len(v) == 0 { // no staging state
+				continue
+			}
+			migrated, err = persistence.MigrateStateData(v)
+		case "current":
+			migrated, err = persistence.MigrateTransactionData(v)
+		default:
+			continue
+		}
+		if err != nil {
+			it.Close()
+			return errors.WithMessagef(err, "migrating %s", key)
+		}
+		if err := chandb.PutBytes(key, migrated); err != nil {
+			it.Close()
+			return errors.WithMessage(err, "putting migrated channel data")
+		}
+	}
+	return errors.WithMessage(it.Close(), "iterating channel table")
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel/persistence/test"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	"perun.network/go-perun/wire"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+func TestPersistRestorer_SchemaVersion(t *testing.T) {
+	db := memorydb.NewDatabase()
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+	version, err := pr.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	require.NoError(t, dbPut(db, schemaKey, SchemaVersion+1))
+	_, err = NewPersistRestorer(db)
+	assert.True(t, IsSchemaVersionError(err))
+}
+
+func TestPersistRestorer_MigrateNetworkPeers(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3c4))
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+
+	c := test.NewClient(ctx, t, rng, pr)
+	peer := wtest.NewRandomAddress(rng)
+	ch := c.NewChannel(t, peer)
+
+	// Turn the database into a version 1 database.
+	require.NoError(t, db.Delete(schemaKey))
+	putLegacyChannel(t, db, ch, false)
+	require.NoError(t, dbPut(pr.channelDB(ch.ID()), prefix.Peers,
+		wire.AddressesWithLen(ch.Params().Parts)))
+
+	pr, err = NewPersistRestorer(db)
+	require.NoError(t, err)
+	version, err := pr.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	peers, err := pr.peersForChan(ch.ID())
+	require.NoError(t, err)
+	assert.Len(t, peers, 2)
+	require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+	active, err := pr.ActivePeers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+}
+
+func TestPersistRestorer_MigrateChannelBackends(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3c5))
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+
+	ch := newUpdatedChannel(ctx, t, rng, pr)
+
+	// Turn the database into a version 2 database.
+	require.NoError(t, dbPut(db, schemaKey, uint32(2)))
+	putLegacyChannel(t, db, ch, false)
+
+	pr, err = NewPersistRestorer(db)
+	require.NoError(t, err)
+	restored, err := pr.RestoreChannel(ctx, ch.ID())
+	require.NoError(t, err)
+	ch.RequireEqual(t, restored)
+}
+
+func TestPersistRestorer_MigrateDataLengths(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3c6))
+	ctx := context.Background()
+	db := memorydb.NewDatabase()
+	pr, err := NewPersistRestorer(db)
+	require.NoError(t, err)
+
+	ch := newUpdatedChannel(ctx, t, rng, pr)
+
+	// Turn the database into a version 3 database.
+	require.NoError(t, dbPut(db, schemaKey, uint32(3)))
+	putLegacyChannel(t, db, ch, true)
+
+	pr, err = NewPersistRestorer(db)
+	require.NoError(t, err)
+	restored, err := pr.RestoreChannel(ctx, ch.ID())
+	require.NoError(t, err)
+	ch.RequireEqual(t, restored)
+}
+
+// newUpdatedChannel creates a funded channel with a current and a staging
+// state.
+func newUpdatedChannel(ctx context.Context, t *testing.T, rng *rand.Rand, pr *PersistRestorer) *test.Channel {
+	c := test.NewClient(ctx, t, rng, pr)
+	ch := c.NewChannel(t, wtest.NewRandomAddress(rng))
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+	ch.SetFunded(t)
+	state := ch.State().Clone()
+	state.Version++
+	require.NoError(t, ch.Update(t, state, ch.Idx()))
+	return ch
+}
+
+// putLegacyChannel stores the params and states of the given channel as they
+// were stored before schema version 4, without the lengths of app data. If
+// withBackends is false, the backend IDs are removed from the params and
+// states, as they were stored before schema version 3.
+func putLegacyChannel(t *testing.T, db sortedkv.Database, ch *test.Channel, withBackends bool) {
+	const n = 4 // length of an encoded backend ID
+	withoutBackend := func(v []byte) []byte {
+		if withBackends || len(v) == 0 {
+			return v
+		}
+		return v[n:]
+	}
+
+	chandb := sortedkv.NewTable(db, channelPrefix(ch.ID()))
+	params, err := chandb.GetBytes("params")
+	require.NoError(t, err)
+	require.NoError(t, chandb.PutBytes("params", withoutBackend(params)))
+
+	var staging []byte
+	if s := ch.StagingTX().State; s != nil {
+		staging, err = test.EncodeLegacyState(s)
+		require.NoError(t, err)
+	}
+	require.NoError(t, chandb.PutBytes("staging:state", withoutBackend(staging)))
+
+	current, err := test.EncodeLegacyTransaction(ch.CurrentTX())
+	require.NoError(t, err)
+	if current[0] == 1 {
+		current = append([]byte{1}, withoutBackend(current[1:])...)
+	}
+	require.NoError(t, chandb.PutBytes("current", current))
+}
@@ -13,6 +13,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -24,17 +25,28 @@ import (
 
 // ChannelCreated inserts a channel into the database.
 func (p *PersistRestorer) ChannelCreated(_ context.Context, s channel.Source, peers []wire.Address) error {
-	db := p.channelDB(s.ID()).NewBatch()
+	return p.apply(func(batch sortedkv.Batch) error { return channelCreated(batch, s, peers) })
+}
+
+// channelCreated adds the insertion of a channel to the batch.
+func channelCreated(batch sortedkv.Batch, s channel.Source, peers []wire.Address) error {
 	// Write the channel data in the "Channel" table.
+	db := channelBatch(batch, s.ID())
 	numParts := len(s.Params().Parts)
-	keys := append([]string{"current", "index", "params", "peers", "phase", "staging:state"},
+	keys := append([]string{"current", "index", "params", "phase", "staging:state"},
 		sigKeys(numParts)...)
 	if err := dbPutSource(db, s, keys...); err != nil {
 		return err
 	}
+	// The peers are needed to unregister the channel from the "Peer" table.
+	// Before schema version 2, the participants were stored instead, see
+	// migrateNetworkPeers.
+	if err := dbPut(db, prefix.Peers, wire.AddressesWithLen(peers)); err != nil {
+		return err
+	}
 
 	// Register the channel in the "Peer" table.
-	peerdb := sortedkv.NewTable(p.db, prefix.PeerDB).NewBatch()
+	peerdb := sortedkv.NewTableBatch(batch, prefix.PeerDB)
 	for _, peer := range peers {
 		key, err := peerChannelKey(peer, s.ID())
 		if err != nil {
@@ -44,11 +56,7 @@ func (p *PersistRestorer) ChannelCreated(_ context.Context, s channel.Source, pe
 			return errors.WithMessage(err, "putting peer channel")
 		}
 	}
-
-	if err := db.Apply(); err != nil {
-		return errors.WithMessage(err, "applying channel batch")
-	}
-	return errors.WithMessage(peerdb.Apply(), "applying peer batch")
+	return nil
 }
 
 // sigKey creates a key for given idx and number of channel
@@ -60,8 +68,17 @@ func sigKey(idx, numParts int) string {
 
 // ChannelRemoved deletes a channel from the database.
 func (p *PersistRestorer) ChannelRemoved(_ context.Context, id channel.ID) error {
-	db := p.channelDB(id).NewBatch()
-	peerdb := sortedkv.NewTable(p.db, prefix.PeerDB).NewBatch()
+	batch := p.db.NewBatch()
+	if err := p.removeChannel(batch, id); err != nil {
+		return err
+	}
+	return errors.WithMessage(batch.Apply(), "applying batch")
+}
+
+// removeChannel adds the deletion of all of a channel's keys to the batch.
+func (p *PersistRestorer) removeChannel(batch sortedkv.Batch, id channel.ID) error {
+	db := channelBatch(batch, id)
+	peerdb := sortedkv.NewTableBatch(batch, prefix.PeerDB)
 	// All keys a channel has.
 	params, err := p.getParamsForChan(id)
 	if err != nil {
@@ -89,23 +106,21 @@ func (p *PersistRestorer) ChannelRemoved(_ context.Context, id channel.ID) error
 			return errors.WithMessage(err, "deleting peer channel")
 		}
 	}
-
-	if err := db.Apply(); err != nil {
-		return errors.WithMessage(err, "applying channel batch")
-	}
-	return errors.WithMessage(peerdb.Apply(), "applying peer batch")
+	return nil
 }
 
 // peersForChan returns a slice of peer addresses for a given channel id from
 // the db of PersistRestorer.
 func (p *PersistRestorer) peersForChan(id channel.ID) ([]wire.Address, error) {
 	var ps wire.AddressesWithLen
-	peers, err := p.channelDB(id).Get(prefix.Peers)
+	peers, err := p.channelDB(id).GetBytes(prefix.Peers)
 	if err != nil {
 		return nil, errors.WithMessage(err, "unable to get peerlist from db")
 	}
-	return []wire.Address(ps), errors.WithMessage(perunio.Decode(bytes.NewBuffer([]byte(peers)), &ps),
-		"decoding peerlist")
+	if err := perunio.Decode(bytes.NewBuffer(peers), &ps); err != nil {
+		return nil, errors.WithMessage(err, "decoding peerlist")
+	}
+	return []wire.Address(ps), nil
 }
 
 // getParamsForChan returns the channel parameters for a given channel id from
@@ -133,43 +148,64 @@ func sigKeys(numParts int) []string {
 
 // Staged persists the staging transaction as well as the channel's phase.
 func (p *PersistRestorer) Staged(_ context.Context, s channel.Source) error {
-	db := p.channelDB(s.ID()).NewBatch()
-
-	if err := dbPutSource(db, s, "staging:state", "phase"); err != nil {
-		return err
-	}
-
-	return errors.WithMessage(db.Apply(), "applying batch")
+	return p.apply(func(batch sortedkv.Batch) error { return staged(batch, s) })
 }
 
 // SigAdded persists the channel's staging transaction.
 func (p *PersistRestorer) SigAdded(_ context.Context, s channel.Source, idx channel.Index) error {
-	db := p.channelDB(s.ID()).NewBatch()
+	return p.apply(func(batch sortedkv.Batch) error { return sigAdded(batch, s, idx) })
+}
 
-	numParts := len(s.Params().Parts)
-	key := sigKey(int(idx), numParts)
-	if err := dbPutSource(db, s, key); err != nil {
+// Enabled persists the channel's staging and current transaction, and phase.
+func (p *PersistRestorer) Enabled(_ context.Context, s channel.Source) error {
+	return p.apply(func(batch sortedkv.Batch) error { return enabled(batch, s) })
+}
+
+// PhaseChanged persists the channel's phase. If the channel got withdrawn,
+// the time of withdrawal is recorded for pruning.
+func (p *PersistRestorer) PhaseChanged(_ context.Context, s channel.Source) error {
+	return p.apply(func(batch sortedkv.Batch) error { return phaseChanged(batch, s) })
+}
+
+// apply writes to a new batch and applies it if all writes succeeded.
+func (p *PersistRestorer) apply(write func(sortedkv.Batch) error) error {
+	batch := p.db.NewBatch()
+	if err := write(batch); err != nil {
 		return err
 	}
+	return errors.WithMessage(batch.Apply(), "applying batch")
+}
 
-	return errors.WithMessage(db.Apply(), "applying batch")
+// staged adds the writes of Staged to the batch.
+func staged(batch sortedkv.Batch, s channel.Source) error {
+	return dbPutSource(channelBatch(batch, s.ID()), s, "staging:state", "phase")
 }
 
-// Enabled persists the channel's staging and current transaction, and phase.
-func (p *PersistRestorer) Enabled(_ context.Context, s channel.Source) error {
-	db := p.channelDB(s.ID()).NewBatch()
+// sigAdded adds the writes of SigAdded to the batch.
+func sigAdded(batch sortedkv.Batch, s channel.Source, idx channel.Index) error {
+	key := sigKey(int(idx), len(s.Params().Parts))
+	return dbPutSource(channelBatch(batch, s.ID()), s, key)
+}
 
+// enabled adds the writes of Enabled to the batch.
+func enabled(batch sortedkv.Batch, s channel.Source) error {
 	numParts := len(s.Params().Parts)
 	keys := append([]string{"staging:state", "current", "phase"}, sigKeys(numParts)...)
-	if err := dbPutSource(db, s, keys...); err != nil {
-		return err
-	}
-	return errors.WithMessage(db.Apply(), "applying batch")
+	return dbPutSource(channelBatch(batch, s.ID()), s, keys...)
 }
 
-// PhaseChanged persists the channel's phase.
-func (p *PersistRestorer) PhaseChanged(_ context.Context, s channel.Source) error {
-	return dbPut(p.channelDB(s.ID()), "phase", s.Phase())
+// phaseChanged adds the writes of PhaseChanged to the batch.
+func phaseChanged(batch sortedkv.Batch, s channel.Source) error {
+	if err := dbPut(channelBatch(batch, s.ID()), "phase", s.Phase()); err != nil {
+		return err
+	}
+	if s.Phase() == channel.Withdrawn {
+		withdrawndb := sortedkv.NewTableBatch(batch, prefix.WithdrawnDB)
+		if err := withdrawndb.Put(withdrawnKey(time.Now(), s.ID()), ""); err != nil {
+			return errors.WithMessage(err, "putting withdrawal time")
+		}
+	}
+	return nil
 }
 
 func dbPutSource(db sortedkv.Writer, s channel.Source, keys ...string) error {
@@ -189,8 +225,6 @@ func dbPutSourceField(db sortedkv.Writer, s channel.Source, key string) error {
 		return dbPut(db, key, s.Idx())
 	case "params":
 		return dbPut(db, key, s.Params())
-	case "peers":
-		return dbPut(db, key, wire.AddressesWithLen(s.Params().Parts))
 	case "phase":
 		return dbPut(db, key, s.Phase())
 	case "staging:state":
@@ -254,5 +288,15 @@ func peerChannelKey(p wire.Address, ch channel.ID) (string, error) {
 
 // channelDB creates a prefixed database for persisting a channel's data.
 func (p *PersistRestorer) channelDB(id channel.ID) sortedkv.Database {
-	return sortedkv.NewTable(p.db, prefix.ChannelDB+string(id[:])+":")
+	return sortedkv.NewTable(p.db, channelPrefix(id))
+}
+
+// channelBatch creates a prefixed view on a batch for writing a channel's
+// data.
+func channelBatch(batch sortedkv.Batch, id channel.ID) sortedkv.Batch {
+	return sortedkv.NewTableBatch(batch, channelPrefix(id))
+}
+
+func channelPrefix(id channel.ID) string {
+	return prefix.ChannelDB + string(id[:]) + ":"
 }
@@ -6,11 +6,13 @@
 package keyvalue
 
 import (
+	"github.com/pkg/errors"
+
 	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/pkg/sortedkv"
 )
 
-var _ persistence.PersistRestorer = (*PersistRestorer)(nil)
+var _ persistence.Pruner = (*PersistRestorer)(nil)
 
 // PersistRestorer implements both the persister and the restorer interface
 // using a sorted key-value store.
@@ -27,15 +29,24 @@ func (pr *PersistRestorer) Close() error {
 }
 
 // NewPersistRestorer creates a new PersistRestorer for the supplied database.
-func NewPersistRestorer(db sortedkv.Database) *PersistRestorer {
-	return &PersistRestorer{
+// Databases of older schema versions are migrated to the current version.
+// Databases of newer versions are refused with a SchemaVersionError.
+func NewPersistRestorer(db sortedkv.Database) (*PersistRestorer, error) {
+	pr := &PersistRestorer{
 		db: db,
 	}
+	if err := pr.migrate(); err != nil {
+		return nil, errors.WithMessage(err, "migrating database")
+	}
+	return pr, nil
 }
 
-var prefix = struct{ ChannelDB, PeerDB, SigKey, Peers string }{
-	ChannelDB: "Chan:",
-	PeerDB:    "Peer:",
-	SigKey:    "staging:sig:",
-	Peers:     "peers",
+var prefix = struct{ ChannelDB, PeerDB, WithdrawnDB, ProposalDB, TombstoneDB, SigKey, Peers string }{
+	ChannelDB:   "Chan:",
+	PeerDB:      "Peer:",
+	WithdrawnDB: "Withdrawn:",
+	ProposalDB:  "Proposal:",
+	TombstoneDB: "Tombstone:",
+	SigKey:      "staging:sig:",
+	Peers:       "peers",
 }
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,6 +18,7 @@ import (
 	_ "perun.network/go-perun/backend/sim"
 	"perun.network/go-perun/channel/persistence/test"
 	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/encrypted"
 	"perun.network/go-perun/pkg/sortedkv/leveldb"
 	"perun.network/go-perun/pkg/sortedkv/memorydb"
 )
@@ -27,15 +29,20 @@ func TestPersistRestorer_Generic(t *testing.T) {
 	lvldb, err := leveldb.LoadDatabase(tmpdir)
 	require.NoError(t, err)
 
+	encdb, err := encrypted.NewDatabase(memorydb.NewDatabase(), encrypted.Key{ID: 1})
+	require.NoError(t, err)
+
 	dbs := []sortedkv.Database{
 		lvldb,
 		memorydb.NewDatabase(),
+		encdb,
 	}
 
 	for _, db := range dbs {
 		func() {
 			defer func() { require.NoError(t, db.Close()) }()
-			pr := NewPersistRestorer(db)
+			pr, err := NewPersistRestorer(db)
+			require.NoError(t, err)
 			test.GenericPersistRestorerTest(
 				context.Background(),
 				t,
@@ -47,6 +54,51 @@ func TestPersistRestorer_Generic(t *testing.T) {
 	}
 }
 
+func TestPersistRestorer_Prune(t *testing.T) {
+	pr, err := NewPersistRestorer(memorydb.NewDatabase())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericPrunerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x94e)),
+		pr,
+		8)
+}
+
+func TestPersistRestorer_Proposals(t *testing.T) {
+	pr, err := NewPersistRestorer(memorydb.NewDatabase())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericProposalPersisterTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x9809)),
+		pr,
+		16)
+}
+
+func TestPersistRestorer_Tombstones(t *testing.T) {
+	pr, err := NewPersistRestorer(memorydb.NewDatabase())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericTombstonePersisterTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x7057)),
+		pr,
+		16)
+}
+
+func TestWithdrawnTimeKey(t *testing.T) {
+	before1970 := withdrawnTimeKey(time.Unix(-1, 0))
+	assert.Equal(t, withdrawnTimeKey(time.Unix(0, 0)), before1970, "times before 1970 must be clamped")
+	assert.Less(t, before1970, withdrawnTimeKey(time.Unix(1, 0)))
+}
+
 func TestChannelIterator_Next_Empty(t *testing.T) {
 	var it ChannelIterator
 	var success bool
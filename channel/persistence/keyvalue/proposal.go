@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+var _ persistence.ProposalPersister = (*PersistRestorer)(nil)
+
+// ProposalUpdated persists the proposal in the "Proposal" table, keyed by its
+// session ID.
+func (p *PersistRestorer) ProposalUpdated(_ context.Context, prop *persistence.Proposal) error {
+	var buf bytes.Buffer
+	if err := prop.Encode(&buf); err != nil {
+		return errors.WithMessage(err, "encoding proposal")
+	}
+	return errors.WithMessage(
+		p.proposalDB().PutBytes(string(prop.SessID[:]), buf.Bytes()),
+		"putting proposal")
+}
+
+// ProposalRemoved deletes the proposal with the given session ID.
+func (p *PersistRestorer) ProposalRemoved(_ context.Context, sessID [32]byte) error {
+	return errors.WithMessage(p.proposalDB().Delete(string(sessID[:])), "deleting proposal")
+}
+
+// RestoreProposals returns all persisted proposals.
+func (p *PersistRestorer) RestoreProposals(context.Context) ([]*persistence.Proposal, error) {
+	it := p.proposalDB().NewIterator()
+	var props []*persistence.Proposal
+	for it.Next() {
+		prop := new(persistence.Proposal)
+		if err := prop.Decode(bytes.NewBuffer(it.ValueBytes())); err != nil {
+			it.Close()
+			return nil, errors.WithMessagef(err, "decoding proposal %x", it.Key())
+		}
+		props = append(props, prop)
+	}
+	return props, errors.WithMessage(it.Close(), "iterating proposals")
+}
+
+// proposalDB returns the table of in-flight proposals.
+func (p *PersistRestorer) proposalDB() sortedkv.Database {
+	return sortedkv.NewTable(p.db, prefix.ProposalDB)
+}
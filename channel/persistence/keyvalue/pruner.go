@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// Prune removes all channels that were withdrawn before the given time. The
+// withdrawal times are kept in the "Withdrawn" table, sorted by time, so that
+// only the expired entries have to be iterated.
+func (p *PersistRestorer) Prune(_ context.Context, before time.Time) (int, error) {
+	db := p.withdrawnDB()
+	it := db.NewIteratorWithRange("", withdrawnTimeKey(before))
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Close(); err != nil {
+		return 0, errors.WithMessage(err, "iterating withdrawn channels")
+	}
+
+	pruned := 0
+	for _, key := range keys {
+		id, err := withdrawnKeyID(key)
+		if err != nil {
+			return pruned, err
+		}
+		batch := p.db.NewBatch()
+		// The channel might have been removed manually in the meantime.
+		has, err := p.channelDB(id).Has("params")
+		if err != nil {
+			return pruned, errors.WithMessage(err, "looking up channel")
+		} else if has {
+			if err := p.removeChannel(batch, id); err != nil {
+				return pruned, errors.WithMessagef(err, "removing channel %x", id)
+			}
+		}
+		if err := sortedkv.NewTableBatch(batch, prefix.WithdrawnDB).Delete(key); err != nil {
+			return pruned, errors.WithMessage(err, "deleting withdrawal time")
+		}
+		if err := batch.Apply(); err != nil {
+			return pruned, errors.WithMessage(err, "applying batch")
+		}
+		if has {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// withdrawnDB returns the table of withdrawal times.
+func (p *PersistRestorer) withdrawnDB() sortedkv.Database {
+	return sortedkv.NewTable(p.db, prefix.WithdrawnDB)
+}
+
+// withdrawnKey creates the key of the withdrawal time of a channel. Keys sort
+// by time because the time is encoded big-endian.
+func withdrawnKey(t time.Time, id channel.ID) string {
+	return withdrawnTimeKey(t) + string(id[:])
+}
+
+// withdrawnTimeKey encodes the time as key prefix. Times before 1970 are
+// clamped to 1970 so that they do not wrap around and break the order.
+func withdrawnTimeKey(t time.Time) string {
+	nanos := t.UnixNano()
+	if nanos < 0 {
+		nanos = 0
+	}
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], uint64(nanos))
+	return string(key[:])
+}
+
+func withdrawnKeyID(key string) (id channel.ID, err error) {
+	if len(key) != 8+len(id) {
+		return id, errors.Errorf("invalid withdrawal key %x", key)
+	}
+	copy(id[:], key[8:])
+	return id, nil
+}
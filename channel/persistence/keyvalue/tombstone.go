@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package keyvalue
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+var _ persistence.TombstonePersister = (*PersistRestorer)(nil)
+
+// TombstoneAdded persists the tombstone in the "Tombstone" table, keyed by
+// the channel ID.
+func (p *PersistRestorer) TombstoneAdded(_ context.Context, id channel.ID, version uint64) error {
+	return dbPut(p.tombstoneDB(), string(id[:]), version)
+}
+
+// RestoreTombstones returns all persisted tombstones.
+func (p *PersistRestorer) RestoreTombstones(context.Context) (map[channel.ID]uint64, error) {
+	it := p.tombstoneDB().NewIterator()
+	tombstones := make(map[channel.ID]uint64)
+	for it.Next() {
+		var id channel.ID
+		if len(it.Key()) != len(id) {
+			it.Close()
+			return nil, errors.Errorf("invalid tombstone key %x", it.Key())
+		}
+		copy(id[:], it.Key())
+		var version uint64
+		if err := perunio.Decode(bytes.NewReader(it.ValueBytes()), &version); err != nil {
+			it.Close()
+			return nil, errors.WithMessagef(err, "decoding tombstone %x", id)
+		}
+		tombstones[id] = version
+	}
+	return tombstones, errors.WithMessage(it.Close(), "iterating tombstones")
+}
+
+// tombstoneDB returns the table of tombstones.
+func (p *PersistRestorer) tombstoneDB() sortedkv.Database {
+	return sortedkv.NewTable(p.db, prefix.TombstoneDB)
+}
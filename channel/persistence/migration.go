@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// MigrateStateData converts an encoded state of the layout in which the app
+// data was not prefixed with its length, see channel.EncodeData. The app data
+// of such a state extends to the end of the encoding. It is used by the
+// migrations of the persisters.
+func MigrateStateData(enc []byte) ([]byte, error) {
+	_, _, start, err := legacyStateDataStart(enc)
+	if err != nil {
+		return nil, err
+	}
+	return withDataLen(enc, start, len(enc))
+}
+
+// MigrateTransactionData converts an encoded transaction of the layout in
+// which the app data of its state was not prefixed with its length. The app
+// data is followed by the signatures of the transaction. Its end is found as
+// the first position from which the rest of the encoding decodes as the
+// signatures, which is unique for backends with signatures of fixed length.
+func MigrateTransactionData(enc []byte) ([]byte, error) {
+	// A transaction starts with a byte that tells whether it has a state.
+	if len(enc) == 0 || enc[0]%2 == 0 {
+		return enc, nil
+	}
+	backend, numParts, start, err := legacyStateDataStart(enc[1:])
+	if err != nil {
+		return nil, err
+	}
+	start++
+
+	sigs := make([]wallet.Sig, numParts)
+	for end := start; end <= len(enc); end++ {
+		r := bytes.NewReader(enc[end:])
+		if wallet.DecodeSparseSigsOf(backend, r, &sigs) == nil && r.Len() == 0 {
+			return withDataLen(enc, start, end)
+		}
+	}
+	return nil, errors.New("no signatures after app data")
+}
+
+// legacyStateDataStart returns the backend and number of participants of an
+// encoded state and the position of its app data.
+func legacyStateDataStart(enc []byte) (backend wallet.BackendID, numParts, start int, err error) {
+	r := bytes.NewReader(enc)
+	var id channel.ID
+	var version uint64
+	if err = perunio.Decode(r, &backend, &id, &version); err != nil {
+		return 0, 0, 0, errors.WithMessage(err, "decoding backend, id or version")
+	}
+	var alloc channel.Allocation
+	if err = alloc.DecodeOf(backend, r); err != nil {
+		return 0, 0, 0, errors.WithMessage(err, "decoding allocation")
+	}
+	var isFinal bool
+	if err = perunio.Decode(r, &isFinal); err != nil {
+		return 0, 0, 0, errors.WithMessage(err, "decoding isFinal")
+	}
+	if _, err = wallet.DecodeAddressOf(backend, r); err != nil {
+		return 0, 0, 0, errors.WithMessage(err, "decoding app definition")
+	}
+	return backend, len(alloc.Balances[0]), len(enc) - r.Len(), nil
+}
+
+// withDataLen inserts the length of the app data enc[start:end] in front of
+// it.
+func withDataLen(enc []byte, start, end int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(enc[:start])
+	if err := perunio.Encode(&buf, uint32(end-start)); err != nil {
+		return nil, err
+	}
+	buf.Write(enc[start:])
+	return buf.Bytes(), nil
+}
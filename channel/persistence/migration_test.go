@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/test"
+	ctest "perun.network/go-perun/channel/test"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestMigrateStateData(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xDA7A))
+	for i := 0; i < 10; i++ {
+		s := ctest.NewRandomState(rng)
+		legacy, err := test.EncodeLegacyState(s)
+		require.NoError(t, err)
+
+		migrated, err := persistence.MigrateStateData(legacy)
+		require.NoError(t, err)
+		assert.Equal(t, encode(t, s), migrated)
+	}
+
+	_, err := persistence.MigrateStateData([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestMigrateTransactionData(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xDA7B))
+	for i := 0; i < 10; i++ {
+		accs, parts := wallettest.NewRandomAccounts(rng, 2+rng.Intn(8))
+		params := ctest.NewRandomParams(rng, ctest.WithParts(parts...))
+		tx := channel.Transaction{
+			State: ctest.NewRandomState(rng, ctest.WithParams(params)),
+			Sigs:  make([]wallet.Sig, len(parts)),
+		}
+		for j, acc := range accs {
+			if rng.Intn(2) == 0 {
+				continue // sparse signatures
+			}
+			sig, err := channel.Sign(acc, params, tx.State)
+			require.NoError(t, err)
+			tx.Sigs[j] = sig
+		}
+		legacy, err := test.EncodeLegacyTransaction(tx)
+		require.NoError(t, err)
+
+		migrated, err := persistence.MigrateTransactionData(legacy)
+		require.NoError(t, err)
+		assert.Equal(t, encode(t, tx), migrated)
+	}
+
+	migrated, err := persistence.MigrateTransactionData([]byte{0})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, migrated, "transaction without state")
+}
+
+func encode(t *testing.T, v perunio.Encoder) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, perunio.Encode(&buf, v))
+	return buf.Bytes()
+}
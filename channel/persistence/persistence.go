@@ -8,6 +8,7 @@ package persistence
 import (
 	"context"
 	"io"
+	"time"
 
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/wire"
@@ -64,6 +65,9 @@ type (
 		// persisted.
 		ActivePeers(context.Context) ([]wire.Address, error)
 
+		// RestoreAll should return an iterator over all persisted channels.
+		RestoreAll() (ChannelIterator, error)
+
 		// RestorePeer should return an iterator over all persisted channels which
 		// the given peer is a part of.
 		RestorePeer(wire.Address) (ChannelIterator, error)
@@ -79,6 +83,16 @@ type (
 		Restorer
 	}
 
+	// A Pruner is a PersistRestorer that keeps track of when channels were
+	// withdrawn so that their data can be removed after a retention period.
+	Pruner interface {
+		PersistRestorer
+
+		// Prune removes all channels that were withdrawn before the given time
+		// and returns how many channels were removed.
+		Prune(ctx context.Context, before time.Time) (int, error)
+	}
+
 	// A ChannelIterator is an iterator over Channels, i.e., channel data that is
 	// necessary for restoring a channel machine. It needs to be implemented by a
 	// persistence backend to allow the framework to restore channels.
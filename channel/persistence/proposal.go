@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wire"
+)
+
+type (
+	// A ProposalPersister is a PersistRestorer that also keeps track of channel
+	// proposals that are in flight, i.e., that were sent or accepted but whose
+	// channel has not been persisted yet. After a restart, the Client aborts
+	// these proposals with their peers.
+	ProposalPersister interface {
+		PersistRestorer
+
+		// ProposalUpdated should persist the proposal, overwriting a previously
+		// persisted proposal with the same session ID.
+		ProposalUpdated(context.Context, *Proposal) error
+
+		// ProposalRemoved should delete the proposal with the given session ID.
+		ProposalRemoved(ctx context.Context, sessID [32]byte) error
+
+		// RestoreProposals should return all persisted proposals.
+		RestoreProposals(context.Context) ([]*Proposal, error)
+	}
+
+	// A Proposal is the state of an in-flight channel proposal.
+	Proposal struct {
+		SessID [32]byte      // SessID is the proposal's session ID.
+		Role   ProposalRole  // Role is the own role in the proposal protocol.
+		Stage  ProposalStage // Stage is the last reached protocol stage.
+		Peer   wire.Address  // Peer is the other participant.
+	}
+
+	// ProposalRole is the role of a participant in the proposal protocol.
+	ProposalRole uint8
+
+	// ProposalStage is the stage of a proposal in the proposal protocol.
+	ProposalStage uint8
+)
+
+// The roles in the proposal protocol.
+const (
+	Proposer ProposalRole = iota
+	Responder
+)
+
+// The stages of the proposal protocol.
+const (
+	// ProposalSent means that the proposer sent the proposal.
+	ProposalSent ProposalStage = iota
+	// ProposalAccepted means that the proposal was accepted by the responder.
+	ProposalAccepted
+)
+
+// Encode encodes a Proposal into an io.Writer.
+func (p *Proposal) Encode(w io.Writer) error {
+	if err := perunio.Encode(w, p.SessID, uint8(p.Role), uint8(p.Stage)); err != nil {
+		return err
+	}
+	return p.Peer.Encode(w)
+}
+
+// Decode decodes a Proposal from an io.Reader.
+func (p *Proposal) Decode(r io.Reader) (err error) {
+	var role, stage uint8
+	if err := perunio.Decode(r, &p.SessID, &role, &stage); err != nil {
+		return err
+	}
+	p.Role, p.Stage = ProposalRole(role), ProposalStage(stage)
+	if !p.Role.Valid() {
+		return errors.Errorf("invalid proposal role %d", role)
+	} else if !p.Stage.Valid() {
+		return errors.Errorf("invalid proposal stage %d", stage)
+	}
+	p.Peer, err = wire.DecodeAddress(r)
+	return errors.WithMessage(err, "decoding peer")
+}
+
+var (
+	proposalRoleNames  = [...]string{"Proposer", "Responder"}
+	proposalStageNames = [...]string{"Sent", "Accepted"}
+)
+
+// Valid returns whether r is a known role.
+func (r ProposalRole) Valid() bool {
+	return int(r) < len(proposalRoleNames)
+}
+
+func (r ProposalRole) String() string {
+	if !r.Valid() {
+		return strconv.Itoa(int(r))
+	}
+	return proposalRoleNames[r]
+}
+
+// Valid returns whether s is a known stage.
+func (s ProposalStage) Valid() bool {
+	return int(s) < len(proposalStageNames)
+}
+
+func (s ProposalStage) String() string {
+	if !s.Valid() {
+		return strconv.Itoa(int(s))
+	}
+	return proposalStageNames[s]
+}
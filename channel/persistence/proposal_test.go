@@ -0,0 +1,46 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel/persistence"
+	iotest "perun.network/go-perun/pkg/io/test"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+func TestProposal(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x9e0))
+	prop := &persistence.Proposal{
+		Role:  persistence.Responder,
+		Stage: persistence.ProposalAccepted,
+		Peer:  wtest.NewRandomAddress(rng),
+	}
+	rng.Read(prop.SessID[:])
+	iotest.GenericSerializerTest(t, prop)
+
+	for _, invalid := range []*persistence.Proposal{
+		{Role: persistence.Responder + 1, Peer: prop.Peer},
+		{Stage: persistence.ProposalAccepted + 1, Peer: prop.Peer},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, invalid.Encode(&buf))
+		assert.Error(t, new(persistence.Proposal).Decode(&buf))
+	}
+}
+
+func TestProposal_String(t *testing.T) {
+	assert.Equal(t, "Responder", persistence.Responder.String())
+	assert.Equal(t, "Accepted", persistence.ProposalAccepted.String())
+	assert.NotPanics(t, func() { assert.Equal(t, "42", persistence.ProposalRole(42).String()) })
+	assert.NotPanics(t, func() { assert.Equal(t, "42", persistence.ProposalStage(42).String()) })
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package replicated contains a PersistRestorer that replicates channel data
+// to several other PersistRestorers for high availability. Every persistence
+// call is made on all replicas and succeeds if a configurable quorum of them
+// succeeds. When restoring, the most up-to-date channel data of all reachable
+// replicas is returned, so that replicas that missed updates do not cause old
+// states to be restored.
+package replicated // import "perun.network/go-perun/channel/persistence/replicated"
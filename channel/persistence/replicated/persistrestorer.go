@@ -0,0 +1,270 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package replicated
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/wire"
+)
+
+var _ persistence.PersistRestorer = (*PersistRestorer)(nil)
+
+// PersistRestorer replicates all channel data to several PersistRestorers.
+//
+// A Persister call succeeds if at least quorum replicas succeed. Hence, the
+// latest data of every channel is stored on at least quorum replicas and can
+// be restored as long as no more than len(replicas)-quorum replicas are
+// unavailable. Replicas that missed updates are not repaired, but their
+// outdated data is superseded by the more recent data of the other replicas
+// during restoring. Likewise, removed channels are recorded as tombstones,
+// which supersede the data of replicas that missed the removal.
+type PersistRestorer struct {
+	replicas []persistence.TombstonePersister
+	quorum   int
+}
+
+// NewPersistRestorer creates a PersistRestorer that replicates to the given
+// replicas. quorum is the number of replicas that need to succeed for a
+// Persister call to succeed. It returns an error if quorum is not between 1
+// and the number of replicas.
+func NewPersistRestorer(quorum int, replicas ...persistence.TombstonePersister) (*PersistRestorer, error) {
+	if quorum < 1 || quorum > len(replicas) {
+		return nil, errors.Errorf("quorum %d out of range [1, %d]", quorum, len(replicas))
+	}
+	return &PersistRestorer{replicas: replicas, quorum: quorum}, nil
+}
+
+// ChannelCreated persists the new channel on all replicas.
+func (r *PersistRestorer) ChannelCreated(
+	ctx context.Context, source channel.Source, peers []wire.Address) error {
+	return r.persist("ChannelCreated", func(pr persistence.TombstonePersister) error {
+		return pr.ChannelCreated(ctx, source, peers)
+	})
+}
+
+// ChannelRemoved adds a tombstone of the channel to all replicas and removes
+// the channel from them. The tombstone holds the latest version of the
+// channel, so that the data of replicas that missed the removal is not
+// restored afterwards.
+func (r *PersistRestorer) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	version := r.removedVersion(ctx, id)
+	return r.persist("ChannelRemoved", func(pr persistence.TombstonePersister) error {
+		if err := pr.TombstoneAdded(ctx, id, version); err != nil {
+			return errors.WithMessage(err, "adding tombstone")
+		}
+		return pr.ChannelRemoved(ctx, id)
+	})
+}
+
+// removedVersion returns the version of the tombstone of a removed channel,
+// which is the latest version of the channel on all replicas. If not all
+// replicas can restore the channel, the tombstone covers all versions.
+func (r *PersistRestorer) removedVersion(ctx context.Context, id channel.ID) uint64 {
+	versions := make([]uint64, len(r.replicas))
+	errs := r.eachIdx(func(i int, pr persistence.TombstonePersister) error {
+		ch, err := pr.RestoreChannel(ctx, id)
+		if err != nil {
+			return err
+		}
+		versions[i] = version(ch.CurrentTXV)
+		return nil
+	})
+	if errs.count() > 0 {
+		return math.MaxUint64
+	}
+
+	var latest uint64
+	for _, v := range versions {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// Staged persists the staging state on all replicas.
+func (r *PersistRestorer) Staged(ctx context.Context, source channel.Source) error {
+	return r.persist("Staged", func(pr persistence.TombstonePersister) error {
+		return pr.Staged(ctx, source)
+	})
+}
+
+// SigAdded persists the added signature on all replicas.
+func (r *PersistRestorer) SigAdded(ctx context.Context, source channel.Source, idx channel.Index) error {
+	return r.persist("SigAdded", func(pr persistence.TombstonePersister) error {
+		return pr.SigAdded(ctx, source, idx)
+	})
+}
+
+// Enabled persists the new current state on all replicas.
+func (r *PersistRestorer) Enabled(ctx context.Context, source channel.Source) error {
+	return r.persist("Enabled", func(pr persistence.TombstonePersister) error {
+		return pr.Enabled(ctx, source)
+	})
+}
+
+// PhaseChanged persists the new phase on all replicas.
+func (r *PersistRestorer) PhaseChanged(ctx context.Context, source channel.Source) error {
+	return r.persist("PhaseChanged", func(pr persistence.TombstonePersister) error {
+		return pr.PhaseChanged(ctx, source)
+	})
+}
+
+// Close closes all replicas. It returns an error if any replica failed to
+// close.
+func (r *PersistRestorer) Close() error {
+	errs := r.each(func(pr persistence.TombstonePersister) error { return pr.Close() })
+	if errs.count() > 0 {
+		return errors.WithMessage(errs, "closing replicas")
+	}
+	return nil
+}
+
+// ActivePeers returns the peers of all reachable replicas.
+func (r *PersistRestorer) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	peers := make([][]wire.Address, len(r.replicas))
+	errs := r.eachIdx(func(i int, pr persistence.TombstonePersister) (err error) {
+		peers[i], err = pr.ActivePeers(ctx)
+		return
+	})
+	if err := r.restoreQuorum("ActivePeers", errs); err != nil {
+		return nil, err
+	}
+
+	var union []wire.Address
+	for _, ps := range peers {
+	peerLoop:
+		for _, p := range ps {
+			for _, q := range union {
+				if p.Equals(q) {
+					continue peerLoop
+				}
+			}
+			union = append(union, p)
+		}
+	}
+	return union, nil
+}
+
+// RestoreAll returns an iterator over the most up-to-date data of all
+// channels that are persisted on any reachable replica.
+func (r *PersistRestorer) RestoreAll() (persistence.ChannelIterator, error) {
+	return r.restoreIter("RestoreAll", func(pr persistence.PersistRestorer) (persistence.ChannelIterator, error) {
+		return pr.RestoreAll()
+	})
+}
+
+// RestorePeer returns an iterator over the most up-to-date data of all
+// channels with the given peer that are persisted on any reachable replica.
+func (r *PersistRestorer) RestorePeer(peer wire.Address) (persistence.ChannelIterator, error) {
+	return r.restoreIter("RestorePeer", func(pr persistence.PersistRestorer) (persistence.ChannelIterator, error) {
+		return pr.RestorePeer(peer)
+	})
+}
+
+// RestoreChannel returns the most up-to-date data of the requested channel of
+// all replicas. Like the iterators, it fails if more replicas failed than are
+// allowed to be unavailable, and it does not restore removed channels.
+func (r *PersistRestorer) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	chs := make([]*persistence.Channel, len(r.replicas))
+	tombs := make([]map[channel.ID]uint64, len(r.replicas))
+	errs := r.eachIdx(func(i int, pr persistence.TombstonePersister) (err error) {
+		if tombs[i], err = pr.RestoreTombstones(ctx); err != nil {
+			return errors.WithMessage(err, "restoring tombstones")
+		}
+		chs[i], err = pr.RestoreChannel(ctx, id)
+		return
+	})
+	if err := r.restoreQuorum("RestoreChannel", errs); err != nil {
+		return nil, errors.WithMessagef(err, "restoring channel %x", id)
+	}
+
+	var latest *persistence.Channel
+	for i, ch := range chs {
+		if errs[i] == nil && (latest == nil || newer(ch, latest)) {
+			latest = ch
+		}
+	}
+	if removed(latest, mergeTombstones(tombs, errs)) {
+		return nil, errors.Errorf("channel %x was removed", id)
+	}
+	return latest, nil
+}
+
+// persist calls f on all replicas concurrently and returns an error if less
+// than quorum replicas succeeded.
+func (r *PersistRestorer) persist(op string, f func(persistence.TombstonePersister) error) error {
+	errs := r.each(f)
+	if succ := len(r.replicas) - errs.count(); succ < r.quorum {
+		return errors.WithMessagef(errs, "%s: %d of %d replicas succeeded, quorum is %d",
+			op, succ, len(r.replicas), r.quorum)
+	}
+	return nil
+}
+
+// restoreQuorum returns an error if more replicas failed than are allowed to
+// be unavailable, in which case a channel might not be found on any of the
+// remaining replicas.
+func (r *PersistRestorer) restoreQuorum(op string, errs replicaErrors) error {
+	if failed := errs.count(); failed > len(r.replicas)-r.quorum {
+		return errors.WithMessagef(errs, "%s: %d of %d replicas failed, at most %d may fail",
+			op, failed, len(r.replicas), len(r.replicas)-r.quorum)
+	}
+	return nil
+}
+
+// each calls f on all replicas concurrently and returns the errors of all
+// replicas.
+func (r *PersistRestorer) each(f func(persistence.TombstonePersister) error) replicaErrors {
+	return r.eachIdx(func(_ int, pr persistence.TombstonePersister) error { return f(pr) })
+}
+
+// eachIdx is like each but also passes the index of the replica to f.
+func (r *PersistRestorer) eachIdx(f func(int, persistence.TombstonePersister) error) replicaErrors {
+	errs := make(replicaErrors, len(r.replicas))
+	var wg sync.WaitGroup
+	wg.Add(len(r.replicas))
+	for i, pr := range r.replicas {
+		go func(i int, pr persistence.TombstonePersister) {
+			defer wg.Done()
+			errs[i] = f(i, pr)
+		}(i, pr)
+	}
+	wg.Wait()
+	return errs
+}
+
+// replicaErrors holds the error of each replica, which is nil for replicas
+// that succeeded.
+type replicaErrors []error
+
+func (e replicaErrors) count() (n int) {
+	for _, err := range e {
+		if err != nil {
+			n++
+		}
+	}
+	return
+}
+
+func (e replicaErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for i, err := range e {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("replica %d: %v", i, err))
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
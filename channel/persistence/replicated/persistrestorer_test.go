@@ -0,0 +1,247 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package replicated
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/test"
+	"perun.network/go-perun/wire"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+// failingReplica is a TombstonePersister that fails all calls while down is
+// set.
+type failingReplica struct {
+	persistence.TombstonePersister
+	down bool
+}
+
+var errDown = errors.New("replica down")
+
+func (f *failingReplica) ChannelCreated(ctx context.Context, s channel.Source, peers []wire.Address) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.ChannelCreated(ctx, s, peers)
+}
+
+func (f *failingReplica) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.ChannelRemoved(ctx, id)
+}
+
+func (f *failingReplica) TombstoneAdded(ctx context.Context, id channel.ID, version uint64) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.TombstoneAdded(ctx, id, version)
+}
+
+func (f *failingReplica) RestoreTombstones(ctx context.Context) (map[channel.ID]uint64, error) {
+	if f.down {
+		return nil, errDown
+	}
+	return f.TombstonePersister.RestoreTombstones(ctx)
+}
+
+func (f *failingReplica) Staged(ctx context.Context, s channel.Source) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.Staged(ctx, s)
+}
+
+func (f *failingReplica) SigAdded(ctx context.Context, s channel.Source, idx channel.Index) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.SigAdded(ctx, s, idx)
+}
+
+func (f *failingReplica) Enabled(ctx context.Context, s channel.Source) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.Enabled(ctx, s)
+}
+
+func (f *failingReplica) PhaseChanged(ctx context.Context, s channel.Source) error {
+	if f.down {
+		return errDown
+	}
+	return f.TombstonePersister.PhaseChanged(ctx, s)
+}
+
+func (f *failingReplica) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	if f.down {
+		return nil, errDown
+	}
+	return f.TombstonePersister.ActivePeers(ctx)
+}
+
+func (f *failingReplica) RestoreAll() (persistence.ChannelIterator, error) {
+	if f.down {
+		return nil, errDown
+	}
+	return f.TombstonePersister.RestoreAll()
+}
+
+func (f *failingReplica) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	if f.down {
+		return nil, errDown
+	}
+	return f.TombstonePersister.RestoreChannel(ctx, id)
+}
+
+func newReplicas(t *testing.T, n int) []*failingReplica {
+	rs := make([]*failingReplica, n)
+	for i := range rs {
+		rs[i] = &failingReplica{TombstonePersister: test.NewPersistRestorer(t)}
+	}
+	return rs
+}
+
+func newPersistRestorer(t *testing.T, quorum int, rs []*failingReplica) *PersistRestorer {
+	prs := make([]persistence.TombstonePersister, len(rs))
+	for i, r := range rs {
+		prs[i] = r
+	}
+	pr, err := NewPersistRestorer(quorum, prs...)
+	require.NoError(t, err)
+	return pr
+}
+
+func TestPersistRestorer_Generic(t *testing.T) {
+	pr := newPersistRestorer(t, 2, newReplicas(t, 3))
+	test.GenericPersistRestorerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x3e91)),
+		pr,
+		4,
+		8,
+	)
+}
+
+func TestNewPersistRestorer(t *testing.T) {
+	r0, r1 := test.NewPersistRestorer(t), test.NewPersistRestorer(t)
+	_, err := NewPersistRestorer(0, r0, r1)
+	assert.Error(t, err)
+	_, err = NewPersistRestorer(3, r0, r1)
+	assert.Error(t, err)
+	_, err = NewPersistRestorer(2, r0, r1)
+	assert.NoError(t, err)
+}
+
+func TestPersistRestorer_Quorum(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3e92))
+	ctx := context.Background()
+	rs := newReplicas(t, 3)
+	pr := newPersistRestorer(t, 2, rs)
+	peers := wtest.NewRandomAddresses(rng, 2)
+
+	rs[0].down = true
+	ch := test.NewRandomChannel(ctx, t, pr, 0, peers, rng)
+	ch.Init(t, rng)
+
+	rs[1].down = true
+	err := pr.Staged(ctx, ch)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3 replicas succeeded")
+	_, err = pr.ActivePeers(ctx)
+	assert.Error(t, err)
+	_, err = pr.RestoreAll()
+	assert.Error(t, err)
+
+	// A single available replica does not suffice to restore a channel.
+	_, err = pr.RestoreChannel(ctx, ch.ID())
+	assert.Error(t, err)
+
+	rs[1].down = false
+	restored, err := pr.RestoreChannel(ctx, ch.ID())
+	require.NoError(t, err)
+	ch.RequireEqual(t, restored)
+}
+
+func TestPersistRestorer_RestoreLatest(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3e93))
+	ctx := context.Background()
+	rs := newReplicas(t, 3)
+	pr := newPersistRestorer(t, 2, rs)
+	peers := wtest.NewRandomAddresses(rng, 2)
+
+	ch := test.NewRandomChannel(ctx, t, pr, 0, peers, rng)
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+
+	// Replica 0 misses the funding and an update.
+	rs[0].down = true
+	ch.SetFunded(t)
+	state := ch.State().Clone()
+	state.Version++
+	require.NoError(t, ch.Update(t, state, ch.Idx()))
+	ch.SignAll(t)
+	ch.EnableUpdate(t)
+
+	// Replica 1 is unavailable during restore.
+	rs[0].down, rs[1].down = false, true
+	restored, err := pr.RestoreChannel(ctx, ch.ID())
+	require.NoError(t, err)
+	ch.RequireEqual(t, restored)
+
+	it, err := pr.RestoreAll()
+	require.NoError(t, err)
+	require.True(t, it.Next(ctx))
+	ch.RequireEqual(t, it.Channel())
+	assert.False(t, it.Next(ctx))
+	require.NoError(t, it.Close())
+
+	peersRestored, err := pr.ActivePeers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, peersRestored, len(peers))
+}
+
+func TestPersistRestorer_Tombstones(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3e94))
+	ctx := context.Background()
+	rs := newReplicas(t, 3)
+	pr := newPersistRestorer(t, 2, rs)
+	peers := wtest.NewRandomAddresses(rng, 2)
+
+	ch := test.NewRandomChannel(ctx, t, pr, 0, peers, rng)
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+
+	// Replica 0 misses the removal and still stores the channel.
+	rs[0].down = true
+	require.NoError(t, pr.ChannelRemoved(ctx, ch.ID()))
+	rs[0].down, rs[1].down = false, true
+
+	_, err := pr.RestoreChannel(ctx, ch.ID())
+	assert.Error(t, err, "removed channel must not be restored")
+	it, err := pr.RestoreAll()
+	require.NoError(t, err)
+	assert.False(t, it.Next(ctx), "removed channel must not be restored")
+	require.NoError(t, it.Close())
+	it, err = pr.RestorePeer(peers[0])
+	require.NoError(t, err)
+	assert.False(t, it.Next(ctx), "removed channel must not be restored")
+	require.NoError(t, it.Close())
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package replicated
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+)
+
+// restoreIter opens an iterator on every replica. The returned iterator merges
+// them on the first call to Next.
+func (r *PersistRestorer) restoreIter(
+	op string,
+	open func(persistence.PersistRestorer) (persistence.ChannelIterator, error),
+) (persistence.ChannelIterator, error) {
+	its := make([]persistence.ChannelIterator, len(r.replicas))
+	errs := r.eachIdx(func(i int, pr persistence.TombstonePersister) (err error) {
+		its[i], err = open(pr)
+		return
+	})
+	if err := r.restoreQuorum(op, errs); err != nil {
+		for _, it := range its {
+			if it != nil {
+				it.Close()
+			}
+		}
+		return nil, err
+	}
+	return &mergedIter{r: r, op: op, its: its, errs: errs, idx: -1}, nil
+}
+
+// mergedIter iterates over the most up-to-date data of the channels of
+// several replica iterators.
+type mergedIter struct {
+	r    *PersistRestorer
+	op   string
+	its  []persistence.ChannelIterator // nil after merging
+	errs replicaErrors
+	err  error
+
+	chans []*persistence.Channel
+	idx   int // has to be initialized to -1
+}
+
+// Next restores the next channel. On the first call, all replica iterators
+// are read and merged.
+func (i *mergedIter) Next(ctx context.Context) bool {
+	if i.its != nil {
+		i.err = i.merge(ctx)
+	}
+	if i.err != nil {
+		return false
+	}
+	i.idx++
+	return i.idx < len(i.chans)
+}
+
+// Channel returns the channel that was restored by the last call to Next.
+func (i *mergedIter) Channel() *persistence.Channel {
+	return i.chans[i.idx]
+}
+
+// Close closes the iterator and returns the error that occurred while merging,
+// if any.
+func (i *mergedIter) Close() error {
+	for _, it := range i.its {
+		if it != nil {
+			it.Close()
+		}
+	}
+	i.its, i.chans = nil, nil
+	return i.err
+}
+
+// merge reads all replica iterators and tombstones concurrently and keeps the
+// most up-to-date data of each channel that was not removed. Channels are
+// sorted by ID so that the iteration order is deterministic.
+func (i *mergedIter) merge(ctx context.Context) error {
+	chans := make([][]*persistence.Channel, len(i.its))
+	tombs := make([]map[channel.ID]uint64, len(i.its))
+	errs := i.r.eachIdx(func(idx int, pr persistence.TombstonePersister) (err error) {
+		it := i.its[idx]
+		if it == nil {
+			return i.errs[idx]
+		}
+		for it.Next(ctx) {
+			chans[idx] = append(chans[idx], it.Channel())
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+		tombs[idx], err = pr.RestoreTombstones(ctx)
+		return errors.WithMessage(err, "restoring tombstones")
+	})
+	i.its = nil
+	if err := i.r.restoreQuorum(i.op, errs); err != nil {
+		return err
+	}
+
+	tombstones := mergeTombstones(tombs, errs)
+	latest := make(map[channel.ID]*persistence.Channel)
+	for idx, chs := range chans {
+		if errs[idx] != nil {
+			continue
+		}
+		for _, ch := range chs {
+			if l, ok := latest[ch.ID()]; !ok || newer(ch, l) {
+				latest[ch.ID()] = ch
+			}
+		}
+	}
+	i.chans = make([]*persistence.Channel, 0, len(latest))
+	for _, ch := range latest {
+		if !removed(ch, tombstones) {
+			i.chans = append(i.chans, ch)
+		}
+	}
+	sort.Slice(i.chans, func(a, b int) bool {
+		ida, idb := i.chans[a].ID(), i.chans[b].ID()
+		return bytes.Compare(ida[:], idb[:]) < 0
+	})
+	return nil
+}
+
+// mergeTombstones returns the union of the tombstones of all replicas that
+// did not fail, keeping the highest version of each channel.
+func mergeTombstones(tombs []map[channel.ID]uint64, errs replicaErrors) map[channel.ID]uint64 {
+	merged := make(map[channel.ID]uint64)
+	for idx, ts := range tombs {
+		if errs[idx] != nil {
+			continue
+		}
+		for id, v := range ts {
+			if v >= merged[id] {
+				merged[id] = v
+			}
+		}
+	}
+	return merged
+}
+
+// removed returns whether the channel was removed, that is, whether there is
+// a tombstone of the channel that is not older than its current state.
+func removed(ch *persistence.Channel, tombstones map[channel.ID]uint64) bool {
+	v, ok := tombstones[ch.ID()]
+	return ok && version(ch.CurrentTXV) <= v
+}
+
+// newer returns whether the data of channel a is more up-to-date than that of
+// channel b. Channels are compared by the version of the current state, then
+// by phase and then by the staging state and its number of signatures, since
+// the channel progresses in this order.
+//
+// A replica that missed a discarded update still has the staging state, which
+// is then restored. This is harmless because incomplete staging states are
+// never enabled without a new signature exchange.
+func newer(a, b *persistence.Channel) bool {
+	if va, vb := version(a.CurrentTXV), version(b.CurrentTXV); va != vb {
+		return va > vb
+	}
+	if a.PhaseV != b.PhaseV {
+		return a.PhaseV > b.PhaseV
+	}
+	if va, vb := version(a.StagingTXV), version(b.StagingTXV); va != vb {
+		return va > vb
+	}
+	return numSigs(a.StagingTXV) > numSigs(b.StagingTXV)
+}
+
+// version returns the version of the transaction's state plus one, or zero if
+// the transaction has no state.
+func version(tx channel.Transaction) uint64 {
+	if tx.State == nil {
+		return 0
+	}
+	return tx.Version + 1
+}
+
+// numSigs returns the number of signatures of the transaction.
+func numSigs(tx channel.Transaction) (n int) {
+	for _, sig := range tx.Sigs {
+		if sig != nil {
+			n++
+		}
+	}
+	return
+}
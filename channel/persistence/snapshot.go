@@ -0,0 +1,203 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+// SnapshotVersion is the version of the snapshot format that is written by
+// WriteSnapshot. Snapshots of newer versions cannot be read. Version 1
+// snapshots, which lack the backend IDs of params and states, and version 2
+// snapshots, which lack the lengths of app data, cannot be read either.
+const SnapshotVersion uint16 = 3
+
+// maxSnapshotSize limits the size of snapshots that are read.
+const maxSnapshotSize = 1 << 24
+
+// snapshotMagic identifies snapshot files.
+var snapshotMagic = [4]byte{'P', 'C', 'S', 'N'}
+
+// A Snapshot is a portable copy of a persisted channel and its network peers.
+// It can be written to a file using WriteSnapshot for backups and imported
+// into any Persister, e.g., on another machine.
+type Snapshot struct {
+	Channel *Channel
+	Peers   []wire.Address
+}
+
+// Export creates a Snapshot of the channel with the given ID. Since the
+// Restorer interface has no lookup of the peers of a channel, all active
+// peers' channels are iterated to find them.
+func Export(ctx context.Context, r Restorer, id channel.ID) (*Snapshot, error) {
+	ch, err := r.RestoreChannel(ctx, id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "restoring channel")
+	}
+	peers, err := r.ActivePeers(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "restoring peers")
+	}
+
+	snap := &Snapshot{Channel: ch}
+	for _, peer := range peers {
+		has, err := peerHasChannel(ctx, r, peer, id)
+		if err != nil {
+			return nil, err
+		} else if has {
+			snap.Peers = append(snap.Peers, peer)
+		}
+	}
+	return snap, nil
+}
+
+func peerHasChannel(ctx context.Context, r Restorer, peer wire.Address, id channel.ID) (bool, error) {
+	it, err := r.RestorePeer(peer)
+	if err != nil {
+		return false, errors.WithMessage(err, "restoring peer channels")
+	}
+	for it.Next(ctx) {
+		if it.Channel().ID() == id {
+			return true, errors.WithMessage(it.Close(), "closing iterator")
+		}
+	}
+	return false, errors.WithMessage(it.Close(), "iterating peer channels")
+}
+
+// Import verifies the Snapshot and then persists it as a new channel.
+func Import(ctx context.Context, p Persister, s *Snapshot) error {
+	if err := s.Verify(); err != nil {
+		return errors.WithMessage(err, "verifying snapshot")
+	}
+	return errors.WithMessage(
+		p.ChannelCreated(ctx, s.Channel, s.Peers),
+		"persisting channel")
+}
+
+// Verify checks the consistency of the Snapshot: The transactions have to
+// belong to the channel and the current transaction has to be signed by all
+// participants.
+func (s *Snapshot) Verify() error {
+	ch := s.Channel
+	if ch == nil || ch.ParamsV == nil {
+		return errors.New("missing channel parameters")
+	}
+	numParts := len(ch.ParamsV.Parts)
+	if int(ch.IdxV) >= numParts {
+		return errors.Errorf("invalid own index %d for %d participants", ch.IdxV, numParts)
+	}
+	id := ch.ID()
+	for _, tx := range []channel.Transaction{ch.CurrentTXV, ch.StagingTXV} {
+		if tx.State != nil && tx.State.ID != id {
+			return errors.Errorf("state of channel %x in snapshot of channel %x", tx.State.ID, id)
+		}
+	}
+
+	cur := ch.CurrentTXV
+	if cur.State == nil {
+		return nil
+	}
+	if len(cur.Sigs) != numParts {
+		return errors.Errorf("current transaction has %d signatures, expected %d", len(cur.Sigs), numParts)
+	}
+	for i, sig := range cur.Sigs {
+		if ok, err := channel.Verify(ch.ParamsV.Parts[i], ch.ParamsV, cur.State, sig); err != nil {
+			return errors.WithMessagef(err, "verifying signature %d", i)
+		} else if !ok {
+			return errors.Errorf("invalid signature %d on current state", i)
+		}
+	}
+	return nil
+}
+
+// Encode encodes the Snapshot, without header, into an io.Writer.
+func (s *Snapshot) Encode(w io.Writer) error {
+	ch := s.Channel
+	return perunio.Encode(w,
+		ch.IdxV,
+		ch.ParamsV,
+		ch.StagingTXV,
+		ch.CurrentTXV,
+		ch.PhaseV,
+		wire.AddressesWithLen(s.Peers))
+}
+
+// Decode decodes a Snapshot, without header, from an io.Reader.
+func (s *Snapshot) Decode(r io.Reader) error {
+	s.Channel = &Channel{ParamsV: new(channel.Params)}
+	ch := s.Channel
+	return perunio.Decode(r,
+		&ch.IdxV,
+		ch.ParamsV,
+		&ch.StagingTXV,
+		&ch.CurrentTXV,
+		&ch.PhaseV,
+		(*wallet.AddressesWithLen)(&s.Peers))
+}
+
+// WriteSnapshot writes a Snapshot in the versioned snapshot file format: A
+// magic number and the format version are followed by the length of the
+// encoded Snapshot, the encoded Snapshot itself and its SHA-256 checksum.
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	var body bytes.Buffer
+	if err := s.Encode(&body); err != nil {
+		return errors.WithMessage(err, "encoding snapshot")
+	}
+	sum := sha256.Sum256(body.Bytes())
+	return perunio.Encode(w,
+		snapshotMagic[:], SnapshotVersion, uint32(body.Len()), body.Bytes(), sum)
+}
+
+// ReadSnapshot reads a Snapshot that was written by WriteSnapshot. It fails
+// if the checksum does not match. The Snapshot is not verified, see Verify.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	magic := make([]byte, len(snapshotMagic))
+	var version uint16
+	if err := perunio.Decode(r, &magic, &version); err != nil {
+		return nil, errors.WithMessage(err, "decoding header")
+	}
+	if !bytes.Equal(magic, snapshotMagic[:]) {
+		return nil, errors.New("not a channel snapshot")
+	} else if version != SnapshotVersion {
+		return nil, errors.Errorf("unsupported snapshot version %d, only %d is supported",
+			version, SnapshotVersion)
+	}
+
+	var size uint32
+	if err := perunio.Decode(r, &size); err != nil {
+		return nil, errors.WithMessage(err, "decoding size")
+	} else if size > maxSnapshotSize {
+		return nil, errors.Errorf("snapshot size %d exceeds maximum %d", size, maxSnapshotSize)
+	}
+	body := make([]byte, size)
+	var sum [sha256.Size]byte
+	if err := perunio.Decode(r, &body, &sum); err != nil {
+		return nil, errors.WithMessage(err, "decoding snapshot")
+	}
+	if sha256.Sum256(body) != sum {
+		return nil, errors.New("snapshot checksum mismatch")
+	}
+
+	var s Snapshot
+	buf := bytes.NewReader(body)
+	if err := s.Decode(buf); err != nil {
+		return nil, errors.WithMessage(err, "decoding snapshot")
+	}
+	if buf.Len() != 0 {
+		return nil, errors.Errorf("%d trailing bytes in snapshot", buf.Len())
+	}
+	return &s, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/test"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+func TestSnapshot_ExportImport(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5a9))
+	ctx := context.Background()
+	src := test.NewPersistRestorer(t)
+	c := test.NewClient(ctx, t, rng, src)
+	peer := wtest.NewRandomAddress(rng)
+	ch := c.NewChannel(t, peer)
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+	ch.SetFunded(t)
+	c.NewChannel(t, wtest.NewRandomAddress(rng)) // other channel
+
+	snap, err := persistence.Export(ctx, src, ch.ID())
+	require.NoError(t, err)
+	require.Len(t, snap.Peers, 2)
+	require.NoError(t, snap.Verify())
+
+	var file bytes.Buffer
+	require.NoError(t, persistence.WriteSnapshot(&file, snap))
+	read, err := persistence.ReadSnapshot(bytes.NewReader(file.Bytes()))
+	require.NoError(t, err)
+
+	dst := test.NewPersistRestorer(t)
+	require.NoError(t, persistence.Import(ctx, dst, read))
+	restored, err := dst.RestoreChannel(ctx, ch.ID())
+	require.NoError(t, err)
+	ch.RequireEqual(t, restored)
+
+	peers, err := dst.ActivePeers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, peers, 2)
+	assert.Error(t, persistence.Import(ctx, dst, read), "importing twice")
+}
+
+func TestSnapshot_Corrupted(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5aa))
+	ctx := context.Background()
+	pr := test.NewPersistRestorer(t)
+	ch := test.NewClient(ctx, t, rng, pr).NewChannel(t, wtest.NewRandomAddress(rng))
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+
+	snap, err := persistence.Export(ctx, pr, ch.ID())
+	require.NoError(t, err)
+	var file bytes.Buffer
+	require.NoError(t, persistence.WriteSnapshot(&file, snap))
+	data := file.Bytes()
+
+	t.Run("checksum", func(t *testing.T) {
+		corrupt := append([]byte(nil), data...)
+		corrupt[len(corrupt)/2] ^= 1
+		_, err := persistence.ReadSnapshot(bytes.NewReader(corrupt))
+		assert.Error(t, err)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		newer := append([]byte(nil), data...)
+		newer[4] = byte(persistence.SnapshotVersion + 1)
+		_, err := persistence.ReadSnapshot(bytes.NewReader(newer))
+		assert.Error(t, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := persistence.ReadSnapshot(bytes.NewReader(data[:len(data)-1]))
+		assert.Error(t, err)
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		snap.Channel.CurrentTXV.Sigs[0], snap.Channel.CurrentTXV.Sigs[1] =
+			snap.Channel.CurrentTXV.Sigs[1], snap.Channel.CurrentTXV.Sigs[0]
+		assert.Error(t, snap.Verify())
+		assert.Error(t, persistence.Import(ctx, test.NewPersistRestorer(t), snap))
+	})
+}
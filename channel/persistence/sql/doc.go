@@ -0,0 +1,11 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package sql contains an implementation of the channel persister interface
+// using a relational database. Channel data is stored in the tables channels,
+// params, peers, states and signatures so that it can be inspected with SQL.
+// The statements are written for SQLite, the database driver has to be
+// imported by the user.
+package sql // import "perun.network/go-perun/channel/persistence/sql"
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaVersion is the version of the database layout that is written by this
+// implementation. Version 1 is the layout of the first release of this
+// package, see schema.
+const SchemaVersion = 1
+
+// A migration upgrades a database from one schema version to the next within
+// the given transaction.
+type migration func(context.Context, *gosql.Tx) error
+
+// migrations[v] upgrades a database from schema version v to v+1. There are
+// no migrations yet.
+var migrations = map[int]migration{}
+
+// SchemaVersionError is returned when a database was written by a newer
+// version of go-perun and its schema is not supported.
+type SchemaVersionError struct {
+	Version int // The schema version of the database.
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf(
+		"database schema version %d is newer than supported version %d, upgrade go-perun",
+		e.Version, SchemaVersion)
+}
+
+// IsSchemaVersionError returns true if the error was a SchemaVersionError.
+func IsSchemaVersionError(err error) bool {
+	_, ok := errors.Cause(err).(*SchemaVersionError)
+	return ok
+}
+
+// migrate upgrades the database to the current schema version. Databases
+// without version, including fresh ones, are of version 1 because the schema
+// statements create the tables of version 1. Each migration is applied in a
+// transaction together with its version so that an interrupted upgrade
+// resumes where it stopped.
+func (pr *PersistRestorer) migrate(ctx context.Context) error {
+	version, err := pr.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return errors.WithStack(&SchemaVersionError{Version: version})
+	}
+
+	for ; version < SchemaVersion; version++ {
+		v := version
+		if err := pr.update(ctx, func(tx *gosql.Tx) error {
+			if err := migrations[v](ctx, tx); err != nil {
+				return errors.WithMessagef(err, "migrating schema version %d", v)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_version`); err != nil {
+				return errors.WithMessage(err, "deleting schema version")
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, v+1)
+			return errors.WithMessage(err, "inserting schema version")
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaVersion reads the schema version of the database.
+func (pr *PersistRestorer) schemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := pr.db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version)
+	if err == gosql.ErrNoRows {
+		return 1, nil
+	}
+	return version, errors.WithMessage(err, "querying schema version")
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	version, err := pr.schemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+	require.NoError(t, pr.migrate(ctx), "migrating twice")
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	ctx := context.Background()
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	_, err := pr.db.Exec(`INSERT INTO schema_version (version) VALUES (?)`, SchemaVersion+1)
+	require.NoError(t, err)
+	err = pr.migrate(ctx)
+	assert.True(t, IsSchemaVersionError(err))
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	gosql "database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wire"
+)
+
+// ChannelCreated inserts a channel into the database.
+func (pr *PersistRestorer) ChannelCreated(ctx context.Context, s channel.Source, peers []wire.Address) error {
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		id := s.ID()
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO channels (id, idx, phase) VALUES (?, ?, ?)`,
+			id[:], s.Idx(), s.Phase()); err != nil {
+			return errors.WithMessage(err, "inserting channel")
+		}
+		if err := insertParams(ctx, tx, s.Params()); err != nil {
+			return err
+		}
+		for _, peer := range peers {
+			addr, err := encode(peer)
+			if err != nil {
+				return errors.WithMessage(err, "encoding peer address")
+			}
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO peers (channel_id, peer) VALUES (?, ?)`,
+				id[:], addr); err != nil {
+				return errors.WithMessage(err, "inserting peer")
+			}
+		}
+		if err := putTX(ctx, tx, id, currentTX, s.CurrentTX()); err != nil {
+			return err
+		}
+		return putTX(ctx, tx, id, stagingTX, s.StagingTX())
+	})
+}
+
+func insertParams(ctx context.Context, tx *gosql.Tx, p *channel.Params) error {
+	data, err := encode(p)
+	if err != nil {
+		return errors.WithMessage(err, "encoding params")
+	}
+	id := p.ID()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO params (channel_id, challenge_duration, nonce, app, num_parts, data)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		id[:], int64(p.ChallengeDuration), p.Nonce.String(), p.App.Def().Bytes(), len(p.Parts), data)
+	return errors.WithMessage(err, "inserting params")
+}
+
+// ChannelRemoved deletes a channel from the database.
+func (pr *PersistRestorer) ChannelRemoved(ctx context.Context, id channel.ID) error {
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		return removeChannel(ctx, tx, id)
+	})
+}
+
+func removeChannel(ctx context.Context, tx *gosql.Tx, id channel.ID) error {
+	res, err := tx.ExecContext(ctx, `DELETE FROM channels WHERE id = ?`, id[:])
+	if err != nil {
+		return errors.WithMessage(err, "deleting channel")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Errorf("unknown channel %x", id)
+	}
+	for _, table := range []string{"params", "peers", "states", "signatures"} {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE channel_id = ?`, id[:]); err != nil {
+			return errors.WithMessage(err, "deleting "+table)
+		}
+	}
+	return nil
+}
+
+// Prune removes all channels that were withdrawn before the given time.
+func (pr *PersistRestorer) Prune(ctx context.Context, before time.Time) (pruned int, err error) {
+	it, err := pr.queryChannels(
+		`SELECT id FROM channels WHERE withdrawn_at < ? ORDER BY id`, before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	err = pr.update(ctx, func(tx *gosql.Tx) error {
+		for _, id := range it.ids {
+			if err := removeChannel(ctx, tx, id); err != nil {
+				return errors.WithMessagef(err, "removing channel %x", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(it.ids), nil
+}
+
+// Staged persists the staging transaction as well as the channel's phase.
+func (pr *PersistRestorer) Staged(ctx context.Context, s channel.Source) error {
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		if err := putTX(ctx, tx, s.ID(), stagingTX, s.StagingTX()); err != nil {
+			return err
+		}
+		return putPhase(ctx, tx, s)
+	})
+}
+
+// SigAdded persists the signature of the given participant on the channel's
+// staging state.
+func (pr *PersistRestorer) SigAdded(ctx context.Context, s channel.Source, idx channel.Index) error {
+	sigs := s.StagingTX().Sigs
+	if int(idx) >= len(sigs) || sigs[idx] == nil {
+		return errors.Errorf("no staging signature of participant %d", idx)
+	}
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		return putSig(ctx, tx, s.ID(), stagingTX, int(idx), sigs[idx])
+	})
+}
+
+// Enabled persists the channel's staging and current transaction, and phase.
+func (pr *PersistRestorer) Enabled(ctx context.Context, s channel.Source) error {
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		if err := putTX(ctx, tx, s.ID(), currentTX, s.CurrentTX()); err != nil {
+			return err
+		}
+		if err := putTX(ctx, tx, s.ID(), stagingTX, s.StagingTX()); err != nil {
+			return err
+		}
+		return putPhase(ctx, tx, s)
+	})
+}
+
+// PhaseChanged persists the channel's phase. If the channel got withdrawn,
+// the time of withdrawal is recorded for pruning. Only the first withdrawal
+// is recorded, so that repeated phase changes do not move the prune clock.
+func (pr *PersistRestorer) PhaseChanged(ctx context.Context, s channel.Source) error {
+	return pr.update(ctx, func(tx *gosql.Tx) error {
+		return putPhase(ctx, tx, s)
+	})
+}
+
+func putPhase(ctx context.Context, tx *gosql.Tx, s channel.Source) error {
+	id := s.ID()
+	query, args := `UPDATE channels SET phase = ? WHERE id = ?`, []interface{}{s.Phase(), id[:]}
+	if s.Phase() == channel.Withdrawn {
+		query = `UPDATE channels SET phase = ?, withdrawn_at = COALESCE(withdrawn_at, ?) WHERE id = ?`
+		args = []interface{}{s.Phase(), time.Now().UnixNano(), id[:]}
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.WithMessage(err, "updating phase")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Errorf("unknown channel %x", id)
+	}
+	return nil
+}
+
+// putTX replaces the state and signatures of the given transaction kind,
+// current or staging. Empty states and signatures are not stored.
+func putTX(ctx context.Context, tx *gosql.Tx, id channel.ID, kind string, t channel.Transaction) error {
+	for _, table := range []string{"states", "signatures"} {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE channel_id = ? AND tx = ?`, id[:], kind); err != nil {
+			return errors.WithMessagef(err, "deleting %s %s", kind, table)
+		}
+	}
+	if t.State == nil {
+		return nil
+	}
+
+	data, err := encode(t.State)
+	if err != nil {
+		return errors.WithMessagef(err, "encoding %s state", kind)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO states (channel_id, tx, version, is_final, data) VALUES (?, ?, ?, ?, ?)`,
+		id[:], kind, int64(t.State.Version), t.State.IsFinal, data); err != nil {
+		return errors.WithMessagef(err, "inserting %s state", kind)
+	}
+	for idx, sig := range t.Sigs {
+		if sig == nil {
+			continue
+		}
+		if err := putSig(ctx, tx, id, kind, idx, sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putSig(ctx context.Context, tx *gosql.Tx, id channel.ID, kind string, idx int, sig []byte) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO signatures (channel_id, tx, idx, sig) VALUES (?, ?, ?, ?)`,
+		id[:], kind, idx, sig)
+	return errors.WithMessagef(err, "inserting %s signature %d", kind, idx)
+}
+
+// encode encodes v using the pkg/io module.
+func encode(v perunio.Encoder) ([]byte, error) {
+	var buf bytes.Buffer
+	err := perunio.Encode(&buf, v)
+	return buf.Bytes(), err
+}
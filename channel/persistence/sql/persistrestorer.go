@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel/persistence"
+)
+
+var _ persistence.Pruner = (*PersistRestorer)(nil)
+
+// PersistRestorer implements both the persister and the restorer interface
+// using a relational database.
+type PersistRestorer struct {
+	db *gosql.DB
+}
+
+// Values of the tx column of the states and signatures tables.
+const (
+	currentTX = "current"
+	stagingTX = "staging"
+)
+
+// schema creates the tables of schema version 1 if they do not exist yet.
+// Later versions are reached by migrations. Each state is stored fully encoded
+// in the data column, its version and finality are only copied for
+// inspection. The same holds for the params columns besides data. The
+// withdrawal time of channels is stored in Unix nanoseconds for pruning. The
+// versions of tombstones are stored as two's complement because SQL integers
+// are signed.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS channels (
+		id           BLOB PRIMARY KEY,
+		idx          INTEGER NOT NULL,
+		phase        INTEGER NOT NULL,
+		withdrawn_at INTEGER
+	)`,
+	`CREATE INDEX IF NOT EXISTS channels_withdrawn_at ON channels (withdrawn_at)`,
+	`CREATE TABLE IF NOT EXISTS params (
+		channel_id         BLOB PRIMARY KEY,
+		challenge_duration INTEGER NOT NULL,
+		nonce              TEXT NOT NULL,
+		app                BLOB NOT NULL,
+		num_parts          INTEGER NOT NULL,
+		data               BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS peers (
+		channel_id BLOB NOT NULL,
+		peer       BLOB NOT NULL,
+		PRIMARY KEY (channel_id, peer)
+	)`,
+	`CREATE INDEX IF NOT EXISTS peers_peer ON peers (peer)`,
+	`CREATE TABLE IF NOT EXISTS states (
+		channel_id BLOB NOT NULL,
+		tx         TEXT NOT NULL,
+		version    INTEGER NOT NULL,
+		is_final   BOOLEAN NOT NULL,
+		data       BLOB NOT NULL,
+		PRIMARY KEY (channel_id, tx)
+	)`,
+	`CREATE TABLE IF NOT EXISTS signatures (
+		channel_id BLOB NOT NULL,
+		tx         TEXT NOT NULL,
+		idx        INTEGER NOT NULL,
+		sig        BLOB NOT NULL,
+		PRIMARY KEY (channel_id, tx, idx)
+	)`,
+	`CREATE TABLE IF NOT EXISTS tombstones (
+		channel_id BLOB PRIMARY KEY,
+		version    INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER NOT NULL
+	)`,
+}
+
+// NewPersistRestorer creates a new PersistRestorer for the supplied database,
+// creates the tables if necessary and migrates them to the current
+// SchemaVersion. The PersistRestorer takes ownership of the database and
+// closes it when it is closed. Writes to SQLite databases are serialized, so
+// it is recommended to limit the database to a single open connection to
+// avoid busy errors.
+func NewPersistRestorer(ctx context.Context, db *gosql.DB) (*PersistRestorer, error) {
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, errors.WithMessage(err, "creating schema")
+		}
+	}
+	pr := &PersistRestorer{db: db}
+	if err := pr.migrate(ctx); err != nil {
+		return nil, errors.WithMessage(err, "migrating schema")
+	}
+	return pr, nil
+}
+
+// Close closes the PersistRestorer and releases all resources it holds.
+func (pr *PersistRestorer) Close() error {
+	return pr.db.Close()
+}
+
+// update runs fn in a database transaction, which is committed if fn succeeds
+// and rolled back otherwise.
+func (pr *PersistRestorer) update(ctx context.Context, fn func(*gosql.Tx) error) error {
+	tx, err := pr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "beginning transaction")
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback() // nolint:errcheck // the original error is more relevant
+		return err
+	}
+	return errors.WithMessage(tx.Commit(), "committing transaction")
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+	gosql "database/sql"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "perun.network/go-perun/backend/sim"
+	"perun.network/go-perun/channel/persistence/test"
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func newTestPersistRestorer(t *testing.T) *PersistRestorer {
+	tmpdir, err := ioutil.TempDir("", "perun-test-sqlpersistrestorer-db-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpdir) })
+
+	db, err := gosql.Open("sqlite3", filepath.Join(tmpdir, "perun.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	pr, err := NewPersistRestorer(context.Background(), db)
+	require.NoError(t, err)
+	return pr
+}
+
+func TestPersistRestorer_Generic(t *testing.T) {
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericPersistRestorerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0xC00FED)),
+		pr,
+		4,
+		16)
+}
+
+func TestPersistRestorer_Prune(t *testing.T) {
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericPrunerTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x94e)),
+		pr,
+		8)
+}
+
+func TestPersistRestorer_Tombstones(t *testing.T) {
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	test.GenericTombstonePersisterTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x7057)),
+		pr,
+		16)
+}
+
+func TestPersistRestorer_Inspect(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x5e1))
+	ctx := context.Background()
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	c := test.NewClient(ctx, t, rng, pr)
+	peer := wallettest.NewRandomAddress(rng)
+	ch := c.NewChannel(t, peer)
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+
+	id := ch.ID()
+	var phase, numSigs int
+	require.NoError(t, pr.db.QueryRow(
+		`SELECT phase FROM channels WHERE id = ?`, id[:]).Scan(&phase))
+	assert.Equal(t, int(ch.Phase()), phase)
+	require.NoError(t, pr.db.QueryRow(
+		`SELECT COUNT(*) FROM signatures WHERE channel_id = ? AND tx = 'current'`, id[:]).Scan(&numSigs))
+	assert.Equal(t, len(ch.Params().Parts), numSigs)
+
+	ch.Settle(t)
+	require.NoError(t, pr.db.QueryRow(`SELECT COUNT(*) FROM peers`).Scan(&numSigs))
+	assert.Zero(t, numSigs)
+}
+
+func TestPersistRestorer_WithdrawnAt(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x3d7))
+	ctx := context.Background()
+	pr := newTestPersistRestorer(t)
+	defer func() { require.NoError(t, pr.Close()) }()
+
+	c := test.NewClient(ctx, t, rng, pr)
+	ch := c.NewChannel(t, wallettest.NewRandomAddress(rng))
+	ch.Init(t, rng)
+	ch.SignAll(t)
+	ch.EnableInit(t)
+	ch.SetFunded(t)
+
+	id := ch.ID()
+	withdrawnAt := func() (at gosql.NullInt64) {
+		require.NoError(t, pr.db.QueryRow(
+			`SELECT withdrawn_at FROM channels WHERE id = ?`, id[:]).Scan(&at))
+		return
+	}
+	assert.False(t, withdrawnAt().Valid, "open channel not withdrawn")
+
+	ch.Withdraw(t)
+	first := withdrawnAt()
+	require.True(t, first.Valid)
+
+	require.NoError(t, pr.PhaseChanged(ctx, ch), "repeated withdrawal")
+	assert.Equal(t, first, withdrawnAt(), "repeated withdrawal must keep the time")
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	gosql "database/sql"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+	"perun.network/go-perun/wire"
+)
+
+var _ persistence.ChannelIterator = (*ChannelIterator)(nil)
+
+// ChannelIterator implements the persistence.ChannelIterator interface. The
+// IDs of the channels are queried upfront, the channels themselves are loaded
+// one by one.
+type ChannelIterator struct {
+	err error
+	ch  *persistence.Channel
+	ids []channel.ID
+
+	restorer *PersistRestorer
+}
+
+// ActivePeers returns a list of all peers with which a channel is persisted.
+func (pr *PersistRestorer) ActivePeers(ctx context.Context) ([]wire.Address, error) {
+	rows, err := pr.db.QueryContext(ctx, `SELECT DISTINCT peer FROM peers`)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying peers")
+	}
+	defer rows.Close()
+
+	var peers []wire.Address
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, errors.WithMessage(err, "scanning peer")
+		}
+		addr, err := wire.DecodeAddress(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "decoding peer (%x)", b)
+		}
+		peers = append(peers, addr)
+	}
+	return peers, errors.WithMessage(rows.Err(), "iterating peers")
+}
+
+// RestoreAll returns an iterator over all persisted channels.
+func (pr *PersistRestorer) RestoreAll() (persistence.ChannelIterator, error) {
+	return pr.queryChannels(`SELECT id FROM channels ORDER BY id`)
+}
+
+// RestorePeer returns an iterator over all persisted channels which the given
+// peer is a part of.
+func (pr *PersistRestorer) RestorePeer(addr wire.Address) (persistence.ChannelIterator, error) {
+	peer, err := encode(addr)
+	if err != nil {
+		return nil, errors.WithMessage(err, "encoding peer address")
+	}
+	return pr.queryChannels(`SELECT channel_id FROM peers WHERE peer = ? ORDER BY channel_id`, peer)
+}
+
+// queryChannels creates an iterator over the channels whose IDs are returned
+// by the given query.
+func (pr *PersistRestorer) queryChannels(query string, args ...interface{}) (*ChannelIterator, error) {
+	rows, err := pr.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying channels")
+	}
+	defer rows.Close()
+
+	it := &ChannelIterator{restorer: pr}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, errors.WithMessage(err, "scanning channel id")
+		}
+		var id channel.ID
+		if len(b) != len(id) {
+			return nil, errors.Errorf("invalid channel id %x", b)
+		}
+		copy(id[:], b)
+		it.ids = append(it.ids, id)
+	}
+	return it, errors.WithMessage(rows.Err(), "iterating channels")
+}
+
+// RestoreChannel restores a single channel.
+func (pr *PersistRestorer) RestoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	ch, err := pr.restoreChannel(ctx, id)
+	if errors.Cause(err) == gosql.ErrNoRows {
+		return nil, errors.Errorf("could not find channel %x", id)
+	}
+	return ch, errors.WithMessagef(err, "error restoring channel %x", id)
+}
+
+func (pr *PersistRestorer) restoreChannel(ctx context.Context, id channel.ID) (*persistence.Channel, error) {
+	ch := &persistence.Channel{ParamsV: new(channel.Params)}
+	var params []byte
+	if err := pr.db.QueryRowContext(ctx,
+		`SELECT c.idx, c.phase, p.data FROM channels c
+		JOIN params p ON p.channel_id = c.id WHERE c.id = ?`, id[:],
+	).Scan(&ch.IdxV, &ch.PhaseV, &params); err != nil {
+		return nil, errors.WithMessage(err, "querying channel")
+	}
+	if err := decode(params, ch.ParamsV); err != nil {
+		return nil, errors.WithMessage(err, "decoding params")
+	}
+
+	numParts := len(ch.ParamsV.Parts)
+	ch.StagingTXV.Sigs = make([]wallet.Sig, numParts)
+	txs := map[string]*channel.Transaction{
+		currentTX: &ch.CurrentTXV,
+		stagingTX: &ch.StagingTXV,
+	}
+	if err := pr.restoreStates(ctx, id, txs, numParts); err != nil {
+		return nil, err
+	}
+	if err := pr.restoreSigs(ctx, id, txs, numParts); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// restoreStates restores the states of the given transactions. The
+// transactions of restored states get an empty signature slice.
+func (pr *PersistRestorer) restoreStates(ctx context.Context, id channel.ID, txs map[string]*channel.Transaction, numParts int) error {
+	rows, err := pr.db.QueryContext(ctx,
+		`SELECT tx, data FROM states WHERE channel_id = ?`, id[:])
+	if err != nil {
+		return errors.WithMessage(err, "querying states")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var data []byte
+		if err := rows.Scan(&kind, &data); err != nil {
+			return errors.WithMessage(err, "scanning state")
+		}
+		t, ok := txs[kind]
+		if !ok {
+			return errors.Errorf("unknown transaction %q", kind)
+		}
+		t.State = new(channel.State)
+		if err := decode(data, t.State); err != nil {
+			return errors.WithMessagef(err, "decoding %s state", kind)
+		}
+		if t.Sigs == nil {
+			t.Sigs = make([]wallet.Sig, numParts)
+		}
+	}
+	return errors.WithMessage(rows.Err(), "iterating states")
+}
+
+// restoreSigs restores the signatures of the given transactions.
+func (pr *PersistRestorer) restoreSigs(ctx context.Context, id channel.ID, txs map[string]*channel.Transaction, numParts int) error {
+	rows, err := pr.db.QueryContext(ctx,
+		`SELECT tx, idx, sig FROM signatures WHERE channel_id = ?`, id[:])
+	if err != nil {
+		return errors.WithMessage(err, "querying signatures")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var idx int
+		var sig wallet.Sig
+		if err := rows.Scan(&kind, &idx, &sig); err != nil {
+			return errors.WithMessage(err, "scanning signature")
+		}
+		t, ok := txs[kind]
+		if !ok || idx < 0 || idx >= numParts || len(t.Sigs) != numParts {
+			return errors.Errorf("invalid %s signature %d", kind, idx)
+		}
+		t.Sigs[idx] = sig
+	}
+	return errors.WithMessage(rows.Err(), "iterating signatures")
+}
+
+// Next advances the iterator and returns whether there is another channel.
+func (i *ChannelIterator) Next(ctx context.Context) bool {
+	if len(i.ids) == 0 || i.err != nil {
+		return false
+	}
+
+	i.ch, i.err = i.restorer.restoreChannel(ctx, i.ids[0])
+	i.err = errors.WithMessagef(i.err, "restoring channel %x", i.ids[0])
+	i.ids = i.ids[1:]
+	return i.err == nil
+}
+
+// Channel returns the iterator's current channel.
+func (i *ChannelIterator) Channel() *persistence.Channel {
+	return i.ch
+}
+
+// Close closes the iterator and releases its resources. It returns the last
+// error that occurred when advancing the iterator.
+func (i *ChannelIterator) Close() error {
+	i.ids = nil
+	return i.err
+}
+
+// decode decodes v from data and checks that data is exhausted.
+func decode(data []byte, v perunio.Decoder) error {
+	buf := bytes.NewReader(data)
+	if err := perunio.Decode(buf, v); err != nil {
+		return err
+	}
+	if buf.Len() != 0 {
+		return errors.Errorf("%d bytes left", buf.Len())
+	}
+	return nil
+}
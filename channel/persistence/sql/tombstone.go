@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+)
+
+var _ persistence.TombstonePersister = (*PersistRestorer)(nil)
+
+// TombstoneAdded persists the tombstone in the tombstones table.
+func (pr *PersistRestorer) TombstoneAdded(ctx context.Context, id channel.ID, version uint64) error {
+	_, err := pr.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO tombstones (channel_id, version) VALUES (?, ?)`,
+		id[:], int64(version))
+	return errors.WithMessage(err, "inserting tombstone")
+}
+
+// RestoreTombstones returns all persisted tombstones.
+func (pr *PersistRestorer) RestoreTombstones(ctx context.Context) (map[channel.ID]uint64, error) {
+	rows, err := pr.db.QueryContext(ctx, `SELECT channel_id, version FROM tombstones`)
+	if err != nil {
+		return nil, errors.WithMessage(err, "querying tombstones")
+	}
+	defer rows.Close()
+
+	tombstones := make(map[channel.ID]uint64)
+	for rows.Next() {
+		var idBytes []byte
+		var version int64
+		if err := rows.Scan(&idBytes, &version); err != nil {
+			return nil, errors.WithMessage(err, "scanning tombstone")
+		}
+		var id channel.ID
+		if len(idBytes) != len(id) {
+			return nil, errors.Errorf("invalid tombstone channel ID %x", idBytes)
+		}
+		copy(id[:], idBytes)
+		tombstones[id] = uint64(version)
+	}
+	return tombstones, errors.WithMessage(rows.Err(), "iterating tombstones")
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"math/rand"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"perun.network/go-perun/channel"
@@ -93,22 +94,30 @@ func requireEqualStagingTX(t require.TestingT, expected, actual channel.Transact
 }
 
 func requireEqualSigs(t require.TestingT, expected, actual []wallet.Sig) {
-	if expected == nil && actual == nil {
-		return
+	if !equalSigs(expected, actual) {
+		require.Equal(t, expected, actual, "StagingTX.Sigs")
 	}
-	actualNil := isNilSigs(actual)
-	expectedNil := isNilSigs(expected)
-	if (expected == nil && actualNil) ||
-		(expectedNil && actual == nil) {
-		return
-	}
-	if actualNil && expectedNil {
-		if len(expected) != len(actual) {
-			t.FailNow()
-		}
-		return
+}
+
+// equalSigs is the loose equality of signatures described at
+// requireEqualStagingTX.
+func equalSigs(expected, actual []wallet.Sig) bool {
+	if isNilSigs(expected) && isNilSigs(actual) {
+		return expected == nil || actual == nil || len(expected) == len(actual)
 	}
-	require.Equal(t, expected, actual, "StagingTX.Sigs")
+	return assert.ObjectsAreEqual(expected, actual)
+}
+
+// EqualSource returns whether two channel states are equal. Like
+// Channel.RequireEqual, it tolerates missing staging signatures to be either
+// a nil slice or a slice of nil signatures.
+func EqualSource(expected, actual channel.Source) bool {
+	return expected.Idx() == actual.Idx() &&
+		assert.ObjectsAreEqual(expected.Params(), actual.Params()) &&
+		assert.ObjectsAreEqual(expected.StagingTX().State, actual.StagingTX().State) &&
+		equalSigs(expected.StagingTX().Sigs, actual.StagingTX().Sigs) &&
+		assert.ObjectsAreEqual(expected.CurrentTX(), actual.CurrentTX()) &&
+		expected.Phase() == actual.Phase()
 }
 
 func isNilSigs(s []wallet.Sig) bool {
@@ -124,7 +133,7 @@ func isNilSigs(s []wallet.Sig) bool {
 func (c *Channel) Init(t require.TestingT, rng *rand.Rand) {
 	initAlloc := *ctest.NewRandomAllocation(rng, ctest.WithNumParts(len(c.accounts)))
 	initData := channel.NewMockOp(channel.OpValid)
-	err := c.StateMachine.Init(nil, initAlloc, initData)
+	err := c.StateMachine.Init(c.ctx, initAlloc, initData)
 	require.NoError(t, err)
 	c.AssertPersisted(c.ctx, t)
 }
@@ -138,14 +147,14 @@ func (c *Channel) EnableInit(t require.TestingT) {
 
 // SignAll signs the current staged state by all parties.
 func (c *Channel) SignAll(t require.TestingT) {
-	_, err := c.Sig(nil) // trigger local signing
+	_, err := c.Sig(c.ctx) // trigger local signing
 	require.NoError(t, err)
 	c.AssertPersisted(c.ctx, t)
 	// remote signers
 	for i := range c.accounts {
 		sig, err := channel.Sign(c.accounts[i], c.Params(), c.StagingState())
 		require.NoError(t, err)
-		c.AddSig(nil, channel.Index(i), sig)
+		c.AddSig(c.ctx, channel.Index(i), sig)
 		c.AssertPersisted(c.ctx, t)
 	}
 }
@@ -208,7 +217,7 @@ func (c *Channel) SetWithdrawn(t require.TestingT) {
 // Settle removes the channels data from the db and checks whether it really was
 // removed from said db or not.
 func (c *Channel) Settle(t require.TestingT) {
-	c.pr.ChannelRemoved(c.ctx, c.ID())
+	require.NoError(t, c.pr.ChannelRemoved(c.ctx, c.ID()))
 	rc, err := c.pr.RestoreChannel(c.ctx, c.ID())
 	require.Error(t, err, "restoring of a non-existing channel")
 	require.Nil(t, rc, "restoring of a non-existing channel")
@@ -0,0 +1,38 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"bytes"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// EncodeLegacyState encodes a state in the layout in which the app data was
+// not prefixed with its length. It is used to test migrations.
+func EncodeLegacyState(s *channel.State) ([]byte, error) {
+	var buf bytes.Buffer
+	err := perunio.Encode(&buf, s.Backend, s.ID, s.Version, s.Allocation, s.IsFinal, s.App.Def(), s.Data)
+	return buf.Bytes(), err
+}
+
+// EncodeLegacyTransaction encodes a transaction in the layout in which the app
+// data of its state was not prefixed with its length. It is used to test
+// migrations.
+func EncodeLegacyTransaction(tx channel.Transaction) ([]byte, error) {
+	if tx.State == nil {
+		return []byte{0}, nil
+	}
+	state, err := EncodeLegacyState(tx.State)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(append([]byte{1}, state...))
+	err = wallet.EncodeSparseSigs(buf, tx.Sigs)
+	return buf.Bytes(), err
+}
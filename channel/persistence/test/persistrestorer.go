@@ -28,11 +28,18 @@ import (
 type PersistRestorer struct {
 	t *testing.T
 
-	mu    sync.RWMutex // protects chans map and peerChans access
+	mu    sync.RWMutex // protects chans map, peerChans and props access
 	chans map[channel.ID]*persistence.Channel
 	pcs   peerChans
+	props map[[32]byte]persistence.Proposal
+	tombs map[channel.ID]uint64
 }
 
+var (
+	_ persistence.ProposalPersister  = (*PersistRestorer)(nil)
+	_ persistence.TombstonePersister = (*PersistRestorer)(nil)
+)
+
 // NewPersistRestorer creates a new testing PersistRestorer that reports assert
 // errors on the passed *testing.T t.
 func NewPersistRestorer(t *testing.T) *PersistRestorer {
@@ -40,6 +47,8 @@ func NewPersistRestorer(t *testing.T) *PersistRestorer {
 		t:     t,
 		chans: make(map[channel.ID]*persistence.Channel),
 		pcs:   make(peerChans),
+		props: make(map[[32]byte]persistence.Proposal),
+		tombs: make(map[channel.ID]uint64),
 	}
 }
 
@@ -116,9 +125,13 @@ func (p *PersistRestorer) Enabled(_ context.Context, s channel.Source) error {
 	return nil
 }
 
-// PhaseChanged only persists the phase.
+// PhaseChanged only persists the phase. Watchers change the phase concurrently
+// to the channel machine, so the phase is written under the lock.
 func (p *PersistRestorer) PhaseChanged(_ context.Context, s channel.Source) error {
-	ch, ok := p.get(s.ID())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, ok := p.chans[s.ID()]
 	if !ok {
 		return errors.Errorf("channel doesn't exist: %x", s.ID())
 	}
@@ -131,9 +144,63 @@ func (p *PersistRestorer) PhaseChanged(_ context.Context, s channel.Source) erro
 // data is deleted. It can be reused afterwards.
 func (p *PersistRestorer) Close() error {
 	p.chans = make(map[channel.ID]*persistence.Channel)
+	p.props = make(map[[32]byte]persistence.Proposal)
+	p.tombs = make(map[channel.ID]uint64)
+	return nil
+}
+
+// ProposalUpdated stores a copy of the proposal.
+func (p *PersistRestorer) ProposalUpdated(_ context.Context, prop *persistence.Proposal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.props[prop.SessID] = *prop
+	return nil
+}
+
+// ProposalRemoved removes the proposal from the test persister's memory.
+func (p *PersistRestorer) ProposalRemoved(_ context.Context, sessID [32]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.props, sessID)
+	return nil
+}
+
+// RestoreProposals returns copies of all stored proposals.
+func (p *PersistRestorer) RestoreProposals(context.Context) ([]*persistence.Proposal, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	props := make([]*persistence.Proposal, 0, len(p.props))
+	for _, prop := range p.props {
+		prop := prop
+		props = append(props, &prop)
+	}
+	return props, nil
+}
+
+// TombstoneAdded stores the tombstone.
+func (p *PersistRestorer) TombstoneAdded(_ context.Context, id channel.ID, version uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tombs[id] = version
 	return nil
 }
 
+// RestoreTombstones returns a copy of all stored tombstones.
+func (p *PersistRestorer) RestoreTombstones(context.Context) (map[channel.ID]uint64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	tombs := make(map[channel.ID]uint64, len(p.tombs))
+	for id, version := range p.tombs {
+		tombs[id] = version
+	}
+	return tombs, nil
+}
+
 // AssertEqual asserts that a channel of the same ID got persisted and that all
 // its data fields match the data coming from Source s.
 func (p *PersistRestorer) AssertEqual(s channel.Source) {
@@ -172,6 +239,21 @@ func (p *PersistRestorer) ActivePeers(context.Context) ([]wire.Address, error) {
 	return p.pcs.Peers(), nil
 }
 
+// RestoreAll returns an iterator over all persisted channels.
+func (p *PersistRestorer) RestoreAll() (persistence.ChannelIterator, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	it := &chanIter{
+		chans: make([]*persistence.Channel, 0, len(p.chans)),
+		idx:   -1,
+	}
+	for _, ch := range p.chans {
+		it.chans = append(it.chans, ch)
+	}
+	return it, nil
+}
+
 // RestorePeer returns an iterator over all persisted channels which
 // the given peer is a part of.
 func (p *PersistRestorer) RestorePeer(peer wire.Address) (persistence.ChannelIterator, error) {
@@ -189,7 +271,7 @@ func (p *PersistRestorer) RestorePeer(peer wire.Address) (persistence.ChannelIte
 	return it, nil
 }
 
-// RestoreChannel should return the channel with the requested ID.
+// RestoreChannel returns a copy of the channel with the requested ID.
 func (p *PersistRestorer) RestoreChannel(_ context.Context, id channel.ID) (*persistence.Channel, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -199,7 +281,7 @@ func (p *PersistRestorer) RestoreChannel(_ context.Context, id channel.ID) (*per
 		return nil, errors.Errorf("channel not found: %x", id)
 	}
 
-	return ch, nil
+	return persistence.CloneSource(ch), nil
 }
 
 type chanIter struct {
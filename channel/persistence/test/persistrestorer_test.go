@@ -25,3 +25,23 @@ func TestPersistRestorer_Generic(t *testing.T) {
 		8,
 	)
 }
+
+func TestPersistRestorer_Proposals(t *testing.T) {
+	test.GenericProposalPersisterTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x9809)),
+		test.NewPersistRestorer(t),
+		16,
+	)
+}
+
+func TestPersistRestorer_Tombstones(t *testing.T) {
+	test.GenericTombstonePersisterTest(
+		context.Background(),
+		t,
+		rand.New(rand.NewSource(0x7057)),
+		test.NewPersistRestorer(t),
+		16,
+	)
+}
@@ -151,6 +151,25 @@ func GenericPersistRestorerTest(
 		}
 	}
 
+	// Test RestoreAll
+	it, err := pr.RestoreAll()
+	require.NoError(t, err)
+	numRestored := 0
+	for it.Next(ctx) {
+		ch := it.Channel()
+		var cached *Channel
+		for _, chmap := range channels {
+			if c, ok := chmap[ch.ID()]; ok {
+				cached = c
+			}
+		}
+		require.NotNil(t, cached, "RestoreAll returned unknown channel")
+		cached.RequireEqual(t, ch)
+		numRestored++
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, numPeers*numChans, numRestored, "RestoreAll")
+
 	// Test ActivePeers
 	persistedPeers, err := pr.ActivePeers(ctx)
 	require.NoError(t, err)
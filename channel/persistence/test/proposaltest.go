@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel/persistence"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+// GenericProposalPersisterTest tests a ProposalPersister by persisting,
+// updating and removing numProps proposals. pp must be fresh and not contain
+// any previous proposals.
+func GenericProposalPersisterTest(
+	ctx context.Context,
+	t *testing.T,
+	rng *rand.Rand,
+	pp persistence.ProposalPersister,
+	numProps int) {
+	props := make(map[[32]byte]*persistence.Proposal, numProps)
+	for i := 0; i < numProps; i++ {
+		prop := &persistence.Proposal{
+			Role:  persistence.ProposalRole(i % 2),
+			Stage: persistence.ProposalSent,
+			Peer:  wtest.NewRandomAddress(rng),
+		}
+		rng.Read(prop.SessID[:])
+		require.NoError(t, pp.ProposalUpdated(ctx, prop))
+		props[prop.SessID] = prop
+	}
+	requireProposals(ctx, t, pp, props)
+
+	// Update every second proposal and remove every third.
+	i := 0
+	for id, prop := range props {
+		if i%2 == 0 {
+			prop.Stage = persistence.ProposalAccepted
+			require.NoError(t, pp.ProposalUpdated(ctx, prop))
+		}
+		if i%3 == 0 {
+			require.NoError(t, pp.ProposalRemoved(ctx, id))
+			delete(props, id)
+		}
+		i++
+	}
+	requireProposals(ctx, t, pp, props)
+
+	for id := range props {
+		require.NoError(t, pp.ProposalRemoved(ctx, id))
+	}
+	requireProposals(ctx, t, pp, nil)
+}
+
+func requireProposals(
+	ctx context.Context,
+	t *testing.T,
+	pp persistence.ProposalPersister,
+	expected map[[32]byte]*persistence.Proposal) {
+	restored, err := pp.RestoreProposals(ctx)
+	require.NoError(t, err)
+	require.Len(t, restored, len(expected))
+	for _, prop := range restored {
+		exp, ok := expected[prop.SessID]
+		require.True(t, ok, "unexpected proposal %x", prop.SessID)
+		assert.Equal(t, exp.Role, prop.Role)
+		assert.Equal(t, exp.Stage, prop.Stage)
+		assert.True(t, exp.Peer.Equals(prop.Peer), "peer mismatch")
+	}
+}
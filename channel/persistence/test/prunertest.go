@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	wtest "perun.network/go-perun/wire/test"
+)
+
+// GenericPrunerTest tests a Pruner by withdrawing some of numChans channels
+// and asserting that exactly those are removed once their retention period is
+// over. pr must be fresh and not contain any previous channels.
+func GenericPrunerTest(
+	ctx context.Context,
+	t *testing.T,
+	rng *rand.Rand,
+	pr persistence.Pruner,
+	numChans int) {
+	c := NewClient(ctx, t, rng, pr)
+	peer := wtest.NewRandomAddress(rng)
+
+	var withdrawn, open []*Channel
+	for i := 0; i < numChans; i++ {
+		ch := c.NewChannel(t, peer)
+		ch.Init(t, rng)
+		ch.SignAll(t)
+		ch.EnableInit(t)
+		ch.SetFunded(t)
+		if i%2 == 0 {
+			open = append(open, ch)
+			continue
+		}
+		ch.Withdraw(t)
+		withdrawn = append(withdrawn, ch)
+	}
+
+	n, err := pr.Prune(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n, "no channel withdrawn before retention")
+	for _, ch := range withdrawn {
+		ch.AssertPersisted(ctx, t)
+	}
+
+	n, err = pr.Prune(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, len(withdrawn), n, "all withdrawn channels pruned")
+	for _, ch := range withdrawn {
+		restored, err := pr.RestoreChannel(ctx, ch.ID())
+		assert.Error(t, err, "restoring pruned channel")
+		assert.Nil(t, restored)
+	}
+	for _, ch := range open {
+		ch.AssertPersisted(ctx, t)
+	}
+
+	n, err = pr.Prune(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, n, "pruning is idempotent")
+
+	for _, ch := range open {
+		ch.Settle(t)
+	}
+}
+
+// Withdraw finalizes, registers and withdraws a funded channel.
+func (c *Channel) Withdraw(t require.TestingT) {
+	statef := c.State().Clone()
+	statef.Version++
+	statef.IsFinal = true
+	c.Update(t, statef, c.Idx())
+	c.SignAll(t)
+	c.EnableFinal(t)
+
+	c.SetRegistering(t)
+	c.SetRegistered(t, &channel.RegisteredEvent{
+		ID:      c.ID(),
+		Version: statef.Version,
+		Timeout: new(channel.ElapsedTimeout),
+	})
+	c.SetWithdrawing(t)
+	c.SetWithdrawn(t)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+)
+
+// GenericTombstonePersisterTest tests a TombstonePersister by persisting and
+// overwriting numTombs tombstones. tp must be fresh and not contain any
+// previous tombstones.
+func GenericTombstonePersisterTest(
+	ctx context.Context,
+	t *testing.T,
+	rng *rand.Rand,
+	tp persistence.TombstonePersister,
+	numTombs int) {
+	tombs, err := tp.RestoreTombstones(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tombs)
+
+	expected := make(map[channel.ID]uint64, numTombs)
+	for i := 0; i < numTombs; i++ {
+		var id channel.ID
+		rng.Read(id[:])
+		expected[id] = rng.Uint64()
+		require.NoError(t, tp.TombstoneAdded(ctx, id, expected[id]))
+	}
+	tombs, err = tp.RestoreTombstones(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, tombs)
+
+	for id := range expected {
+		expected[id] = math.MaxUint64
+		require.NoError(t, tp.TombstoneAdded(ctx, id, expected[id]))
+	}
+	tombs, err = tp.RestoreTombstones(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, tombs, "tombstones must be overwritten")
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package persistence
+
+import (
+	"context"
+
+	"perun.network/go-perun/channel"
+)
+
+// A TombstonePersister is a PersistRestorer that also keeps tombstones of
+// removed channels. A tombstone records the version up to which a channel was
+// removed, so that replicated persistence can tell removed channels apart
+// from channels that are still stored by a replica that missed the removal.
+type TombstonePersister interface {
+	PersistRestorer
+
+	// TombstoneAdded should persist that the channel with the given ID was
+	// removed at the given version, overwriting a previously persisted
+	// tombstone of the same channel.
+	TombstoneAdded(ctx context.Context, id channel.ID, version uint64) error
+
+	// RestoreTombstones should return the versions of all persisted
+	// tombstones.
+	RestoreTombstones(context.Context) (map[channel.ID]uint64, error)
+}
@@ -23,6 +23,9 @@ type (
 	State struct {
 		// id is the immutable id of the channel this state belongs to
 		ID ID
+		// Backend is the ID of the backend of the channel's blockchain. It
+		// determines how the app definition and assets are decoded.
+		Backend wallet.BackendID
 		// version counter
 		Version uint64
 		// App identifies the application that this channel is running.
@@ -70,6 +73,7 @@ func newState(params *Params, initBals Allocation, initData Data) (*State, error
 	}
 	return &State{
 		ID:         params.ID(),
+		Backend:    params.Backend,
 		Version:    0,
 		App:        params.App,
 		Allocation: initBals,
@@ -96,19 +100,28 @@ func (s *State) Clone() *State {
 
 // Encode encodes a state into an `io.Writer` or returns an `error`
 func (s State) Encode(w io.Writer) error {
-	err := perunio.Encode(w, s.ID, s.Version, s.Allocation, s.IsFinal, s.App.Def(), s.Data)
-	return errors.WithMessage(err, "state encode")
+	if err := perunio.Encode(w, s.Backend, s.ID, s.Version, s.Allocation, s.IsFinal, s.App.Def()); err != nil {
+		return errors.WithMessage(err, "state encode")
+	}
+	return errors.WithMessage(EncodeData(w, s.Data), "state encode")
 }
 
 // Decode decodes a state from an `io.Reader` or returns an `error`
 func (s *State) Decode(r io.Reader) error {
-	// Decode ID, Version, Allocation, IsFinal
-	if err := perunio.Decode(r, &s.ID, &s.Version, &s.Allocation, &s.IsFinal); err != nil {
-		return errors.WithMessage(err, "id or version decode")
+	// Decode Backend, ID, Version
+	if err := perunio.Decode(r, &s.Backend, &s.ID, &s.Version); err != nil {
+		return errors.WithMessage(err, "backend, id or version decode")
+	}
+	// Decode Allocation, IsFinal
+	if err := s.Allocation.DecodeOf(s.Backend, r); err != nil {
+		return errors.WithMessage(err, "allocation decode")
+	}
+	if err := perunio.Decode(r, &s.IsFinal); err != nil {
+		return errors.WithMessage(err, "isFinal decode")
 	}
 	// Decode app
 	var err error
-	def, err := wallet.DecodeAddress(r)
+	def, err := wallet.DecodeAddressOf(s.Backend, r)
 	if err != nil {
 		return errors.WithMessage(err, "app definition decode")
 	}
@@ -117,7 +130,7 @@ func (s *State) Decode(r io.Reader) error {
 		return errors.WithMessage(err, "app from definition")
 	}
 	// Decode app data
-	s.Data, err = s.App.DecodeData(r)
+	s.Data, err = DecodeData(r, s.App)
 	return errors.WithMessage(err, "app decode data")
 }
 
@@ -130,6 +143,9 @@ func (s *State) Equal(t *State) error {
 	if s.ID != t.ID {
 		return errors.New("different IDs")
 	}
+	if s.Backend != t.Backend {
+		return errors.New("different Backends")
+	}
 	if s.Version != t.Version {
 		return errors.New("different Versions")
 	}
@@ -6,6 +6,7 @@
 package test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,7 +40,8 @@ type Setup struct {
 // GenericBackendTest tests the interface functions of the global channel.Backend with the passed test data.
 func GenericBackendTest(t *testing.T, s *Setup) {
 	require := require.New(t)
-	ID := channel.CalcID(s.Params)
+	ID, err := channel.CalcID(s.Params)
+	require.NoError(err, "CalcID should not return an error")
 	require.Equal(ID, s.State.ID, "ChannelID(params) should match the States ID")
 	require.Equal(ID, s.Params.ID(), "ChannelID(params) should match the Params ID")
 	require.NotNil(s.State.Data, "State data can not be nil")
@@ -61,10 +63,15 @@ func GenericBackendTest(t *testing.T, s *Setup) {
 func genericChannelIDTest(t *testing.T, s *Setup) {
 	require.NotNil(t, s.Params.Parts, "params.Parts can not be nil")
 	assert.Panics(t, func() { channel.CalcID(nil) }, "ChannelID(nil) should panic")
+	unknown := *s.Params
+	unknown.Backend = math.MaxUint32
+	_, err := channel.CalcID(&unknown)
+	assert.Error(t, err, "CalcID should return an error for an unknown backend")
 
 	// Check that modifying the state changes the id
 	for _, modParams := range buildModifiedParams(s.Params, s.Params2, s) {
-		ID := channel.CalcID(&modParams)
+		ID, err := channel.CalcID(&modParams)
+		assert.NoError(t, err, "CalcID should not return an error")
 		assert.NotEqual(t, ID, s.State.ID, "Channel ids should differ")
 	}
 }
@@ -76,7 +83,9 @@ func genericSignTest(t *testing.T, s *Setup) {
 
 func genericVerifyTest(t *testing.T, s *Setup) {
 	addr := s.Account.Address()
-	require.Equal(t, channel.CalcID(s.Params), s.Params.ID(), "Invalid test params")
+	ID, err := channel.CalcID(s.Params)
+	require.NoError(t, err, "CalcID should not return an error")
+	require.Equal(t, ID, s.Params.ID(), "Invalid test params")
 	sig, err := channel.Sign(s.Account, s.Params, s.State)
 	require.NoError(t, err, "Sign should not return an error")
 
@@ -16,7 +16,9 @@ import (
 type MockAppRandomizer struct {
 }
 
-// NewRandomApp creates a new MockApp with a random address.
+// NewRandomApp creates a new MockApp with a random address. The app is not
+// registered, so tests have to resolve MockApps by setting the MockAppBackend
+// as default with channel.RegisterDefaultAppBackend.
 func (MockAppRandomizer) NewRandomApp(rng *rand.Rand) channel.App {
 	return channel.NewMockApp(test.NewRandomAddress(rng))
 }
@@ -133,7 +133,7 @@ func NewRandomParamsAndState(rng *rand.Rand, opts ...RandomOpt) (params *channel
 }
 
 // NewRandomParams generates a new random `channel.Params`.
-// Options: `WithParams`, `WithNumParts`, `WithParts`, `WithFirstPart`, `WithNonce`, `WithChallengeDuration`
+// Options: `WithParams`, `WithBackend`, `WithNumParts`, `WithParts`, `WithFirstPart`, `WithNonce`, `WithChallengeDuration`
 // and all from `NewRandomApp`.
 func NewRandomParams(rng *rand.Rand, opts ...RandomOpt) *channel.Params {
 	opt := mergeRandomOpts(opts...)
@@ -156,13 +156,13 @@ func NewRandomParams(rng *rand.Rand, opts ...RandomOpt) *channel.Params {
 	challengeDuration := opt.ChallengeDuration(rng)
 	app := NewRandomApp(rng, opt)
 
-	params := channel.NewParamsUnsafe(challengeDuration, parts, app.Def(), nonce)
+	params := channel.NewParamsUnsafe(opt.Backend(), challengeDuration, parts, app.Def(), nonce)
 	updateOpts(opts, WithParams(params))
 	return params
 }
 
 // NewRandomState generates a new random `channel.State`.
-// Options: `WithState`, `WithVersion`, `WithIsFinal`
+// Options: `WithState`, `WithBackend`, `WithVersion`, `WithIsFinal`
 // and all from `NewRandomChannelID`, `NewRandomApp`, `NewRandomAllocation` and `NewRandomData`.
 func NewRandomState(rng *rand.Rand, opts ...RandomOpt) (state *channel.State) {
 	opt := mergeRandomOpts(opts...)
@@ -178,6 +178,7 @@ func NewRandomState(rng *rand.Rand, opts ...RandomOpt) (state *channel.State) {
 	isFinal := opt.IsFinal(rng)
 
 	state = &channel.State{
+		Backend:    opt.Backend(),
 		ID:         id,
 		Version:    version,
 		App:        app,
@@ -46,6 +46,11 @@ func WithAssets(assets ...channel.Asset) RandomOpt {
 	return RandomOpt{"assets": assets, "numAssets": len(assets)}
 }
 
+// WithBackend sets the ID of the backend that should be used.
+func WithBackend(id wallet.BackendID) RandomOpt {
+	return RandomOpt{"backend": id}
+}
+
 // WithBalances sets the `Balances` that should be used in a generated Allocation.
 // Also sets `WithNumAssets` and `WithNumParts` iff `balances` is not empty.
 func WithBalances(balances ...[]channel.Bal) RandomOpt {
@@ -129,15 +134,15 @@ func WithNumParts(numParts int) RandomOpt {
 }
 
 // WithState sets the `State` that should be used.
-// Also sets `WithID`, `WithVersion`, `WithApp`, `WithAllocation`, `WithAppData` and `WithIsFinal`.
+// Also sets `WithBackend`, `WithID`, `WithVersion`, `WithApp`, `WithAllocation`, `WithAppData` and `WithIsFinal`.
 func WithState(state *channel.State) RandomOpt {
-	return RandomOpt{"state": state}.Append(WithID(state.ID), WithVersion(state.Version), WithApp(state.App), WithAllocation(&state.Allocation), WithAppData(state.Data), WithIsFinal(state.IsFinal))
+	return RandomOpt{"state": state}.Append(WithBackend(state.Backend), WithID(state.ID), WithVersion(state.Version), WithApp(state.App), WithAllocation(&state.Allocation), WithAppData(state.Data), WithIsFinal(state.IsFinal))
 }
 
 // WithParams sets the `Params` that should be used.
-// Also sets `WithID`, `WithChallengeDuration`, `WithParts`, `WithApp` and `WithNonce`.
+// Also sets `WithBackend`, `WithID`, `WithChallengeDuration`, `WithParts`, `WithApp` and `WithNonce`.
 func WithParams(params *channel.Params) RandomOpt {
-	return RandomOpt{"params": params}.Append(WithID(params.ID()), WithChallengeDuration(params.ChallengeDuration), WithParts(params.Parts...), WithApp(params.App), WithNonce(params.Nonce))
+	return RandomOpt{"params": params}.Append(WithBackend(params.Backend), WithID(params.ID()), WithChallengeDuration(params.ChallengeDuration), WithParts(params.Parts...), WithApp(params.App), WithNonce(params.Nonce))
 }
 
 // WithParts sets the `Parts` that should be used when generating Params.
@@ -231,6 +236,15 @@ func (o RandomOpt) Balances() [][]channel.Bal {
 	return o["balances"].([][]channel.Bal)
 }
 
+// Backend returns the `Backend` value of the `RandomOpt`.
+// If not present, returns the default backend.
+func (o RandomOpt) Backend() wallet.BackendID {
+	if _, ok := o["backend"]; !ok {
+		return wallet.DefaultBackend()
+	}
+	return o["backend"].(wallet.BackendID)
+}
+
 // BalancesRange returns the `BalancesRange` value of the `RandomOpt`.
 // If not present, returns nil,nil.
 func (o RandomOpt) BalancesRange() (min, max *int64) {
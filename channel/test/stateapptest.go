@@ -0,0 +1,258 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel"
+)
+
+// StateAppSetup provides all objects needed for the generic StateApp tests.
+type StateAppSetup struct {
+	// App is the app under test.
+	App channel.StateApp
+	// Randomizer creates the random data of the states. Only its NewRandomData
+	// is used.
+	Randomizer AppRandomizer
+	// NextData optionally creates plausible transitions, which random data
+	// rarely are. If set, it is called for half of the transitions with a copy
+	// of the current state with incremented version and the actor. It returns
+	// the data of the next state and may update the balances of the given
+	// state, e.g., by calling the app's update helpers. Otherwise, the
+	// transitions are random.
+	NextData func(rng *rand.Rand, from *channel.State, actor channel.Index) channel.Data
+	// PaymentLike enables the check that no valid transition decreases the
+	// balance of a participant other than the actor.
+	PaymentLike bool
+	// Opts are passed to the randomizers of the params and the initial states,
+	// e.g., WithNumParts or WithBalancesInRange.
+	Opts []RandomOpt
+	// NumWalks is the number of random walks, 10 if zero.
+	NumWalks int
+	// NumSteps is the number of random transitions per walk, 50 if zero.
+	NumSteps int
+}
+
+// maxInitTries is the number of random initial states that are tried for each
+// walk of GenericStateAppTest until the app accepts one.
+const maxInitTries = 100
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// GenericStateAppTest tests a StateApp with random walks of transitions. Each
+// walk starts at a random initial state that is valid for the app. Each step
+// creates a transition by a random actor, which is either created by NextData
+// or keeps or replaces the data and moves balances between the participants
+// randomly. The data of each state is
+// checked for encoding round-trips and the independence of its clones. Each
+// transition is checked to not panic and to not modify the states. If the app
+// is payment-like, valid transitions must not decrease the balance of any
+// participant other than the actor. A walk continues at the new state if the
+// transition is valid.
+// The app must be resolvable by its definition, e.g., by registering it with
+// channel.RegisterApp.
+func GenericStateAppTest(t *testing.T, rng *rand.Rand, s *StateAppSetup) {
+	require.NotNil(t, s.App, "App must be set")
+	require.NotNil(t, s.Randomizer, "Randomizer must be set")
+
+	numWalks, numSteps := s.NumWalks, s.NumSteps
+	if numWalks == 0 {
+		numWalks = 10
+	}
+	if numSteps == 0 {
+		numSteps = 50
+	}
+
+	for i := 0; i < numWalks; i++ {
+		params, state := newValidInitState(t, rng, s)
+		checkData(t, s.App, state.Data)
+		for j := 0; j < numSteps; j++ {
+			actor := channel.Index(rng.Intn(len(params.Parts)))
+			next := newRandomTransition(rng, s, state, actor)
+			checkData(t, s.App, next.Data)
+			if checkTransition(t, s, params, state, next, actor) {
+				state = next
+			}
+		}
+	}
+}
+
+// newValidInitState returns random params and a random initial state that the
+// app accepts. It fails the test if no such state is found.
+func newValidInitState(t *testing.T, rng *rand.Rand, s *StateAppSetup) (*channel.Params, *channel.State) {
+	for i := 0; i < maxInitTries; i++ {
+		// The merged options memorize random values like the number of
+		// participants, so that params and state match.
+		opt := mergeRandomOpts(s.Opts...).Append(WithApp(s.App), WithNumLocked(0))
+		params := NewRandomParams(rng, opt)
+		state := NewRandomState(rng, opt,
+			WithParams(params),
+			WithAppData(s.Randomizer.NewRandomData(rng)),
+			WithIsFinal(false))
+
+		var err error
+		require.NotPanics(t, func() { err = s.App.ValidInit(params, state) }, "ValidInit")
+		if err == nil {
+			return params, state
+		}
+	}
+	t.Fatalf("app did not accept any of %d random initial states", maxInitTries)
+	return nil, nil
+}
+
+// newRandomTransition returns the next version of the state. If the setup has
+// a NextData, it creates half of the transitions by the actor. Otherwise, the
+// next state either keeps or replaces the data and an amount of a random asset
+// is moved from one random participant to another, if any.
+func newRandomTransition(rng *rand.Rand, s *StateAppSetup, from *channel.State, actor channel.Index) *channel.State {
+	to := from.Clone()
+	to.Version++
+	if s.NextData != nil && rng.Intn(2) == 0 {
+		to.Data = s.NextData(rng, to, actor)
+		return to
+	}
+	if rng.Intn(2) == 0 {
+		to.Data = s.Randomizer.NewRandomData(rng)
+	}
+
+	numParts := len(to.Balances[0])
+	if rng.Intn(4) == 0 || numParts < 2 {
+		return to // no balance change
+	}
+	bals := to.Balances[rng.Intn(len(to.Balances))]
+	sender, receiver := rng.Intn(numParts), rng.Intn(numParts-1)
+	if receiver >= sender {
+		receiver++
+	}
+	amount := new(big.Int)
+	if bals[sender].Sign() > 0 {
+		amount.Rand(rng, bals[sender])
+		amount.Add(amount, big.NewInt(1))
+	}
+	bals[sender].Sub(bals[sender], amount)
+	bals[receiver].Add(bals[receiver], amount)
+	return to
+}
+
+// checkTransition checks the transition by the actor and returns whether it
+// is valid.
+func checkTransition(t *testing.T, s *StateAppSetup, params *channel.Params, from, to *channel.State, actor channel.Index) bool {
+	fromEnc, toEnc := encode(t, from), encode(t, to)
+	var err error
+	if !assert.NotPanics(t, func() { err = s.App.ValidTransition(params, from, to, actor) },
+		"ValidTransition by actor %d", actor) {
+		return false
+	}
+	assert.Equal(t, fromEnc, encode(t, from), "ValidTransition must not modify the from state")
+	assert.Equal(t, toEnc, encode(t, to), "ValidTransition must not modify the to state")
+	if err != nil {
+		return false
+	}
+
+	if s.PaymentLike {
+		for i, asset := range from.Balances {
+			for j, bal := range asset {
+				if channel.Index(j) != actor && to.Balances[i][j].Cmp(bal) < 0 {
+					t.Errorf("valid transition by actor %d decreases balance of participant %d in asset %d from %v to %v",
+						actor, j, i, bal, to.Balances[i][j])
+				}
+			}
+		}
+	}
+	return true
+}
+
+// checkData checks that the data survives an encoding round-trip through the
+// app's DecodeData and that its clones are independent.
+func checkData(t *testing.T, app channel.StateApp, data channel.Data) {
+	var buf bytes.Buffer
+	require.NoError(t, data.Encode(&buf), "encoding data")
+	enc := append([]byte(nil), buf.Bytes()...)
+
+	decoded, err := app.DecodeData(&buf)
+	require.NoError(t, err, "decoding data")
+	assert.Zero(t, buf.Len(), "DecodeData must read the whole encoding")
+	assert.IsType(t, data, decoded)
+	assert.Equal(t, enc, encodeData(t, decoded), "decoded data must encode equally")
+
+	clone := data.Clone()
+	assert.IsType(t, data, clone)
+	assert.Equal(t, enc, encodeData(t, clone), "cloned data must encode equally")
+	if err := sharedMemory(reflect.ValueOf(data), reflect.ValueOf(clone)); err != nil {
+		t.Errorf("clone of %T is not independent: %v", data, err)
+	}
+}
+
+// sharedMemory returns an error if a and b share mutable memory, that is,
+// pointers, slices or maps. Zero-sized values are ignored because Go may
+// allocate them at the same address. time.Time values are immutable and may
+// share their location.
+func sharedMemory(a, b reflect.Value) error {
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() ||
+		a.Type().Size() == 0 || a.Type() == timeType {
+		return nil
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return nil
+		}
+		if a.Pointer() == b.Pointer() && a.Elem().Type().Size() != 0 {
+			return errors.Errorf("shared %v", a.Type())
+		}
+		return sharedMemory(a.Elem(), b.Elem())
+	case reflect.Interface:
+		return sharedMemory(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() == 0 || b.Len() == 0 {
+			return nil
+		}
+		if a.Pointer() == b.Pointer() {
+			return errors.Errorf("shared %v", a.Type())
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			if err := sharedMemory(a.Index(i), b.Index(i)); err != nil {
+				return errors.WithMessagef(err, "index %d", i)
+			}
+		}
+	case reflect.Map:
+		if !a.IsNil() && a.Pointer() == b.Pointer() {
+			return errors.Errorf("shared %v", a.Type())
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if err := sharedMemory(a.Field(i), b.Field(i)); err != nil {
+				return errors.WithMessagef(err, "field %s", a.Type().Field(i).Name)
+			}
+		}
+	}
+	return nil
+}
+
+func encode(t *testing.T, s *channel.State) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, s.Encode(&buf), "encoding state")
+	return buf.Bytes()
+}
+
+func encodeData(t *testing.T, data channel.Data) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, data.Encode(&buf), "encoding data")
+	return buf.Bytes()
+}
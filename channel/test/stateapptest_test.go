@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedMemory(t *testing.T) {
+	type data struct {
+		Amount *big.Int
+		Bytes  []byte
+		Map    map[string]int
+		Time   time.Time
+		Empty  *struct{}
+	}
+	newData := func() *data {
+		return &data{
+			Amount: big.NewInt(42),
+			Bytes:  []byte{1, 2, 3},
+			Map:    map[string]int{"a": 1},
+			Time:   time.Now(),
+			Empty:  new(struct{}),
+		}
+	}
+	shared := func(a, b interface{}) bool {
+		return sharedMemory(reflect.ValueOf(a), reflect.ValueOf(b)) != nil
+	}
+
+	a, b := newData(), newData()
+	assert.False(t, shared(a, b), "independent data")
+	b.Time = a.Time
+	b.Empty = a.Empty
+	assert.False(t, shared(a, b), "time and zero-sized values are ignored")
+	assert.True(t, shared(a, a), "same pointer")
+
+	modified := []func(a, b *data){
+		func(a, b *data) { b.Amount = a.Amount },
+		func(a, b *data) { b.Bytes = a.Bytes },
+		func(a, b *data) { b.Map = a.Map },
+	}
+	for i, modify := range modified {
+		c := newData()
+		modify(a, c)
+		assert.Truef(t, shared(a, c), "shared field %d", i)
+	}
+}
@@ -65,5 +65,5 @@ func (t *Transaction) Decode(r io.Reader) error {
 
 	t.Sigs = make([]wallet.Sig, t.State.NumParts())
 
-	return wallet.DecodeSparseSigs(r, &t.Sigs)
+	return wallet.DecodeSparseSigsOf(t.State.Backend, r, &t.Sigs)
 }
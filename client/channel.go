@@ -50,7 +50,11 @@ func (c *Client) newChannel(
 
 // channelFromSource is used to create a channel controller from restored data.
 func (c *Client) channelFromSource(s channel.Source, peers ...*wire.Endpoint) (*Channel, error) {
-	acc, err := c.wallet.Unlock(s.Params().Parts[s.Idx()])
+	b, err := c.backend(s.Params().Backend)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := b.wallet.Unlock(s.Params().Parts[s.Idx()])
 	if err != nil {
 		return nil, errors.WithMessage(err, "unlocking account for channel")
 	}
@@ -65,6 +69,10 @@ func (c *Client) channelFromSource(s channel.Source, peers ...*wire.Endpoint) (*
 
 // channelFromMachine creates a channel controller around the passed state machine.
 func (c *Client) channelFromMachine(machine *channel.StateMachine, peers ...*wire.Endpoint) (*Channel, error) {
+	b, err := c.backend(machine.Params().Backend)
+	if err != nil {
+		return nil, err
+	}
 	pmachine := persistence.FromStateMachine(machine, c.pr)
 
 	// bundle peers into channel connection
@@ -80,8 +88,8 @@ func (c *Client) channelFromMachine(machine *channel.StateMachine, peers ...*wir
 		log:         logger,
 		conn:        conn,
 		machine:     pmachine,
-		adjudicator: c.adjudicator,
-		wallet:      c.wallet,
+		adjudicator: b.adjudicator,
+		wallet:      b.wallet,
 	}, nil
 }
 
@@ -174,6 +182,7 @@ func (c *Channel) initExchangeSigsAndEnable(ctx context.Context) error {
 	go func() {
 		send <- c.conn.Send(ctx, &msgChannelUpdateAcc{
 			ChannelID: c.ID(),
+			Backend:   c.Params().Backend,
 			Version:   0,
 			Sig:       sig,
 		})
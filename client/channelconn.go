@@ -116,6 +116,12 @@ func (c *channelConn) Peers() []wire.Address {
 	return ps
 }
 
+// isOffline returns whether the connection has no peers, e.g., because the
+// channel was restored before its peers connected.
+func (c *channelConn) isOffline() bool {
+	return len(c.peerIdx) == 0
+}
+
 // newUpdateResRecv creates a new update response receiver for the given version.
 // The receiver should be closed after all expected responses are received.
 // The receiver is also closed when the channel connection is closed.
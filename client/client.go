@@ -7,6 +7,8 @@ package client
 
 import (
 	"context"
+	stdsync "sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -24,17 +26,26 @@ import (
 //
 // Currently, only the two-party protocol is fully implemented.
 type Client struct {
-	id          wire.Account
-	peers       *wire.EndpointRegistry
-	channels    chanRegistry
-	reqRecv     *wire.Receiver
+	id       wire.Account
+	peers    *wire.EndpointRegistry
+	channels chanRegistry
+	reqRecv  *wire.Receiver
+	backends map[wallet.BackendID]*chainBackend
+	pr       persistence.PersistRestorer
+	log      log.Logger // structured logger for this client
+
+	staleMtx   stdsync.Mutex
+	staleProps []*persistence.Proposal // proposals in flight before a restart
+
+	sync.Closer
+}
+
+// chainBackend holds the funder, adjudicator and wallet that the client uses
+// for the channels of one blockchain backend.
+type chainBackend struct {
 	funder      channel.Funder
 	adjudicator channel.Adjudicator
 	wallet      wallet.Wallet
-	pr          persistence.PersistRestorer
-	log         log.Logger // structured logger for this client
-
-	sync.Closer
 }
 
 // New creates a new State Channel Client.
@@ -52,13 +63,16 @@ type Client struct {
 // The wallet is used to resolve addresses to accounts when creating or
 // restoring channels.
 //
+// The funder, adjudicator and wallet are used for the channels of the default
+// wallet backend. Further backends can be added with AddBackend.
+//
 // If any argument is nil, New panics.
 func New(
 	id wire.Account,
 	dialer wire.Dialer,
 	funder channel.Funder,
 	adjudicator channel.Adjudicator,
-	wallet wallet.Wallet,
+	w wallet.Wallet,
 ) *Client {
 	if id == nil {
 		log.Panic("identity must not be nil")
@@ -70,24 +84,59 @@ func New(
 		log.Panic("funder must not be nil")
 	} else if adjudicator == nil {
 		log.Panic("adjudicator must not be nil")
-	} else if wallet == nil {
+	} else if w == nil {
 		log.Panic("wallet must not be nil")
 	}
 
 	c := &Client{
-		id:          id,
-		channels:    makeChanRegistry(),
-		reqRecv:     wire.NewReceiver(),
-		funder:      funder,
-		adjudicator: adjudicator,
-		wallet:      wallet,
-		pr:          persistence.NonPersistRestorer,
-		log:         log,
+		id:       id,
+		channels: makeChanRegistry(),
+		reqRecv:  wire.NewReceiver(),
+		backends: map[wallet.BackendID]*chainBackend{
+			wallet.DefaultBackend(): {funder, adjudicator, w},
+		},
+		pr:  persistence.NonPersistRestorer,
+		log: log,
 	}
 	c.peers = wire.NewEndpointRegistry(id, c.subscribePeer, dialer)
 	return c
 }
 
+// AddBackend adds the funder, adjudicator and wallet that are used for the
+// channels of the blockchain backend with the given ID. This way, a client can
+// open channels on several blockchains. The backend must be registered with
+// the channel package and must not have been added yet. This methods is
+// expected to be called during the setup of the client and is hence not
+// thread-safe.
+func (c *Client) AddBackend(
+	id wallet.BackendID,
+	funder channel.Funder,
+	adjudicator channel.Adjudicator,
+	wallet wallet.Wallet,
+) error {
+	if funder == nil || adjudicator == nil || wallet == nil {
+		return errors.New("invalid nil argument")
+	}
+	if _, err := channel.BackendOf(id); err != nil {
+		return err
+	}
+	if _, ok := c.backends[id]; ok {
+		return errors.Errorf("backend %d already added", id)
+	}
+	c.backends[id] = &chainBackend{funder, adjudicator, wallet}
+	return nil
+}
+
+// backend returns the funder, adjudicator and wallet for the backend with the
+// given ID.
+func (c *Client) backend(id wallet.BackendID) (*chainBackend, error) {
+	b, ok := c.backends[id]
+	if !ok {
+		return nil, errors.Errorf("no funder, adjudicator and wallet for backend %d", id)
+	}
+	return b, nil
+}
+
 // Close closes this state channel client.
 // It also closes the peer registry.
 func (c *Client) Close() error {
@@ -122,6 +171,56 @@ func (c *Client) EnablePersistence(pr persistence.PersistRestorer) {
 	c.pr = pr
 }
 
+// EnablePruning starts removing the persisted data of channels that have been
+// withdrawn for longer than the retention period. The PersistRestorer is
+// pruned right away and then every interval until the client is closed. The
+// PersistRestorer set with EnablePersistence has to be a persistence.Pruner.
+// The retention period and interval have to be positive. This method is
+// expected to be called once during the setup of the client, after
+// EnablePersistence.
+func (c *Client) EnablePruning(retention, interval time.Duration) error {
+	if retention <= 0 {
+		return errors.Errorf("retention period must be positive, is %v", retention)
+	} else if interval <= 0 {
+		return errors.Errorf("pruning interval must be positive, is %v", interval)
+	}
+	pruner, ok := c.pr.(persistence.Pruner)
+	if !ok {
+		return errors.New("PersistRestorer does not support pruning")
+	}
+	go c.pruneLoop(pruner, retention, interval)
+	return nil
+}
+
+func (c *Client) pruneLoop(pruner persistence.Pruner, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := pruner.Prune(c.Ctx(), time.Now().Add(-retention))
+		if err != nil {
+			c.log.Warnf("Pruning withdrawn channels: %v", err)
+		} else if n > 0 {
+			c.log.Infof("Pruned %d withdrawn channels.", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-c.Closed():
+			return
+		}
+	}
+}
+
+// SetRateLimits sets per-peer limits on inbound messages, e.g., on channel
+// proposals and updates, which otherwise start a handler routine each. The
+// limits apply to all peers that connect afterwards. Peers that exceed the
+// limits too often are disconnected and optionally banned, see
+// wire.RateLimitConfig. This method is expected to be called once during the
+// setup of the client.
+func (c *Client) SetRateLimits(cfg wire.RateLimitConfig) {
+	c.peers.SetRateLimits(cfg)
+}
+
 // Channel queries a channel by its ID.
 func (c *Client) Channel(id channel.ID) (*Channel, error) {
 	if ch, ok := c.channels.Get(id); ok {
@@ -170,6 +269,7 @@ func (c *Client) subscribePeer(p *wire.Endpoint) {
 	// connection.
 	p.OnCreateAlways(func() {
 		c.restorePeerChannels(p, cancel)
+		go c.abortProposals(p)
 	})
 }
 
@@ -219,7 +319,9 @@ func (c *Client) logChan(id channel.ID) log.Logger {
 // Reconnect attempts to reconnect to all known peers from persistence. This
 // will restore all channels for all peers to which a connection could
 // successfully be established. Newly restored channels should be acquired
-// through the OnNewChannel callback.
+// through the OnNewChannel callback. Peers of proposals that were in flight
+// before a restart are also connected so that the proposals are aborted, see
+// Restore.
 //
 // Note that connections are currently established serially, so allow for enough
 // time in the passed context.
@@ -228,10 +330,73 @@ func (c *Client) Reconnect(ctx context.Context) error {
 	if err != nil {
 		return errors.WithMessage(err, "restoring active peers")
 	}
-	_, err = c.getPeers(ctx, ps)
+	_, err = c.getPeers(ctx, append(ps, c.stalePeers()...))
 	return err
 }
 
+// Restore restores all persisted channels that are not withdrawn yet and
+// starts watching them on-chain, independently of whether their peers are
+// connected. This way, disputes are also handled for channels whose peers do
+// not come back online. Restored channels are passed to the OnNewChannel
+// callback. They can be settled, but not updated until their peers reconnect.
+// Then, they are synchronized and replaced by channels that are connected to
+// their peers, which are watched as well and passed to the OnNewChannel
+// callback again.
+//
+// If the PersistRestorer is a persistence.ProposalPersister, the proposals
+// that were in flight before the restart are restored as well. They are
+// aborted with their peers as soon as the peers connect, so that both sides
+// agree that the proposed channels do not exist.
+//
+// Restore is expected to be called once during the setup of the client, after
+// EnablePersistence and OnNewChannel.
+func (c *Client) Restore(ctx context.Context) error {
+	if err := c.restoreProposals(ctx); err != nil {
+		return err
+	}
+
+	it, err := c.pr.RestoreAll()
+	if err != nil {
+		return errors.WithMessage(err, "restoring channels")
+	}
+
+	for it.Next(ctx) {
+		if chdata := it.Channel(); chdata.PhaseV != channel.Withdrawn {
+			c.restoreOfflineChannel(chdata)
+		}
+	}
+	return errors.WithMessage(it.Close(), "restoring channels")
+}
+
+// restoreOfflineChannel creates a channel controller without peers from
+// restored data and starts its watcher.
+func (c *Client) restoreOfflineChannel(chdata *persistence.Channel) {
+	log := c.logChan(chdata.ID())
+	ch, err := c.channelFromSource(chdata)
+	if err != nil {
+		log.Errorf("Failed to restore channel: %v", err)
+		return
+	}
+	if !c.channels.Put(chdata.ID(), ch) {
+		log.Debug("Channel already present, closing restored channel.")
+		ch.Close()
+		return
+	}
+	ch.wallet.IncrementUsage(ch.machine.Account().Address())
+
+	log.Info("Channel restored, watching.")
+	c.watch(ch)
+}
+
+// watch starts the watcher of a restored channel in the background.
+func (c *Client) watch(ch *Channel) {
+	go func() {
+		if err := ch.Watch(); err != nil {
+			c.logChan(ch.ID()).Warnf("Watcher returned: %v", err)
+		}
+	}()
+}
+
 // getPeers gets all peers from the registry for the provided addresses,
 // skipping the own peer, if present in the list.
 func (c *Client) getPeers(
@@ -15,7 +15,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
+	chprtest "perun.network/go-perun/channel/persistence/test"
 	channeltest "perun.network/go-perun/channel/test"
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/pkg/test"
 	"perun.network/go-perun/wallet"
 	wallettest "perun.network/go-perun/wallet/test"
 	"perun.network/go-perun/wire"
@@ -116,3 +121,161 @@ func TestClient_Channel(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+type countingPruner struct {
+	persistence.PersistRestorer
+	pruned chan time.Time
+}
+
+func (p *countingPruner) Prune(_ context.Context, before time.Time) (int, error) {
+	p.pruned <- before
+	return 0, nil
+}
+
+func TestClient_EnablePruning(t *testing.T) {
+	c := &Client{pr: persistence.NonPersistRestorer, log: log.Get()}
+	assert.Error(t, c.EnablePruning(time.Hour, time.Millisecond))
+
+	pruner := &countingPruner{persistence.NonPersistRestorer, make(chan time.Time)}
+	c.pr = pruner
+	assert.Error(t, c.EnablePruning(0, time.Millisecond), "zero retention")
+	assert.Error(t, c.EnablePruning(time.Hour, -time.Millisecond), "negative interval")
+	require.NoError(t, c.EnablePruning(time.Hour, time.Millisecond))
+	for i := 0; i < 2; i++ {
+		select {
+		case before := <-pruner.pruned:
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Minute)
+		case <-time.After(time.Second):
+			t.Fatal("not pruned")
+		}
+	}
+
+	require.NoError(t, c.Closer.Close()) // dummy client without registry
+	select {
+	case <-pruner.pruned:
+	case <-time.After(10 * time.Millisecond):
+	}
+	select {
+	case <-pruner.pruned:
+		t.Error("pruned after close")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// watchingAdjudicator reports an old state as registered for every channel
+// and records the refutations and withdrawals.
+type watchingAdjudicator struct {
+	registered chan *channel.State
+	withdrawn  chan channel.ID
+}
+
+func (a *watchingAdjudicator) Register(_ context.Context, req channel.AdjudicatorReq) (*channel.RegisteredEvent, error) {
+	a.registered <- req.Tx.State
+	return &channel.RegisteredEvent{
+		ID:      req.Params.ID(),
+		Version: req.Tx.Version,
+		Timeout: new(channel.ElapsedTimeout),
+	}, nil
+}
+
+func (a *watchingAdjudicator) SubscribeRegistered(
+	_ context.Context,
+	params *channel.Params,
+) (channel.RegisteredSubscription, error) {
+	sub := &registeredSub{events: make(chan *channel.RegisteredEvent, 1), closed: make(chan struct{})}
+	sub.events <- &channel.RegisteredEvent{ID: params.ID(), Timeout: new(channel.ElapsedTimeout)}
+	return sub, nil
+}
+
+func (a *watchingAdjudicator) Withdraw(_ context.Context, req channel.AdjudicatorReq) error {
+	a.withdrawn <- req.Params.ID()
+	return nil
+}
+
+type registeredSub struct {
+	events    chan *channel.RegisteredEvent
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *registeredSub) Next() *channel.RegisteredEvent {
+	select {
+	case ev := <-s.events:
+		return ev
+	case <-s.closed:
+		return nil
+	}
+}
+
+func (s *registeredSub) Err() error { return nil }
+
+func (s *registeredSub) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestClient_Restore(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(0x4e570e))
+	pr := chprtest.NewPersistRestorer(t)
+	peers := wiretest.NewRandomAddresses(rng, 2)
+
+	newChannel := func(phase channel.Phase) *persistence.Channel {
+		_, parts := wallettest.NewRandomAccounts(rng, 2)
+		params, state := channeltest.NewRandomParamsAndState(rng,
+			channeltest.WithParts(parts...),
+			channeltest.WithNumLocked(0),
+			channeltest.WithIsFinal(false))
+		ch := &persistence.Channel{
+			ParamsV:    params,
+			CurrentTXV: channel.Transaction{State: state, Sigs: make([]wallet.Sig, 2)},
+			PhaseV:     phase,
+		}
+		require.NoError(t, pr.ChannelCreated(ctx, ch, peers))
+		return ch
+	}
+	acting := newChannel(channel.Acting)
+	newChannel(channel.Withdrawn)
+
+	adj := &watchingAdjudicator{
+		registered: make(chan *channel.State, 2),
+		withdrawn:  make(chan channel.ID, 2),
+	}
+	c := &Client{
+		channels: makeChanRegistry(),
+		backends: map[wallet.BackendID]*chainBackend{
+			wallet.DefaultBackend(): {adjudicator: adj, wallet: wallettest.RandomWallet()},
+		},
+		pr:  pr,
+		log: log.Get(),
+	}
+	defer c.channels.CloseAll()
+	restored := make(chan *Channel, 2)
+	c.OnNewChannel(func(ch *Channel) { restored <- ch })
+
+	require.NoError(t, c.Restore(ctx))
+	require.Len(t, restored, 1, "only the non-withdrawn channel should be restored")
+	ch := <-restored
+	assert.Equal(t, acting.ID(), ch.ID())
+	assert.Empty(t, ch.Peers())
+
+	// The watcher refutes the old registered state without any peer being
+	// connected.
+	select {
+	case state := <-adj.registered:
+		assert.Equal(t, acting.CurrentTXV.State, state)
+	case <-time.After(time.Second):
+		t.Fatal("restored channel not watched")
+	}
+	select {
+	case id := <-adj.withdrawn:
+		assert.Equal(t, acting.ID(), id)
+	case <-time.After(time.Second):
+		t.Fatal("restored channel not withdrawn")
+	}
+	test.Within1s.Eventually(t, func(t test.T) {
+		pch, err := pr.RestoreChannel(ctx, acting.ID())
+		require.NoError(t, err)
+		assert.Equal(t, channel.Withdrawn, pch.PhaseV)
+	})
+}
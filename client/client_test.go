@@ -20,6 +20,7 @@ import (
 	"perun.network/go-perun/channel"
 	perunsync "perun.network/go-perun/pkg/sync"
 	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
 	wtest "perun.network/go-perun/wallet/test"
 	"perun.network/go-perun/wire"
 	wiretest "perun.network/go-perun/wire/test"
@@ -121,6 +122,34 @@ func TestClient_New(t *testing.T) {
 	assert.NotNil(t, c.peers)
 }
 
+func TestClient_AddBackend(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xadd))
+	id := wtest.NewRandomAccount(rng)
+	f, a, w := &DummyFunder{t}, &DummyAdjudicator{t}, wtest.RandomWallet()
+	c := New(id, &DummyDialer{t}, f, a, w)
+
+	// Register the default backend a second time under another ID, unless a
+	// previous run of this test already did.
+	const other wallet.BackendID = 0xadd
+	chb, err := channel.BackendOf(wallet.DefaultBackend())
+	require.NoError(t, err)
+	if _, err := channel.BackendOf(other); err != nil {
+		channel.RegisterBackend(other, chb)
+	}
+
+	assert.Error(t, c.AddBackend(wallet.DefaultBackend(), f, a, w), "default backend already added")
+	assert.Error(t, c.AddBackend(other+1, f, a, w), "unregistered backend")
+	assert.Error(t, c.AddBackend(other, nil, a, w))
+	assert.Error(t, c.AddBackend(other, f, nil, w))
+	assert.Error(t, c.AddBackend(other, f, a, nil))
+	assert.NoError(t, c.AddBackend(other, f, a, w))
+	assert.Error(t, c.AddBackend(other, f, a, w), "backend already added")
+
+	b, err := c.backend(other)
+	require.NoError(t, err)
+	assert.Same(t, w, b.wallet)
+}
+
 func TestClient_NewAndListen_ListenerClose(t *testing.T) {
 	require := require.New(t)
 	ass := assert.New(t)
@@ -0,0 +1,20 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// +build gofuzz
+
+package client
+
+import (
+	_ "perun.network/go-perun/backend/sim" // backend init
+	"perun.network/go-perun/wire"
+)
+
+// Fuzz is the go-fuzz entry point for fuzzing the decoders of all wire
+// messages, including the ones registered by package client. The simulated
+// backend is used to decode addresses, signatures and assets.
+func Fuzz(data []byte) int {
+	return wire.FuzzDecode(data)
+}
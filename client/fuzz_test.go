@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package client
+
+import (
+	"math/rand"
+	"testing"
+
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/test"
+	wallettest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wiretest "perun.network/go-perun/wire/test"
+)
+
+// TestFuzzDecoders fuzzes the decoders of all wire messages, including the
+// ones registered by package client.
+func TestFuzzDecoders(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xF0221))
+	proposal := NewRandomChannelProposalReqNumParts(rng, 2)
+	var sessID SessionID
+	rng.Read(sessID[:])
+	params, state := test.NewRandomParamsAndState(rng, test.WithNumParts(2))
+	tx := test.NewRandomTransaction(rng, []bool{true, false}, test.WithParams(params))
+
+	wiretest.FuzzDecoders(t, rng, 64,
+		wire.NewPingMsg(),
+		wire.NewAuthResponseMsg(wallettest.NewRandomAccount(rng)),
+		proposal,
+		&ChannelProposalAcc{SessID: sessID, ParticipantAddr: wallettest.NewRandomAddress(rng)},
+		&ChannelProposalRej{SessID: sessID, Reason: "fuzz"},
+		&msgChannelUpdate{
+			ChannelUpdate: ChannelUpdate{State: state, ActorIdx: 0},
+			Sig:           newRandomSig(rng),
+		},
+		&msgChannelUpdateAcc{ChannelID: state.ID, Version: state.Version, Sig: newRandomSig(rng)},
+		&msgChannelUpdateRej{ChannelID: state.ID, Version: state.Version, Reason: "fuzz"},
+		&msgChannelSync{Phase: channel.Acting, CurrentTX: *tx},
+	)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/wallet"
+)
+
+// jsonChannelProposal is the JSON encoding of a ChannelProposal, which
+// follows the JSON encodings of the channel package:
+//
+//	{"backend": 0, "challengeDuration": 60, "nonce": "42",
+//	 "participant": "0x…", "app": "0x…", "initData": "0x…",
+//	 "initBals": Allocation, "peers": ["0x…", "0x…"]}
+//
+// The participant address, app definition and initial allocation are decoded
+// with the backend of the proposal, the peer addresses with the default
+// backend.
+type jsonChannelProposal struct {
+	Backend           wallet.BackendID `json:"backend"`
+	ChallengeDuration uint64           `json:"challengeDuration"`
+	Nonce             string           `json:"nonce"`
+	ParticipantAddr   string           `json:"participant"`
+	AppDef            string           `json:"app"`
+	InitData          string           `json:"initData"`
+	InitBals          json.RawMessage  `json:"initBals"`
+	PeerAddrs         []string         `json:"peers"`
+}
+
+var (
+	_ json.Marshaler   = ChannelProposal{}
+	_ json.Unmarshaler = (*ChannelProposal)(nil)
+)
+
+// MarshalJSON encodes the channel proposal as JSON.
+func (c ChannelProposal) MarshalJSON() (_ []byte, err error) {
+	j := jsonChannelProposal{
+		Backend:           c.Backend,
+		ChallengeDuration: c.ChallengeDuration,
+		PeerAddrs:         make([]string, len(c.PeerAddrs)),
+	}
+	if c.Nonce != nil {
+		j.Nonce = c.Nonce.String()
+	}
+	if j.ParticipantAddr, err = perunio.EncodeHex(c.ParticipantAddr); err != nil {
+		return nil, errors.WithMessage(err, "encoding participant address")
+	}
+	if j.AppDef, err = perunio.EncodeHex(c.AppDef); err != nil {
+		return nil, errors.WithMessage(err, "encoding app definition")
+	}
+	if j.InitData, err = perunio.EncodeHex(c.InitData); err != nil {
+		return nil, errors.WithMessage(err, "encoding initial data")
+	}
+	if j.InitBals, err = json.Marshal(c.InitBals); err != nil {
+		return nil, errors.WithMessage(err, "encoding initial balances")
+	}
+	for i, addr := range c.PeerAddrs {
+		if j.PeerAddrs[i], err = perunio.EncodeHex(addr); err != nil {
+			return nil, errors.WithMessagef(err, "encoding peer address %d", i)
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a channel proposal from JSON. The initial data is
+// decoded by the app of the app definition.
+func (c *ChannelProposal) UnmarshalJSON(data []byte) (err error) {
+	var j jsonChannelProposal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return errors.Wrap(err, "unmarshaling channel proposal")
+	}
+
+	c.Backend, c.ChallengeDuration = j.Backend, j.ChallengeDuration
+	var ok bool
+	if c.Nonce, ok = new(big.Int).SetString(j.Nonce, 10); !ok {
+		return errors.Errorf("invalid nonce %q", j.Nonce)
+	}
+	if c.ParticipantAddr, err = wallet.DecodeAddressHexOf(c.Backend, j.ParticipantAddr); err != nil {
+		return errors.WithMessage(err, "decoding participant address")
+	}
+	if c.AppDef, err = wallet.DecodeAddressHexOf(c.Backend, j.AppDef); err != nil {
+		return errors.WithMessage(err, "decoding app definition")
+	}
+	app, err := channel.AppFromDefinition(c.AppDef)
+	if err != nil {
+		return err
+	}
+	if err := perunio.DecodeHex(j.InitData, func(r io.Reader) (err error) {
+		c.InitData, err = app.DecodeData(r)
+		return err
+	}); err != nil {
+		return errors.WithMessage(err, "decoding initial data")
+	}
+
+	c.InitBals = nil
+	if len(j.InitBals) != 0 && string(j.InitBals) != "null" {
+		c.InitBals = new(channel.Allocation)
+		if err := c.InitBals.UnmarshalJSONOf(c.Backend, j.InitBals); err != nil {
+			return errors.WithMessage(err, "decoding initial balances")
+		}
+	}
+
+	if len(j.PeerAddrs) < 2 {
+		return errors.Errorf("expected at least 2 participants, got %d", len(j.PeerAddrs))
+	}
+	if len(j.PeerAddrs) > channel.MaxNumParts {
+		return errors.Errorf("expected at most %d participants, got %d",
+			channel.MaxNumParts, len(j.PeerAddrs))
+	}
+	c.PeerAddrs = make([]wallet.Address, len(j.PeerAddrs))
+	for i, s := range j.PeerAddrs {
+		if c.PeerAddrs[i], err = wallet.DecodeAddressHex(s); err != nil {
+			return errors.WithMessagef(err, "decoding peer address %d", i)
+		}
+	}
+	return nil
+}
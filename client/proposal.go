@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"perun.network/go-perun/channel"
+	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/log"
 	"perun.network/go-perun/pkg/sync/atomic"
 	"perun.network/go-perun/wallet"
@@ -117,11 +118,19 @@ func (c *Client) ProposeChannel(ctx context.Context, req *ChannelProposal) (*Cha
 		return nil, errors.WithMessage(err, "invalid channel proposal")
 	}
 
-	// 2. send proposal and wait for response
+	// 2. persist proposal, send it and wait for response
+	sessID, peer := req.SessID(), req.PeerAddrs[1]
+	if err := c.persistProposal(ctx, sessID, persistence.Proposer, persistence.ProposalSent, peer); err != nil {
+		return nil, err
+	}
 	parts, err := c.exchangeTwoPartyProposal(ctx, req)
 	if err != nil {
+		c.removeProposal(ctx, sessID)
 		return nil, errors.WithMessage(err, "sending proposal")
 	}
+	if err := c.persistProposal(ctx, sessID, persistence.Proposer, persistence.ProposalAccepted, peer); err != nil {
+		return nil, err
+	}
 
 	// 3. create params, channel machine from gathered participant addresses
 	// 4. fund channel
@@ -160,13 +169,20 @@ func (c *Client) handleChannelProposalAcc(
 	// that might trigger a fast peer to send those. We don't know the channel id
 	// yet so the cache predicate is coarser than the later subscription.
 	enableVer0Cache(ctx, p)
+	enableAbortCache(ctx, p, req.SessID())
 
 	msgAccept := &ChannelProposalAcc{
 		SessID:          req.SessID(),
+		Backend:         req.Backend,
 		ParticipantAddr: acc.Participant,
 	}
+	if err := c.persistProposal(ctx, msgAccept.SessID,
+		persistence.Responder, persistence.ProposalAccepted, p.PerunAddress); err != nil {
+		return nil, err
+	}
 	if err := p.Send(ctx, msgAccept); err != nil {
 		c.logPeer(p).Errorf("error sending proposal acceptance: %v", err)
+		c.removeProposal(ctx, msgAccept.SessID)
 		return nil, errors.WithMessage(err, "sending proposal acceptance")
 	}
 
@@ -209,8 +225,9 @@ func (c *Client) exchangeTwoPartyProposal(
 	// that might trigger a fast peer to send those. We don't know the channel id
 	// yet so the cache predicate is coarser than the later subscription.
 	enableVer0Cache(ctx, p)
-
 	sessID := proposal.SessID()
+	enableAbortCache(ctx, p, sessID)
+
 	isResponse := func(m wire.Msg) bool {
 		return (m.Type() == wire.ChannelProposalAcc &&
 			m.(*ChannelProposalAcc).SessID == sessID) ||
@@ -237,6 +254,10 @@ func (c *Client) exchangeTwoPartyProposal(
 	}
 
 	acc := rawResponse.(*ChannelProposalAcc) // this is safe because of predicate isResponse
+	if acc.Backend != proposal.Backend {
+		return nil, errors.Errorf("participant address of backend %d, expected backend %d",
+			acc.Backend, proposal.Backend)
+	}
 	return []wallet.Address{proposal.ParticipantAddr, acc.ParticipantAddr}, nil
 }
 
@@ -253,6 +274,10 @@ func (c *Client) validTwoPartyProposal(
 		return err
 	}
 
+	if _, err := c.backend(proposal.Backend); err != nil {
+		return err
+	}
+
 	if len(proposal.PeerAddrs) != 2 {
 		return errors.Errorf("exptected 2 peers, got %d", len(proposal.PeerAddrs))
 	}
@@ -291,39 +316,26 @@ func (c *Client) setupChannel(
 	prop *ChannelProposal,
 	parts []wallet.Address, // result of the MPCPP on prop
 ) (*Channel, error) {
-	params := channel.NewParamsUnsafe(prop.ChallengeDuration, parts, prop.AppDef, prop.Nonce)
-	if c.channels.Has(params.ID()) {
-		return nil, errors.New("channel already exists")
-	}
-
-	peers, err := c.getPeers(ctx, prop.PeerAddrs)
-	if err != nil {
-		return nil, errors.WithMessage(err, "getting peers from the registry")
-	}
-	acc, err := c.wallet.Unlock(prop.ParticipantAddr)
-	if err != nil {
-		return nil, errors.WithMessage(err, "unlocking account")
-	}
-
-	ch, err := c.newChannel(acc, peers, *params)
+	b, err := c.backend(prop.Backend)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := c.pr.ChannelCreated(ctx, ch.machine, prop.PeerAddrs); err != nil {
-		return ch, errors.WithMessage(err, "persisting new channel")
+	ch, peers, err := c.createChannel(ctx, b, prop, parts)
+	if err != nil {
+		// The proposal stays persisted so that it is aborted with the peers
+		// after a restart.
+		return ch, err
 	}
+	// From now on, the channel is persisted instead of the proposal.
+	c.removeProposal(ctx, prop.SessID())
 
-	if err := ch.init(ctx, prop.InitBals, prop.InitData); err != nil {
-		return ch, errors.WithMessage(err, "setting initial bals and data")
-	}
-	if err := ch.initExchangeSigsAndEnable(ctx); err != nil {
-		return ch, errors.WithMessage(err, "exchanging initial sigs and enabling state")
+	if err := c.initChannel(ctx, ch, prop, peers); err != nil {
+		return ch, err
 	}
 
-	if err = c.funder.Fund(ctx,
+	if err = b.funder.Fund(ctx,
 		channel.FundingReq{
-			Params: params,
+			Params: ch.Params(),
 			State:  ch.machine.State(), // initial state
 			Idx:    ch.machine.Idx(),
 		}); channel.IsFundingTimeoutError(err) {
@@ -339,14 +351,48 @@ func (c *Client) setupChannel(
 	if err := ch.machine.SetFunded(ctx); err != nil {
 		return ch, errors.WithMessage(err, "error in SetFunded()")
 	}
-	if !c.channels.Put(params.ID(), ch) {
+	if !c.channels.Put(ch.ID(), ch) {
 		return ch, errors.New("channel already exists")
 	}
-	c.wallet.IncrementUsage(acc.Address())
+	b.wallet.IncrementUsage(ch.machine.Account().Address())
 
 	return ch, nil
 }
 
+// createChannel creates and persists the channel controller for the given
+// proposal and participant addresses, using the wallet of the given backend.
+// It also returns the channel's peers.
+func (c *Client) createChannel(
+	ctx context.Context,
+	b *chainBackend,
+	prop *ChannelProposal,
+	parts []wallet.Address,
+) (*Channel, []*wire.Endpoint, error) {
+	params := channel.NewParamsUnsafe(prop.Backend, prop.ChallengeDuration, parts, prop.AppDef, prop.Nonce)
+	if c.channels.Has(params.ID()) {
+		return nil, nil, errors.New("channel already exists")
+	}
+
+	peers, err := c.getPeers(ctx, prop.PeerAddrs)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "getting peers from the registry")
+	}
+	acc, err := b.wallet.Unlock(prop.ParticipantAddr)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "unlocking account")
+	}
+
+	ch, err := c.newChannel(acc, peers, *params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.pr.ChannelCreated(ctx, ch.machine, prop.PeerAddrs); err != nil {
+		return ch, nil, errors.WithMessage(err, "persisting new channel")
+	}
+	return ch, peers, nil
+}
+
 // enableVer0Cache enables caching of incoming version 0 signatures
 func enableVer0Cache(ctx context.Context, c wire.Cacher) {
 	c.Cache(ctx, func(m wire.Msg) bool {
@@ -19,9 +19,10 @@ import (
 func TestClient_validTwoPartyProposal(t *testing.T) {
 	rng := rand.New(rand.NewSource(0xdeadbeef))
 
-	// dummy client that only has an id
+	// dummy client that only has an id and the default backend
 	c := &Client{
-		id: wallettest.NewRandomAccount(rng),
+		id:       wallettest.NewRandomAccount(rng),
+		backends: map[wallet.BackendID]*chainBackend{wallet.DefaultBackend(): {}},
 	}
 	validProp := *NewRandomChannelProposalReqNumParts(rng, 2)
 	validProp.PeerAddrs[0] = c.id.Address() // set us as the proposer
@@ -32,6 +33,8 @@ func TestClient_validTwoPartyProposal(t *testing.T) {
 	validProp3Peers := *NewRandomChannelProposalReqNumParts(rng, 3)
 	invalidProp := validProp          // shallow copy
 	invalidProp.ChallengeDuration = 0 // invalidate
+	unknownBackendProp := validProp   // shallow copy
+	unknownBackendProp.Backend++      // no funder, adjudicator and wallet
 
 	tests := []struct {
 		prop     *ChannelProposal
@@ -64,6 +67,10 @@ func TestClient_validTwoPartyProposal(t *testing.T) {
 			&invalidProp, // invalid proposal, correct other params
 			0, peerAddr, false,
 		},
+		{
+			&unknownBackendProp, // backend not added to client
+			0, peerAddr, false,
+		},
 	}
 
 	for i, tt := range tests {
@@ -86,6 +93,7 @@ func NewRandomChannelProposalReqNumParts(rng *rand.Rand, numPeers int) *ChannelP
 	alloc := channeltest.NewRandomAllocation(rng, channeltest.WithNumParts(numPeers))
 	participantAddr := wallettest.NewRandomAddress(rng)
 	return &ChannelProposal{
+		Backend:           params.Backend,
 		ChallengeDuration: rng.Uint64(),
 		Nonce:             params.Nonce,
 		ParticipantAddr:   participantAddr,
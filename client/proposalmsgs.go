@@ -46,7 +46,13 @@ type SessionID = [32]byte
 //
 // ChannelProposal implements the channel proposal messages from the
 // Multi-Party Channel Proposal Protocol (MPCPP).
+//
+// Backend is the ID of the wallet and channel backend of the proposed channel.
+// ParticipantAddr, AppDef and the assets of InitBals belong to this backend.
+// The PeerAddrs are network identities and always belong to the default
+// wallet backend.
 type ChannelProposal struct {
+	Backend           wallet.BackendID
 	ChallengeDuration uint64
 	Nonce             *big.Int
 	ParticipantAddr   wallet.Address
@@ -67,11 +73,17 @@ func (c ChannelProposal) Encode(w io.Writer) error {
 		return errors.New("writer must not be nil")
 	}
 
-	if err := perunio.Encode(w, c.ChallengeDuration, c.Nonce); err != nil {
+	if err := perunio.Encode(w, c.Backend, c.ChallengeDuration, c.Nonce); err != nil {
 		return err
 	}
 
-	if err := perunio.Encode(w, c.ParticipantAddr, c.AppDef, c.InitData, c.InitBals); err != nil {
+	if err := perunio.Encode(w, c.ParticipantAddr, c.AppDef); err != nil {
+		return err
+	}
+	if err := channel.EncodeData(w, c.InitData); err != nil {
+		return err
+	}
+	if err := perunio.Encode(w, c.InitBals); err != nil {
 		return err
 	}
 
@@ -100,14 +112,14 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 		return errors.New("reader must not be nil")
 	}
 
-	if err := perunio.Decode(r, &c.ChallengeDuration, &c.Nonce); err != nil {
+	if err := perunio.Decode(r, &c.Backend, &c.ChallengeDuration, &c.Nonce); err != nil {
 		return err
 	}
 
-	if c.ParticipantAddr, err = wallet.DecodeAddress(r); err != nil {
+	if c.ParticipantAddr, err = wallet.DecodeAddressOf(c.Backend, r); err != nil {
 		return err
 	}
-	if c.AppDef, err = wallet.DecodeAddress(r); err != nil {
+	if c.AppDef, err = wallet.DecodeAddressOf(c.Backend, r); err != nil {
 		return err
 	}
 	var app channel.App
@@ -115,14 +127,14 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 		return err
 	}
 
-	if c.InitData, err = app.DecodeData(r); err != nil {
+	if c.InitData, err = channel.DecodeData(r, app); err != nil {
 		return err
 	}
 
 	if c.InitBals == nil {
 		c.InitBals = new(channel.Allocation)
 	}
-	if err := perunio.Decode(r, c.InitBals); err != nil {
+	if err := c.InitBals.DecodeOf(c.Backend, r); err != nil {
 		return err
 	}
 
@@ -139,6 +151,9 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 			"expected at most %d participants, got %d",
 			channel.MaxNumParts, numParts)
 	}
+	if err := perunio.CheckRemaining(r, int(numParts)); err != nil {
+		return errors.WithMessage(err, "decoding peer addresses")
+	}
 
 	c.PeerAddrs = make([]wallet.Address, numParts)
 	for i := range c.PeerAddrs {
@@ -153,7 +168,7 @@ func (c *ChannelProposal) Decode(r io.Reader) (err error) {
 // SessID calculates the SessionID of a ChannelProposalReq.
 func (c ChannelProposal) SessID() (sid SessionID) {
 	hasher := sha3.New256()
-	if err := perunio.Encode(hasher, c.Nonce); err != nil {
+	if err := perunio.Encode(hasher, c.Backend, c.Nonce); err != nil {
 		log.Panicf("session ID nonce encoding: %v", err)
 	}
 
@@ -203,12 +218,14 @@ func (c ChannelProposal) Valid() error {
 // ChannelProposalAcc contains all data for a response to a channel proposal
 // message. The SessID must be computed from the channel proposal messages one
 // wishes to respond to. ParticipantAddr should be a participant address just
-// for this channel instantiation.
+// for this channel instantiation. It belongs to the wallet backend Backend,
+// which must match the backend of the proposal.
 //
 // The type implements the channel proposal response messages from the
 // Multi-Party Channel Proposal Protocol (MPCPP).
 type ChannelProposalAcc struct {
 	SessID          SessionID
+	Backend         wallet.BackendID
 	ParticipantAddr wallet.Address
 }
 
@@ -219,7 +236,7 @@ func (ChannelProposalAcc) Type() wire.Type {
 
 // Encode encodes the ChannelProposalAcc into an io.Writer.
 func (acc ChannelProposalAcc) Encode(w io.Writer) error {
-	if err := perunio.Encode(w, acc.SessID); err != nil {
+	if err := perunio.Encode(w, acc.SessID, acc.Backend); err != nil {
 		return errors.WithMessage(err, "SID encoding")
 	}
 
@@ -232,11 +249,11 @@ func (acc ChannelProposalAcc) Encode(w io.Writer) error {
 
 // Decode decodes a ChannelProposalAcc from an io.Reader.
 func (acc *ChannelProposalAcc) Decode(r io.Reader) (err error) {
-	if err = perunio.Decode(r, &acc.SessID); err != nil {
+	if err = perunio.Decode(r, &acc.SessID, &acc.Backend); err != nil {
 		return errors.WithMessage(err, "SID decoding")
 	}
 
-	acc.ParticipantAddr, err = wallet.DecodeAddress(r)
+	acc.ParticipantAddr, err = wallet.DecodeAddressOf(acc.Backend, r)
 	return errors.WithMessage(err, "participant address decoding")
 }
 
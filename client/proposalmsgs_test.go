@@ -7,6 +7,7 @@ package client_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
 	"math/rand"
 	"testing"
@@ -19,6 +20,7 @@ import (
 	"perun.network/go-perun/client"
 	"perun.network/go-perun/pkg/io"
 	perunio "perun.network/go-perun/pkg/io"
+	iotest "perun.network/go-perun/pkg/io/test"
 	"perun.network/go-perun/wallet"
 	wallettest "perun.network/go-perun/wallet/test"
 	"perun.network/go-perun/wire"
@@ -67,6 +69,37 @@ func TestChannelProposalReqSerialization(t *testing.T) {
 	}
 }
 
+func TestChannelProposalReqJSON(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x150))
+	for i := 0; i < 4; i++ {
+		m := &client.ChannelProposal{
+			ChallengeDuration: uint64(rng.Int63()),
+			Nonce:             big.NewInt(rng.Int63()),
+			ParticipantAddr:   wallettest.NewRandomAddress(rng),
+			AppDef:            test.NewRandomApp(rng).Def(),
+			InitData:          test.NewRandomData(rng),
+			InitBals:          test.NewRandomAllocation(rng, test.WithNumParts(2)),
+			PeerAddrs: []wallet.Address{
+				wallettest.NewRandomAddress(rng),
+				wallettest.NewRandomAddress(rng),
+			},
+		}
+		iotest.GenericJSONTest(t, m)
+	}
+
+	m := client.ChannelProposal{
+		Nonce:           big.NewInt(1),
+		ParticipantAddr: wallettest.NewRandomAddress(rng),
+		AppDef:          test.NewRandomApp(rng).Def(),
+		InitData:        test.NewRandomData(rng),
+		InitBals:        test.NewRandomAllocation(rng, test.WithNumParts(2)),
+		PeerAddrs:       []wallet.Address{wallettest.NewRandomAddress(rng)},
+	}
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.Error(t, json.Unmarshal(data, new(client.ChannelProposal)), "single peer")
+}
+
 func TestChannelProposalReqDecode_CheckMaxNumParts(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
@@ -77,9 +110,10 @@ func TestChannelProposalReqDecode_CheckMaxNumParts(t *testing.T) {
 
 	// reimplementation of ChannelProposalReq.Encode modified to create the
 	// maximum number of participants possible with the encoding
-	require.NoError(perunio.Encode(buffer, c.ChallengeDuration, c.Nonce))
-	require.NoError(
-		io.Encode(buffer, c.ParticipantAddr, c.AppDef, c.InitData, c.InitBals))
+	require.NoError(perunio.Encode(buffer, c.Backend, c.ChallengeDuration, c.Nonce))
+	require.NoError(io.Encode(buffer, c.ParticipantAddr, c.AppDef))
+	require.NoError(channel.EncodeData(buffer, c.InitData))
+	require.NoError(io.Encode(buffer, c.InitBals))
 
 	numParts := int32(channel.MaxNumParts + 1)
 	require.NoError(perunio.Encode(buffer, numParts))
@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/wire"
+)
+
+// reasonAborted is the rejection reason that is sent to peers whose proposals
+// are aborted after a restart.
+const reasonAborted = "proposal aborted after restart"
+
+// persistProposal persists the stage of an in-flight proposal if the
+// PersistRestorer is a ProposalPersister. The proposal is persisted until its
+// channel is persisted or the proposal fails.
+func (c *Client) persistProposal(
+	ctx context.Context,
+	sessID SessionID,
+	role persistence.ProposalRole,
+	stage persistence.ProposalStage,
+	peer wire.Address,
+) error {
+	pp, ok := c.pr.(persistence.ProposalPersister)
+	if !ok {
+		return nil
+	}
+	prop := &persistence.Proposal{SessID: sessID, Role: role, Stage: stage, Peer: peer}
+	return errors.WithMessage(pp.ProposalUpdated(ctx, prop), "persisting proposal")
+}
+
+// removeProposal removes a persisted proposal. Errors are only logged because
+// a stale proposal is aborted after the next restart anyways.
+func (c *Client) removeProposal(ctx context.Context, sessID SessionID) {
+	pp, ok := c.pr.(persistence.ProposalPersister)
+	if !ok {
+		return
+	}
+	if err := pp.ProposalRemoved(ctx, sessID); err != nil {
+		c.log.Warnf("Failed to remove persisted proposal %x: %v", sessID, err)
+	}
+}
+
+// restoreProposals loads the proposals that were in flight when the client
+// was shut down. They are aborted once their peers connect.
+func (c *Client) restoreProposals(ctx context.Context) error {
+	pp, ok := c.pr.(persistence.ProposalPersister)
+	if !ok {
+		return nil
+	}
+	props, err := pp.RestoreProposals(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "restoring proposals")
+	}
+
+	c.staleMtx.Lock()
+	defer c.staleMtx.Unlock()
+	c.staleProps = append(c.staleProps, props...)
+	return nil
+}
+
+// stalePeers returns the peers of all stale proposals.
+func (c *Client) stalePeers() []wire.Address {
+	c.staleMtx.Lock()
+	defer c.staleMtx.Unlock()
+
+	peers := make([]wire.Address, len(c.staleProps))
+	for i, prop := range c.staleProps {
+		peers[i] = prop.Peer
+	}
+	return peers
+}
+
+// takeStaleProposals removes and returns the stale proposals with the given
+// peer.
+func (c *Client) takeStaleProposals(peer wire.Address) (props []*persistence.Proposal) {
+	c.staleMtx.Lock()
+	defer c.staleMtx.Unlock()
+
+	rest := c.staleProps[:0]
+	for _, prop := range c.staleProps {
+		if prop.Peer.Equals(peer) {
+			props = append(props, prop)
+		} else {
+			rest = append(rest, prop)
+		}
+	}
+	c.staleProps = rest
+	return props
+}
+
+// abortProposals aborts all stale proposals with the given peer by sending a
+// rejection. The peer then stops setting up the proposed channel, in case it
+// is still waiting for us.
+func (c *Client) abortProposals(p *wire.Endpoint) {
+	for _, prop := range c.takeStaleProposals(p.PerunAddress) {
+		log := c.logPeer(p).WithField("session", prop.SessID)
+		rej := &ChannelProposalRej{SessID: prop.SessID, Reason: reasonAborted}
+		if err := p.Send(c.Ctx(), rej); err != nil {
+			log.Warnf("Failed to abort proposal: %v", err)
+			continue
+		}
+		c.removeProposal(c.Ctx(), prop.SessID)
+		log.Infof("Aborted proposal as %v in stage %v.", prop.Role, prop.Stage)
+	}
+}
+
+// enableAbortCache enables caching of incoming rejections of the given
+// session, so that aborts that arrive before initChannel subscribes to them
+// are not lost.
+func enableAbortCache(ctx context.Context, c wire.Cacher, sessID SessionID) {
+	c.Cache(ctx, isRejOf(sessID))
+}
+
+// isRejOf returns a predicate that matches rejections of the given session.
+func isRejOf(sessID SessionID) wire.Predicate {
+	return func(m wire.Msg) bool {
+		return m.Type() == wire.ChannelProposalRej &&
+			m.(*ChannelProposalRej).SessID == sessID
+	}
+}
+
+// initChannel sets the initial state of a new channel and exchanges the
+// initial signatures. If a peer aborts the proposal in the meantime, e.g.,
+// because it restarted, the setup is cancelled and the channel is removed.
+func (c *Client) initChannel(
+	ctx context.Context,
+	ch *Channel,
+	prop *ChannelProposal,
+	peers []*wire.Endpoint,
+) error {
+	initCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	aborts := wire.NewReceiver()
+	defer aborts.Close()
+	for _, p := range peers {
+		if err := p.Subscribe(aborts, isRejOf(prop.SessID())); err != nil {
+			return errors.WithMessagef(err, "subscribing peer %v", p)
+		}
+	}
+	aborted := make(chan *ChannelProposalRej, 1)
+	go func() {
+		if _, m := aborts.Next(initCtx); m != nil {
+			aborted <- m.(*ChannelProposalRej)
+			cancel()
+		}
+	}()
+
+	err := ch.init(initCtx, prop.InitBals, prop.InitData)
+	if err != nil {
+		err = errors.WithMessage(err, "setting initial bals and data")
+	} else if err = ch.initExchangeSigsAndEnable(initCtx); err != nil {
+		err = errors.WithMessage(err, "exchanging initial sigs and enabling state")
+	}
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case rej := <-aborted:
+		ch.Close()
+		if err := c.pr.ChannelRemoved(ctx, ch.ID()); err != nil {
+			ch.log.Warnf("Failed to remove aborted channel: %v", err)
+		}
+		return errors.Errorf("channel proposal aborted by peer: %s", rej.Reason)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/channel/persistence"
+	chprtest "perun.network/go-perun/channel/persistence/test"
+	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wiretest "perun.network/go-perun/wire/test"
+)
+
+func TestClient_AbortStaleProposals(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xAB047))
+	nodeID, peerID := wtest.NewRandomAccount(rng), wtest.NewRandomAccount(rng)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	pr := chprtest.NewPersistRestorer(t)
+	stale := &persistence.Proposal{
+		Role:  persistence.Proposer,
+		Stage: persistence.ProposalSent,
+		Peer:  peerID.Address(),
+	}
+	rng.Read(stale.SessID[:])
+	require.NoError(t, pr.ProposalUpdated(ctx, stale))
+
+	hub := new(wiretest.ConnHub)
+	c := New(nodeID, &DummyDialer{t}, &DummyFunder{t}, &DummyAdjudicator{t}, wtest.RandomWallet())
+	defer c.Close()
+	c.EnablePersistence(pr)
+	require.NoError(t, c.Restore(ctx))
+	go c.Listen(hub.NewNetListener(nodeID.Address()))
+
+	conn, err := hub.NewNetDialer().Dial(ctx, nodeID.Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = wire.ExchangeAddrs(ctx, peerID, conn)
+	require.NoError(t, err)
+
+	m, err := conn.Recv()
+	require.NoError(t, err)
+	require.IsType(t, (*ChannelProposalRej)(nil), m)
+	assert.Equal(t, stale.SessID, m.(*ChannelProposalRej).SessID)
+	assert.Equal(t, reasonAborted, m.(*ChannelProposalRej).Reason)
+
+	test.Within1s.Eventually(t, func(t test.T) {
+		props, err := pr.RestoreProposals(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, props, "aborted proposal should be removed")
+	})
+}
+
+func TestClient_ProposalAbortedByPeer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xAB048))
+	nodeID, peerID := wtest.NewRandomAccount(rng), wtest.NewRandomAccount(rng)
+	prop := NewRandomChannelProposalReqNumParts(rng, 2)
+	prop.PeerAddrs = []wallet.Address{peerID.Address(), nodeID.Address()}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	pr := chprtest.NewPersistRestorer(t)
+	hub := new(wiretest.ConnHub)
+	c := New(nodeID, hub.NewNetDialer(), &DummyFunder{t}, &DummyAdjudicator{t}, wtest.RandomWallet())
+	defer c.Close()
+	c.EnablePersistence(pr)
+	go c.Listen(hub.NewNetListener(nodeID.Address()))
+	accepted := make(chan error, 1)
+	go c.Handle(
+		ProposalHandlerFunc(func(_ *ChannelProposal, r *ProposalResponder) {
+			_, err := r.Accept(ctx, ProposalAcc{Participant: wtest.NewRandomAccount(rng).Address()})
+			accepted <- err
+		}),
+		UpdateHandlerFunc(func(ChannelUpdate, *UpdateResponder) {}))
+
+	conn, err := hub.NewNetDialer().Dial(ctx, nodeID.Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = wire.ExchangeAddrs(ctx, peerID, conn)
+	require.NoError(t, err)
+	require.NoError(t, conn.Send(prop))
+	m, err := conn.Recv()
+	require.NoError(t, err)
+	require.Equal(t, wire.ChannelProposalAcc, m.Type())
+
+	// The peer restarts before it persisted the channel and aborts.
+	require.NoError(t, conn.Send(&ChannelProposalRej{SessID: prop.SessID(), Reason: reasonAborted}))
+	select {
+	case err := <-accepted:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "aborted by peer")
+	case <-ctx.Done():
+		t.Fatal("proposal not aborted")
+	}
+
+	props, err := pr.RestoreProposals(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, props)
+	it, err := pr.RestoreAll()
+	require.NoError(t, err)
+	assert.False(t, it.Next(ctx), "aborted channel should be removed")
+}
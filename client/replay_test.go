@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/wallet"
+	wtest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+	wiretest "perun.network/go-perun/wire/test"
+)
+
+// TestClient_RecordReplay records a session in which a peer proposes a channel
+// to a client, and then replays it into a fresh client with the same identity.
+func TestClient_RecordReplay(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7ec0))
+	nodeID, peerID := wtest.NewRandomAccount(rng), wtest.NewRandomAccount(rng)
+	prop := NewRandomChannelProposalReqNumParts(rng, 2)
+	prop.PeerAddrs = []wallet.Address{peerID.Address(), nodeID.Address()}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// newClient creates a client that listens on the hub and rejects all
+	// proposals. Rejected proposals are passed to the proposals channel.
+	newClient := func(listener wire.Listener) (*Client, chan *ChannelProposal) {
+		c := New(nodeID, &DummyDialer{t}, &DummyFunder{t}, &DummyAdjudicator{t}, wtest.RandomWallet())
+		proposals := make(chan *ChannelProposal, 1)
+		go c.Listen(listener)
+		go c.Handle(
+			ProposalHandlerFunc(func(p *ChannelProposal, r *ProposalResponder) {
+				// The replay may close the connection before Reject returns.
+				r.Reject(ctx, "no thanks") // nolint:errcheck
+				proposals <- p
+			}),
+			UpdateHandlerFunc(func(ChannelUpdate, *UpdateResponder) {}))
+		return c, proposals
+	}
+
+	// Record the original session.
+	var file bytes.Buffer
+	hub := new(wiretest.ConnHub)
+	rec := wire.NewRecorder(&file)
+	c, proposals := newClient(wire.NewRecordingListener(
+		hub.NewNetListener(nodeID.Address()), nodeID.Address(), rec))
+
+	conn, err := hub.NewNetDialer().Dial(ctx, nodeID.Address())
+	require.NoError(t, err)
+	_, err = wire.ExchangeAddrs(ctx, peerID, conn)
+	require.NoError(t, err)
+	require.NoError(t, conn.Send(prop))
+	m, err := conn.Recv()
+	require.NoError(t, err)
+	require.Equal(t, wire.ChannelProposalRej, m.Type())
+	<-proposals // Wait until the rejection is recorded.
+	require.NoError(t, c.Close())
+
+	records, err := wire.ReadRecords(&file)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	// Replay the session into a fresh client.
+	hub2 := new(wiretest.ConnHub)
+	c2, proposals2 := newClient(hub2.NewNetListener(nodeID.Address()))
+	defer c2.Close()
+	received, err := wiretest.Replay(ctx, hub2.NewNetDialer(), nodeID.Address(), peerID.Address(), records)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	assert.Equal(t, wire.ChannelProposalRej, received[1].Type())
+	assert.Equal(t, prop.SessID(), (<-proposals2).SessID())
+}
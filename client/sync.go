@@ -33,14 +33,33 @@ func (c *Client) restorePeerChannels(p *wire.Endpoint, done func()) {
 			defer wg.Done()
 			log := c.logChan(chdata.ID())
 			log.Debug("Restoring channel...")
+			// Channels that were restored without peers are replaced as long as
+			// they are not disputed. Closing the old channel stops its watcher,
+			// so the new channel is watched instead.
+			var replaced bool
+			if old, ok := c.channels.Get(chdata.ID()); ok &&
+				old.conn.isOffline() && old.Phase() == channel.Acting {
+				replaced = true
+				old.Close()
+				old.wallet.DecrementUsage(old.machine.Account().Address())
+				var err error
+				if chdata, err = c.pr.RestoreChannel(c.Ctx(), chdata.ID()); err != nil {
+					log.Errorf("Failed to reload channel: %v", err)
+					return
+				}
+			}
 			// Synchronize the channel with the peer, and settle if this fails.
 			if err := c.syncChannel(c.Ctx(), chdata, p); err != nil {
 				log.Errorf("Error synchronizing channels: %v; attempting settlement...", err)
 				chdata.PhaseV = channel.Withdrawing
 				// No peers, because we don't want any connections.
-				if ch, err := c.channelFromSource(chdata); err != nil {
+				ch, err := c.channelFromSource(chdata)
+				if err != nil {
 					log.Errorf("Failed to reconstruct channel for settling: %v", err)
-				} else if err := ch.Settle(c.Ctx()); err != nil {
+					return
+				}
+				ch.wallet.IncrementUsage(ch.machine.Account().Address())
+				if err := ch.Settle(c.Ctx()); err != nil {
 					log.Errorf("Failed to settle channel: %v", err)
 				}
 				return
@@ -60,7 +79,11 @@ func (c *Client) restorePeerChannels(p *wire.Endpoint, done func()) {
 				// If the channel already existed, close this one.
 				ch.Close()
 			} else {
+				ch.wallet.IncrementUsage(ch.machine.Account().Address())
 				log.Info("Channel restored.")
+				if replaced {
+					c.watch(ch)
+				}
 			}
 		}()
 	}
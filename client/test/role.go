@@ -20,6 +20,7 @@ import (
 	"perun.network/go-perun/channel/persistence"
 	"perun.network/go-perun/client"
 	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
 	wallettest "perun.network/go-perun/wallet/test"
 	"perun.network/go-perun/wire"
 )
@@ -226,6 +227,7 @@ func (r *role) ChannelProposal(rng *rand.Rand, cfg *ExecConfig) *client.ChannelP
 		Balances: [][]channel.Bal{cfg.InitBals[:]},
 	}
 	return &client.ChannelProposal{
+		Backend:           wallet.DefaultBackend(),
 		ChallengeDuration: 60, // 60 sec
 		Nonce:             big.NewInt(rng.Int63()),
 		ParticipantAddr:   r.setup.Wallet.NewRandomAccount(rng).Address(),
@@ -250,6 +250,7 @@ func (c *Channel) handleUpdateAcc(
 
 	msgUpAcc := &msgChannelUpdateAcc{
 		ChannelID: c.ID(),
+		Backend:   c.Params().Backend,
 		Version:   req.State.Version,
 		Sig:       sig,
 	}
@@ -60,6 +60,9 @@ type (
 	msgChannelUpdateAcc struct {
 		// ChannelID is the channel ID.
 		ChannelID channel.ID
+		// Backend is the ID of the channel's backend, which is needed to
+		// decode the signature.
+		Backend wallet.BackendID
 		// Version of the state that is accepted.
 		Version uint64
 		// Sig is the signature on the proposed new state by the sender.
@@ -111,19 +114,19 @@ func (c *msgChannelUpdate) Decode(r io.Reader) (err error) {
 	if err := perunio.Decode(r, c.State, &c.ActorIdx); err != nil {
 		return err
 	}
-	c.Sig, err = wallet.DecodeSig(r)
+	c.Sig, err = wallet.DecodeSigOf(c.State.Backend, r)
 	return err
 }
 
 func (c msgChannelUpdateAcc) Encode(w io.Writer) error {
-	return perunio.Encode(w, c.ChannelID, c.Version, c.Sig)
+	return perunio.Encode(w, c.ChannelID, c.Backend, c.Version, c.Sig)
 }
 
 func (c *msgChannelUpdateAcc) Decode(r io.Reader) (err error) {
-	if err := perunio.Decode(r, &c.ChannelID, &c.Version); err != nil {
+	if err := perunio.Decode(r, &c.ChannelID, &c.Backend, &c.Version); err != nil {
 		return err
 	}
-	c.Sig, err = wallet.DecodeSig(r)
+	c.Sig, err = wallet.DecodeSigOf(c.Backend, r)
 	return err
 }
 
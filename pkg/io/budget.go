@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package io
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// A Lener knows how many unread bytes it contains, like bytes.Reader.
+type Lener interface {
+	Len() int
+}
+
+// CheckRemaining checks that a reader can still contain n more elements
+// before a decoder allocates memory for them. Every encoded element is assumed
+// to take at least one byte, so decoding budgets are enforced by the size of
+// the data to decode: If r is a Lener holding fewer than n unread bytes, an
+// error is returned. For other readers, the check always succeeds.
+//
+// Decoders must call CheckRemaining with attacker-supplied counts before
+// allocating slices of that size.
+func CheckRemaining(r io.Reader, n int) error {
+	l, ok := r.(Lener)
+	if !ok {
+		return nil
+	}
+	if n < 0 || n > l.Len() {
+		return errors.Errorf("decoding budget exceeded: %d elements announced but only %d bytes left", n, l.Len())
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package io_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+func TestCheckRemaining(t *testing.T) {
+	r := bytes.NewReader(make([]byte, 8))
+	assert.NoError(t, perunio.CheckRemaining(r, 0))
+	assert.NoError(t, perunio.CheckRemaining(r, 8))
+	assert.Error(t, perunio.CheckRemaining(r, 9))
+	assert.Error(t, perunio.CheckRemaining(r, -1))
+
+	// Readers of unknown length are not checked.
+	pr, _ := io.Pipe()
+	assert.NoError(t, perunio.CheckRemaining(pr, 1<<30))
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package io
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// hexPrefix is the prefix of hex encodings.
+const hexPrefix = "0x"
+
+// EncodeHex returns the encoding of e as a hex string with prefix "0x". It is
+// used for the JSON encoding of values whose decoding depends on a backend,
+// e.g., addresses and assets.
+func EncodeHex(e Encoder) (string, error) {
+	var buf bytes.Buffer
+	if err := e.Encode(&buf); err != nil {
+		return "", err
+	}
+	return hexPrefix + hex.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeHex decodes the hex string s, which must have prefix "0x", with dec.
+// It returns an error if dec does not read the whole encoding.
+func DecodeHex(s string, dec func(io.Reader) error) error {
+	if !strings.HasPrefix(s, hexPrefix) {
+		return errors.Errorf("hex string %q does not start with %s", s, hexPrefix)
+	}
+	b, err := hex.DecodeString(s[len(hexPrefix):])
+	if err != nil {
+		return errors.Wrap(err, "decoding hex string")
+	}
+	r := bytes.NewReader(b)
+	if err := dec(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return errors.Errorf("%d trailing bytes in hex string", r.Len())
+	}
+	return nil
+}
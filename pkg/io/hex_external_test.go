@@ -0,0 +1,35 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package io_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+func TestHex(t *testing.T) {
+	s, err := perunio.EncodeHex(perunio.ByteSlice{0x01, 0xab, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, "0x01abff", s)
+
+	decoded := make(perunio.ByteSlice, 3)
+	require.NoError(t, perunio.DecodeHex(s, decoded.Decode))
+	assert.Equal(t, perunio.ByteSlice{0x01, 0xab, 0xff}, decoded)
+
+	s, err = perunio.EncodeHex(perunio.ByteSlice{})
+	require.NoError(t, err)
+	assert.Equal(t, "0x", s)
+
+	short := make(perunio.ByteSlice, 2)
+	assert.Error(t, perunio.DecodeHex("0x01abff", short.Decode), "trailing bytes")
+	assert.Error(t, perunio.DecodeHex("0x01ab", decoded.Decode), "too short")
+	assert.Error(t, perunio.DecodeHex("01abff", decoded.Decode), "missing prefix")
+	assert.Error(t, perunio.DecodeHex("0x01abfg", decoded.Decode), "invalid hex")
+}
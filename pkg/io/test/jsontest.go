@@ -0,0 +1,34 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// GenericJSONTest tests whether marshaling and then unmarshaling the values
+// to and from JSON results in the original values. The values must be
+// pointers.
+func GenericJSONTest(t *testing.T, values ...interface{}) {
+	for i, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("failed to marshal %dth element (%T): %+v", i, v, err)
+			continue
+		}
+
+		dest := reflect.New(reflect.TypeOf(v).Elem())
+		if err := json.Unmarshal(data, dest.Interface()); err != nil {
+			t.Errorf("failed to unmarshal %dth element (%T) from %s: %+v", i, v, data, err)
+		} else if !reflect.DeepEqual(v, dest.Interface()) {
+			t.Errorf(
+				"marshaling and unmarshaling the %dth element (%T) resulted in different value: %v, %v",
+				i, v, reflect.ValueOf(v).Elem(), dest.Elem())
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package encrypted
+
+import "perun.network/go-perun/pkg/sortedkv"
+
+// Batch is a batch of the underlying database that encrypts its values.
+type Batch struct {
+	sortedkv.Batch
+	db *Database
+}
+
+// Put encrypts the value and puts it into the batch.
+func (b *Batch) Put(key string, value string) error {
+	return b.PutBytes(key, []byte(value))
+}
+
+// PutBytes encrypts the value and puts it into the batch.
+func (b *Batch) PutBytes(key string, value []byte) error {
+	enc, err := b.db.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return b.Batch.PutBytes(key, enc)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// KeySize is the size of the secret of a Key.
+const KeySize = 32
+
+// headerLen is the length of the key ID in front of every encrypted value.
+const headerLen = 4
+
+// A Key is a secret used to encrypt values. Every value is tagged with the ID
+// of the key it was encrypted with so that multiple keys can be used at the
+// same time during key rotation.
+type Key struct {
+	ID     uint32
+	Secret [KeySize]byte
+}
+
+var _ sortedkv.Database = (*Database)(nil)
+
+// Database is a sortedkv.Database that encrypts all values before writing
+// them to the wrapped database. Each value is authenticated together with its
+// key so that values cannot be swapped between keys.
+type Database struct {
+	db      sortedkv.Database
+	aeads   map[uint32]cipher.AEAD
+	current uint32 // ID of the key used for encryption.
+}
+
+// NewDatabase wraps the given database. New values are encrypted with the
+// first key, all keys can be used for decryption. To rotate keys, pass the new
+// key first and the old keys after it, and then call ReEncrypt. Afterwards,
+// the old keys are no longer needed.
+func NewDatabase(db sortedkv.Database, keys ...Key) (*Database, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key given")
+	}
+	d := &Database{
+		db:      db,
+		aeads:   make(map[uint32]cipher.AEAD, len(keys)),
+		current: keys[0].ID,
+	}
+	for _, key := range keys {
+		if _, ok := d.aeads[key.ID]; ok {
+			return nil, errors.Errorf("duplicate key ID %d", key.ID)
+		}
+		block, err := aes.NewCipher(key.Secret[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "creating cipher")
+		}
+		if d.aeads[key.ID], err = cipher.NewGCM(block); err != nil {
+			return nil, errors.Wrap(err, "creating GCM")
+		}
+	}
+	return d, nil
+}
+
+// encrypt encrypts the value of the given key with the current key. The
+// result consists of the key ID, the nonce and the sealed value.
+func (d *Database) encrypt(key string, value []byte) ([]byte, error) {
+	aead := d.aeads[d.current]
+	out := make([]byte, headerLen+aead.NonceSize(), headerLen+aead.NonceSize()+len(value)+aead.Overhead())
+	binary.LittleEndian.PutUint32(out, d.current)
+	nonce := out[headerLen:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+	return aead.Seal(out, nonce, value, []byte(key)), nil
+}
+
+// decrypt decrypts and authenticates the value of the given key.
+func (d *Database) decrypt(key string, value []byte) ([]byte, error) {
+	if len(value) < headerLen {
+		return nil, errors.Errorf("value of %q too short", key)
+	}
+	id := binary.LittleEndian.Uint32(value)
+	aead, ok := d.aeads[id]
+	if !ok {
+		return nil, errors.Errorf("value of %q encrypted with unknown key %d", key, id)
+	}
+	value = value[headerLen:]
+	if len(value) < aead.NonceSize() {
+		return nil, errors.Errorf("value of %q too short", key)
+	}
+	nonce, sealed := value[:aead.NonceSize()], value[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(key))
+	return plain, errors.Wrapf(err, "decrypting value of %q", key)
+}
+
+// keyID returns the ID of the key that an encrypted value was encrypted with.
+func keyID(value []byte) (uint32, bool) {
+	if len(value) < headerLen {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(value), true
+}
+
+// Has returns true iff the database contains a key.
+func (d *Database) Has(key string) (bool, error) {
+	return d.db.Has(key)
+}
+
+// Get returns the decrypted value as string for given key if it is present
+// in the store.
+func (d *Database) Get(key string) (string, error) {
+	val, err := d.GetBytes(key)
+	return string(val), err
+}
+
+// GetBytes returns the decrypted value as []byte for given key if it is
+// present in the store.
+func (d *Database) GetBytes(key string) ([]byte, error) {
+	val, err := d.db.GetBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	return d.decrypt(key, val)
+}
+
+// Put encrypts the given value and inserts it into the key-value store.
+func (d *Database) Put(key string, value string) error {
+	return d.PutBytes(key, []byte(value))
+}
+
+// PutBytes encrypts the given value and inserts it into the key-value store.
+func (d *Database) PutBytes(key string, value []byte) error {
+	enc, err := d.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return d.db.PutBytes(key, enc)
+}
+
+// Delete removes the key from the key-value store.
+func (d *Database) Delete(key string) error {
+	return d.db.Delete(key)
+}
+
+// NewBatch creates a new batch that encrypts the values put into it.
+func (d *Database) NewBatch() sortedkv.Batch {
+	return &Batch{Batch: d.db.NewBatch(), db: d}
+}
+
+// NewIterator creates a new iterator that decrypts all values.
+func (d *Database) NewIterator() sortedkv.Iterator {
+	return &Iterator{Iterator: d.db.NewIterator(), db: d}
+}
+
+// NewIteratorWithRange creates a new iterator based on a given range that
+// decrypts all values.
+func (d *Database) NewIteratorWithRange(start string, end string) sortedkv.Iterator {
+	return &Iterator{Iterator: d.db.NewIteratorWithRange(start, end), db: d}
+}
+
+// NewIteratorWithPrefix creates a new iterator for a given prefix that
+// decrypts all values.
+func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
+	return &Iterator{Iterator: d.db.NewIteratorWithPrefix(prefix), db: d}
+}
+
+// Close closes the underlying database.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// ReEncrypt re-encrypts all values that were not encrypted with the current
+// key and returns their number. After ReEncrypt, only the current key is needed
+// to read the database. It should not be called concurrently with writes.
+func (d *Database) ReEncrypt() (int, error) {
+	it := d.db.NewIterator()
+	batch := d.NewBatch()
+	n := 0
+	for it.Next() {
+		val := it.ValueBytes()
+		if id, ok := keyID(val); ok && id == d.current {
+			continue
+		}
+		plain, err := d.decrypt(it.Key(), val)
+		if err != nil {
+			it.Close()
+			return 0, err
+		}
+		if err := batch.PutBytes(it.Key(), plain); err != nil {
+			it.Close()
+			return 0, err
+		}
+		n++
+	}
+	if err := it.Close(); err != nil {
+		return 0, errors.WithMessage(err, "iterating database")
+	}
+	return n, errors.WithMessage(batch.Apply(), "applying batch")
+}
@@ -0,0 +1,9 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package encrypted implements a key-value database decorator that encrypts
+// all values with AES-256-GCM before they are written to the underlying
+// database. Keys are stored in plaintext so that the database stays sorted.
+package encrypted // import "perun.network/go-perun/pkg/sortedkv/encrypted"
@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package encrypted
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+	"perun.network/go-perun/pkg/sortedkv/test"
+)
+
+func newRandomKey(rng *rand.Rand, id uint32) (key Key) {
+	key.ID = id
+	rng.Read(key.Secret[:])
+	return
+}
+
+func newTestDatabase(t *testing.T, db sortedkv.Database, keys ...Key) *Database {
+	enc, err := NewDatabase(db, keys...)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestDatabase_Generic(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xe7c))
+	key := newRandomKey(rng, 1)
+	t.Run("Database", func(t *testing.T) {
+		test.GenericDatabaseTest(t, newTestDatabase(t, memorydb.NewDatabase(), key))
+	})
+	t.Run("Batch", func(t *testing.T) {
+		test.GenericBatchTest(t, newTestDatabase(t, memorydb.NewDatabase(), key))
+	})
+	t.Run("Iterator", func(t *testing.T) {
+		test.GenericIteratorTest(t, newTestDatabase(t, memorydb.NewDatabase(), key))
+	})
+}
+
+func TestNewDatabase(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xe7d))
+	_, err := NewDatabase(memorydb.NewDatabase())
+	assert.Error(t, err, "no key")
+	_, err = NewDatabase(memorydb.NewDatabase(), newRandomKey(rng, 1), newRandomKey(rng, 1))
+	assert.Error(t, err, "duplicate key ID")
+}
+
+func TestDatabase_Encrypted(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xe7e))
+	raw := memorydb.NewDatabase()
+	db := newTestDatabase(t, raw, newRandomKey(rng, 1))
+
+	const value = "secret channel state"
+	require.NoError(t, db.Put("a", value))
+	require.NoError(t, db.Put("b", value))
+	stored, err := raw.Get("a")
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(stored, value), "value stored in plaintext")
+	other, err := raw.Get("b")
+	require.NoError(t, err)
+	assert.NotEqual(t, stored, other, "nonce reused")
+
+	// Values are bound to their keys.
+	require.NoError(t, raw.Put("b", stored))
+	_, err = db.Get("b")
+	assert.Error(t, err, "swapped value")
+
+	// Tampered values are detected.
+	tampered := []byte(stored)
+	tampered[len(tampered)-1] ^= 1
+	require.NoError(t, raw.PutBytes("a", tampered))
+	_, err = db.Get("a")
+	assert.Error(t, err, "tampered value")
+
+	it := db.NewIterator()
+	assert.False(t, it.Next())
+	assert.Error(t, it.Close())
+
+	// Wrong keys cannot decrypt.
+	require.NoError(t, db.Put("a", value))
+	wrong := newTestDatabase(t, raw, newRandomKey(rng, 1))
+	_, err = wrong.Get("a")
+	assert.Error(t, err, "wrong key")
+	unknown := newTestDatabase(t, raw, newRandomKey(rng, 2))
+	_, err = unknown.Get("a")
+	assert.Error(t, err, "unknown key")
+}
+
+func TestDatabase_ReEncrypt(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xe7f))
+	raw := memorydb.NewDatabase()
+	oldKey, newKey := newRandomKey(rng, 1), newRandomKey(rng, 2)
+	db := newTestDatabase(t, raw, oldKey)
+	require.NoError(t, db.Put("a", "1"))
+	require.NoError(t, db.Put("b", "2"))
+
+	rotated := newTestDatabase(t, raw, newKey, oldKey)
+	require.NoError(t, rotated.Put("c", "3"))
+	n, err := rotated.ReEncrypt()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	onlyNew := newTestDatabase(t, raw, newKey)
+	dbtest := test.DatabaseTest{T: t, Database: onlyNew}
+	dbtest.MustGetEqual("a", "1")
+	dbtest.MustGetEqual("b", "2")
+	dbtest.MustGetEqual("c", "3")
+
+	n, err = onlyNew.ReEncrypt()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
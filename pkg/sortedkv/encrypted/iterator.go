@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package encrypted
+
+import "perun.network/go-perun/pkg/sortedkv"
+
+// Iterator is an iterator of the underlying database that decrypts its
+// values. If a value cannot be decrypted, the iteration stops and Close
+// returns the error.
+type Iterator struct {
+	sortedkv.Iterator
+	db    *Database
+	value []byte
+	err   error
+}
+
+// Next moves the iterator to the next key/value pair and decrypts the value.
+func (it *Iterator) Next() bool {
+	it.value = nil
+	if it.err != nil || !it.Iterator.Next() {
+		return false
+	}
+	it.value, it.err = it.db.decrypt(it.Iterator.Key(), it.Iterator.ValueBytes())
+	return it.err == nil
+}
+
+// Value returns the decrypted value of the current key/value pair, or "" if
+// done.
+func (it *Iterator) Value() string {
+	return string(it.value)
+}
+
+// ValueBytes returns the decrypted value of the current key/value pair, or nil
+// if done.
+func (it *Iterator) ValueBytes() []byte {
+	return it.value
+}
+
+// Close releases associated resources. It returns any accumulated error,
+// including decryption errors.
+func (it *Iterator) Close() error {
+	err := it.Iterator.Close()
+	if it.err != nil {
+		return it.err
+	}
+	return err
+}
@@ -10,19 +10,36 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"perun.network/go-perun/pkg/sortedkv"
 )
 
-// Database implements the Database interface and stores the values in memory.
+// Database implements the Database interface and stores the values in a
+// LevelDB database on disk.
 type Database struct {
 	*leveldb.DB
-	path string
+	path      string
+	writeOpts *opt.WriteOptions
 }
 
-// LoadDatabase creates a new, empty Database.
+// Options configure a Database.
+type Options struct {
+	// Sync makes every write, including batches, wait until the data is synced
+	// to disk. Without Sync, the last writes may be lost if the machine
+	// crashes. Writes are never lost if only the process crashes.
+	Sync bool
+}
+
+// LoadDatabase opens or creates the Database at the given path.
 func LoadDatabase(path string) (*Database, error) {
+	return LoadDatabaseWithOptions(path, Options{})
+}
+
+// LoadDatabaseWithOptions opens or creates the Database at the given path
+// with the given options.
+func LoadDatabaseWithOptions(path string, opts Options) (*Database, error) {
 	db, err := leveldb.OpenFile(path, nil)
 
 	if err != nil {
@@ -30,8 +47,9 @@ func LoadDatabase(path string) (*Database, error) {
 	}
 
 	return &Database{
-		db,
-		path,
+		DB:        db,
+		path:      path,
+		writeOpts: &opt.WriteOptions{Sync: opts.Sync},
 	}, nil
 }
 
@@ -66,7 +84,7 @@ func (d *Database) Put(key string, value string) error {
 // PutBytes inserts the given value into the key-value store.
 // If the key is already present, it is overwritten and no error is returned.
 func (d *Database) PutBytes(key string, value []byte) error {
-	err := d.DB.Put([]byte(key), value, nil)
+	err := d.DB.Put([]byte(key), value, d.writeOpts)
 	return errors.Wrap(err, "Database.Put(key, value) error")
 }
 
@@ -83,7 +101,7 @@ func (d *Database) Delete(key string) error {
 		return errors.New("Database.Delete(key) error")
 	}
 
-	err = d.DB.Delete([]byte(key), nil)
+	err = d.DB.Delete([]byte(key), d.writeOpts)
 	return errors.Wrap(err, "Database.Delete(key) error")
 }
 
@@ -91,7 +109,7 @@ func (d *Database) Delete(key string) error {
 
 // NewBatch creates a new batch.
 func (d *Database) NewBatch() sortedkv.Batch {
-	return &Batch{&leveldb.Batch{}, d.DB}
+	return &Batch{&leveldb.Batch{}, d.DB, d.writeOpts}
 }
 
 // Iterateable interface.
@@ -127,3 +145,10 @@ func (d *Database) NewIteratorWithPrefix(prefix string) sortedkv.Iterator {
 
 	return &Iterator{d.DB.NewIterator(slice, nil), sync.Mutex{}}
 }
+
+// Compact compacts the whole key range of the database. Deleted data is only
+// discarded from disk during compaction, so this should be called after many
+// keys got deleted, e.g., after pruning withdrawn channels.
+func (d *Database) Compact() error {
+	return errors.Wrap(d.DB.CompactRange(util.Range{}), "Database.Compact() error")
+}
@@ -37,6 +37,29 @@ func TestIterator(t *testing.T) {
 	})
 }
 
+func TestDatabase_Compact(t *testing.T) {
+	runTestOnTempDatabase(t, func(db *Database) {
+		require.NoError(t, db.Put("key", "value"))
+		require.NoError(t, db.Delete("key"))
+		assert.NoError(t, db.Compact())
+		has, err := db.Has("key")
+		assert.NoError(t, err)
+		assert.False(t, has)
+	})
+}
+
+func TestDatabase_Sync(t *testing.T) {
+	path, err := ioutil.TempDir("", "perun_testdb_")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	db, err := LoadDatabaseWithOptions(path, Options{Sync: true})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, db.Close()) }()
+	assert.True(t, db.writeOpts.Sync)
+	test.GenericBatchTest(t, db)
+}
+
 func runTestOnTempDatabase(t *testing.T, tester func(*Database)) {
 	// Create a temporary directory and delete it when done
 	path, err := ioutil.TempDir("", "perun_testdb_")
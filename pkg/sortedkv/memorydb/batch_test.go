@@ -8,6 +8,9 @@ package memorydb
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"perun.network/go-perun/pkg/sortedkv"
 	"perun.network/go-perun/pkg/sortedkv/test"
 )
@@ -21,3 +24,15 @@ func TestBatch(t *testing.T) {
 		test.GenericBatchTest(t, sortedkv.NewTable(NewDatabase(), "table"))
 	})
 }
+
+func TestBatch_Apply_Atomic(t *testing.T) {
+	db := NewDatabase()
+	dbtest := test.DatabaseTest{T: t, Database: db}
+	dbtest.Put("a", "1")
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Put("a", "2"))
+	require.NoError(t, batch.Delete("missing"))
+	assert.Error(t, batch.Apply())
+	dbtest.MustGetEqual("a", "1")
+}
@@ -12,6 +12,14 @@ type tableBatch struct {
 	prefix string
 }
 
+// NewTableBatch creates a view on a batch in which all keys are prefixed with
+// the given prefix. Writes to multiple tables can be applied atomically by
+// creating table batches on the same underlying batch. Applying or resetting
+// a table batch applies or resets the underlying batch.
+func NewTableBatch(b Batch, prefix string) Batch {
+	return &tableBatch{b, prefix}
+}
+
 func (b *tableBatch) pkey(key string) string {
 	return b.prefix + key
 }
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/pkg/sortedkv"
+)
+
+// ErrCrashed is returned by all writes to a CrashingDatabase after its crash.
+var ErrCrashed = errors.New("database crashed")
+
+// CrashingDatabase is a fault-injecting Database decorator that simulates a
+// crash of the process at a given write. Each Put, PutBytes, Delete and batch
+// Apply is one write that is either fully applied to the underlying Database
+// or not at all, like in a real database. The crashing write and all later
+// writes fail with ErrCrashed. Reads are passed through.
+//
+// To test crash consistency systematically, run a workload once for every
+// possible crash point and restore from the underlying Database after each
+// crash.
+type CrashingDatabase struct {
+	sortedkv.Database
+
+	mutex   sync.Mutex
+	writes  int // Number of successful writes.
+	crashAt int // Index of the write that crashes, or negative.
+}
+
+var _ sortedkv.Database = (*CrashingDatabase)(nil)
+
+// NewCrashingDatabase wraps a Database so that it crashes at the write with
+// index crashAt, that is, after crashAt successful writes. If crashAt is
+// negative, it never crashes, which can be used to count the writes of a
+// workload.
+func NewCrashingDatabase(db sortedkv.Database, crashAt int) *CrashingDatabase {
+	return &CrashingDatabase{Database: db, crashAt: crashAt}
+}
+
+// Writes returns the number of successful writes.
+func (d *CrashingDatabase) Writes() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.writes
+}
+
+// Crashed returns whether the database crashed.
+func (d *CrashingDatabase) Crashed() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.crashed()
+}
+
+func (d *CrashingDatabase) crashed() bool {
+	return d.crashAt >= 0 && d.writes >= d.crashAt
+}
+
+// write performs a write unless the database crashes.
+func (d *CrashingDatabase) write(fn func() error) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.crashed() {
+		return ErrCrashed
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	d.writes++
+	return nil
+}
+
+// Put calls Put on the underlying Database unless it crashes.
+func (d *CrashingDatabase) Put(key, value string) error {
+	return d.write(func() error { return d.Database.Put(key, value) })
+}
+
+// PutBytes calls PutBytes on the underlying Database unless it crashes.
+func (d *CrashingDatabase) PutBytes(key string, value []byte) error {
+	return d.write(func() error { return d.Database.PutBytes(key, value) })
+}
+
+// Delete calls Delete on the underlying Database unless it crashes.
+func (d *CrashingDatabase) Delete(key string) error {
+	return d.write(func() error { return d.Database.Delete(key) })
+}
+
+// NewBatch creates a batch whose Apply is one write.
+func (d *CrashingDatabase) NewBatch() sortedkv.Batch {
+	return &crashingBatch{Batch: d.Database.NewBatch(), db: d}
+}
+
+type crashingBatch struct {
+	sortedkv.Batch
+	db *CrashingDatabase
+}
+
+func (b *crashingBatch) Apply() error {
+	return b.db.write(b.Batch.Apply)
+}
@@ -102,6 +102,9 @@ func (a *AddressesWithLen) Decode(r stdio.Reader) (err error) {
 	if err = io.Decode(r, &parts); err != nil {
 		return errors.WithMessage(err, "decoding count")
 	}
+	if err = io.CheckRemaining(r, int(parts)); err != nil {
+		return errors.WithMessage(err, "decoding addresses")
+	}
 
 	*a = make(AddressesWithLen, parts)
 	return (*Addresses)(a).Decode(r)
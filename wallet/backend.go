@@ -6,12 +6,14 @@
 package wallet
 
 import (
+	"fmt"
 	"io"
-)
+	"sync"
+
+	"github.com/pkg/errors"
 
-// backend is set to the global wallet backend. Must not be set directly but
-// through importing the needed backend.
-var backend Backend
+	perunio "perun.network/go-perun/pkg/io"
+)
 
 // Backend provides useful methods for this blockchain.
 type Backend interface {
@@ -28,26 +30,118 @@ type Backend interface {
 	VerifySignature(msg []byte, sign Sig, a Address) (bool, error)
 }
 
-// SetBackend sets the global wallet backend. Must not be called directly but
-// through importing the needed backend.
-func SetBackend(b Backend) {
-	if backend != nil {
-		panic("wallet backend already set")
+// BackendID identifies a backend. Channels carry the ID of their backend so
+// that several backends can be used in one process.
+type BackendID uint32
+
+// Encode serializes a BackendID.
+func (id BackendID) Encode(w io.Writer) error {
+	return perunio.Encode(w, uint32(id))
+}
+
+// Decode deserializes a BackendID.
+func (id *BackendID) Decode(r io.Reader) error {
+	return perunio.Decode(r, (*uint32)(id))
+}
+
+// backends holds all registered wallet backends. The default backend is used
+// by the functions that are not bound to a channel, e.g., for decoding the
+// addresses of network peers.
+var backends = struct {
+	sync.RWMutex
+	m          map[BackendID]Backend
+	def        BackendID
+	defaultSet bool
+}{m: make(map[BackendID]Backend)}
+
+// RegisterBackend registers a wallet backend under the given ID. Must not be
+// called directly but through importing the needed backend. It panics if a
+// backend is already registered under the ID. The first registered backend
+// becomes the default backend.
+func RegisterBackend(id BackendID, b Backend) {
+	if b == nil {
+		panic("nil wallet backend")
+	}
+	backends.Lock()
+	defer backends.Unlock()
+	if _, ok := backends.m[id]; ok {
+		panic(fmt.Sprintf("wallet backend %d already registered", id))
+	}
+	backends.m[id] = b
+	if !backends.defaultSet {
+		backends.def, backends.defaultSet = id, true
+	}
+}
+
+// SetDefaultBackend sets the default backend to the backend registered under
+// the given ID. It panics if no backend is registered under the ID.
+func SetDefaultBackend(id BackendID) {
+	backends.Lock()
+	defer backends.Unlock()
+	if _, ok := backends.m[id]; !ok {
+		panic(fmt.Sprintf("wallet backend %d not registered", id))
 	}
-	backend = b
+	backends.def, backends.defaultSet = id, true
 }
 
-// DecodeAddress calls DecodeAddress of the current backend
+// DefaultBackend returns the ID of the default backend.
+func DefaultBackend() BackendID {
+	backends.RLock()
+	defer backends.RUnlock()
+	return backends.def
+}
+
+// BackendOf returns the backend registered under the given ID.
+func BackendOf(id BackendID) (Backend, error) {
+	backends.RLock()
+	defer backends.RUnlock()
+	b, ok := backends.m[id]
+	if !ok {
+		return nil, errors.Errorf("unknown wallet backend %d", id)
+	}
+	return b, nil
+}
+
+// defaultBackend returns the default backend. It panics if no backend is
+// registered.
+func defaultBackend() Backend {
+	backends.RLock()
+	defer backends.RUnlock()
+	if !backends.defaultSet {
+		panic("no wallet backend registered")
+	}
+	return backends.m[backends.def]
+}
+
+// DecodeAddress calls DecodeAddress of the default backend
 func DecodeAddress(r io.Reader) (Address, error) {
-	return backend.DecodeAddress(r)
+	return defaultBackend().DecodeAddress(r)
 }
 
-// DecodeSig calls DecodeSig of the current backend
+// DecodeSig calls DecodeSig of the default backend
 func DecodeSig(r io.Reader) (Sig, error) {
-	return backend.DecodeSig(r)
+	return defaultBackend().DecodeSig(r)
 }
 
-// VerifySignature calls VerifySignature of the current backend
+// VerifySignature calls VerifySignature of the default backend
 func VerifySignature(msg []byte, sign Sig, a Address) (bool, error) {
-	return backend.VerifySignature(msg, sign, a)
+	return defaultBackend().VerifySignature(msg, sign, a)
+}
+
+// DecodeAddressOf calls DecodeAddress of the backend with the given ID.
+func DecodeAddressOf(id BackendID, r io.Reader) (Address, error) {
+	b, err := BackendOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.DecodeAddress(r)
+}
+
+// DecodeSigOf calls DecodeSig of the backend with the given ID.
+func DecodeSigOf(id BackendID, r io.Reader) (Sig, error) {
+	b, err := BackendOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.DecodeSig(r)
 }
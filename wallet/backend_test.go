@@ -42,7 +42,7 @@ var _ Backend = (*mockBackend)(nil)
 // TestGlobalBackend tests all global backend wrappers
 func TestGlobalBackend(t *testing.T) {
 	b := &mockBackend{test.NewWrapMock(t)}
-	SetBackend(b)
+	RegisterBackend(0, b)
 	DecodeAddress(nil)
 	b.AssertCalled()
 	DecodeSig(nil)
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wallet
+
+import (
+	"io"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// The JSON encoding of addresses and signatures is the hex string of their
+// binary encoding, see perunio.EncodeHex. Addresses of the backends implement
+// json.Marshaler and json.Unmarshaler this way. Types that contain the
+// Address interface use the following functions to decode addresses with the
+// backend of their choice.
+
+// DecodeAddressHex decodes a hex encoded address with the default backend.
+func DecodeAddressHex(s string) (Address, error) {
+	return DecodeAddressHexOf(DefaultBackend(), s)
+}
+
+// DecodeAddressHexOf decodes a hex encoded address with the backend of the
+// given ID.
+func DecodeAddressHexOf(id BackendID, s string) (Address, error) {
+	var a Address
+	err := perunio.DecodeHex(s, func(r io.Reader) (err error) {
+		a, err = DecodeAddressOf(id, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// EncodeSigHex returns the hex encoding of a signature. A nil signature is
+// encoded as the empty string.
+func EncodeSigHex(sig Sig) (string, error) {
+	if sig == nil {
+		return "", nil
+	}
+	return perunio.EncodeHex(perunio.ByteSlice(sig))
+}
+
+// DecodeSigHexOf decodes a hex encoded signature with the backend of the given
+// ID. The empty string is decoded as a nil signature.
+func DecodeSigHexOf(id BackendID, s string) (Sig, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sig Sig
+	err := perunio.DecodeHex(s, func(r io.Reader) (err error) {
+		sig, err = DecodeSigOf(id, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
@@ -34,14 +34,15 @@ func CloneSigs(sigs []Sig) []Sig {
 
 var _ perunio.Decoder = SigDec{}
 
-// SigDec is a helper type to decode signatures.
+// SigDec is a helper type to decode signatures of the backend Backend.
 type SigDec struct {
-	Sig *Sig
+	Sig     *Sig
+	Backend BackendID
 }
 
 // Decode decodes a single signature.
 func (s SigDec) Decode(r io.Reader) (err error) {
-	*s.Sig, err = DecodeSig(r)
+	*s.Sig, err = DecodeSigOf(s.Backend, r)
 	return err
 }
 
@@ -72,7 +73,18 @@ func EncodeSparseSigs(w io.Writer, sigs []Sig) error {
 }
 
 // DecodeSparseSigs decodes a collection of signatures in the form (mask, sig, sig, sig, ...)
-func DecodeSparseSigs(r io.Reader, sigs *[]Sig) (err error) {
+// using the default backend.
+func DecodeSparseSigs(r io.Reader, sigs *[]Sig) error {
+	return DecodeSparseSigsOf(DefaultBackend(), r, sigs)
+}
+
+// DecodeSparseSigsOf decodes a collection of signatures in the form (mask,
+// sig, sig, sig, ...) using the backend with the given ID.
+func DecodeSparseSigsOf(id BackendID, r io.Reader, sigs *[]Sig) (err error) {
+	b, err := BackendOf(id)
+	if err != nil {
+		return err
+	}
 	masklen := int(math.Ceil(float64(len(*sigs)) / 8.0))
 	mask := make([]uint8, masklen)
 
@@ -87,7 +99,7 @@ func DecodeSparseSigs(r io.Reader, sigs *[]Sig) (err error) {
 			if ((mask[maskIdx] >> bitIdx) % 2) == 0 {
 				(*sigs)[sigIdx] = nil
 			} else {
-				(*sigs)[sigIdx], err = DecodeSig(r)
+				(*sigs)[sigIdx], err = b.DecodeSig(r)
 				if err != nil {
 					return errors.WithMessagef(err, "decoding signature %d", sigIdx)
 				}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	perunio "perun.network/go-perun/pkg/io"
 	"perun.network/go-perun/pkg/test"
 	"perun.network/go-perun/wallet"
 )
@@ -33,11 +34,24 @@ type Account = wallet.Account
 // of the peer on the other end of the connection. If the supplied context times
 // out before the protocol finishes, closes the connection.
 //
-// In the future, ExchangeAddrs will be replaced by Authenticate to run a proper
-// authentication protocol. The protocol will then exchange Perun addresses and
-// establish authenticity.
+// ExchangeAddrs is a shorthand for Handshake that discards the peer's protocol
+// version and features.
 func ExchangeAddrs(ctx context.Context, id Account, conn Conn) (Address, error) {
-	var addr Address
+	info, err := Handshake(ctx, id, conn)
+	return info.Address, err
+}
+
+// Handshake runs the initial protocol on a new peer connection. Both sides
+// announce their Perun address, protocol version and Features. It returns
+// what was learned about the peer on the other end of the connection,
+// including the negotiated protocol version. If the peer's protocol version is
+// not supported, an error is returned. If the supplied context times out
+// before the protocol finishes, closes the connection.
+//
+// In the future, Handshake will also run a proper authentication protocol to
+// establish authenticity of the exchanged Perun addresses.
+func Handshake(ctx context.Context, id Account, conn Conn) (PeerInfo, error) {
+	var info PeerInfo
 	var err error
 	ok := test.TerminatesCtx(ctx, func() {
 		sent := make(chan error, 1)
@@ -48,25 +62,35 @@ func ExchangeAddrs(ctx context.Context, id Account, conn Conn) (Address, error)
 			err = errors.WithMessage(err, "receiving message")
 		} else if addrM, ok := m.(*AuthResponseMsg); !ok {
 			err = errors.Errorf("expected AuthResponse wire msg, got %v", m.Type())
-		} else {
-			err = <-sent // Wait until the message was sent.
-			addr = addrM.Address
+		} else if err = <-sent; err == nil { // Wait until the message was sent.
+			info.Version, err = negotiateVersion(addrM.Version)
+			info.Address, info.Features = addrM.Address, addrM.Features
+			if vc, ok := conn.(versionedConn); ok && err == nil {
+				vc.setVersion(info.Version)
+			}
 		}
 	})
 
 	if !ok {
 		conn.Close()
-		return nil, ctx.Err()
+		return PeerInfo{}, ctx.Err()
+	}
+	if err != nil {
+		return PeerInfo{}, err
 	}
 
-	return addr, err
+	return info, nil
 }
 
 var _ Msg = (*AuthResponseMsg)(nil)
 
 // AuthResponseMsg is the response message in the peer authentication protocol.
+// Besides the sender's Perun address, it announces the sender's protocol
+// version and Features.
 type AuthResponseMsg struct {
-	Address Address
+	Address  Address
+	Version  uint16
+	Features Features
 }
 
 // Type returns AuthResponse.
@@ -76,18 +100,34 @@ func (m *AuthResponseMsg) Type() Type {
 
 // Encode encodes this AuthResponseMsg into an io.Writer.
 func (m *AuthResponseMsg) Encode(w io.Writer) error {
-	return m.Address.Encode(w)
+	return perunio.Encode(w, m.Address, m.Version, m.Features)
 }
 
-// Decode decodes an AuthResponseMsg from an io.Reader.
+// Decode decodes an AuthResponseMsg from an io.Reader. If the message ends
+// after the address, it was sent by a legacy node and its Version is set to
+// LegacyProtocolVersion.
 func (m *AuthResponseMsg) Decode(r io.Reader) (err error) {
-	m.Address, err = wallet.DecodeAddress(r)
-	return
+	if m.Address, err = wallet.DecodeAddress(r); err != nil {
+		return err
+	}
+	err = perunio.Decode(r, &m.Version)
+	if errors.Cause(err) == io.EOF {
+		m.Version, m.Features = LegacyProtocolVersion, Features{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return perunio.Decode(r, &m.Features)
 }
 
-// NewAuthResponseMsg creates an authentication response message.
+// NewAuthResponseMsg creates an authentication response message announcing
+// the own protocol version and Features.
 // In the future, it will also take an authentication challenge message as
 // additional argument.
 func NewAuthResponseMsg(id Account) Msg {
-	return &AuthResponseMsg{id.Address()}
+	return &AuthResponseMsg{
+		Address:  id.Address(),
+		Version:  ProtocolVersion,
+		Features: LocalFeatures(),
+	}
 }
@@ -6,12 +6,14 @@
 package wire
 
 import (
+	"bytes"
 	"context"
 	"math/rand"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	pkgtest "perun.network/go-perun/pkg/test"
 	wallettest "perun.network/go-perun/wallet/test"
@@ -78,3 +80,54 @@ func TestExchangeAddrs_BogusMsg(t *testing.T) {
 	assert.Error(t, err, "ExchangeAddrs should error when peer sends a non-AuthResponseMsg")
 	assert.Nil(t, addr)
 }
+
+func TestHandshake_Features(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xfeA7))
+	acc, peerAcc := wallettest.NewRandomAccount(rng), wallettest.NewRandomAccount(rng)
+	conn := newMockConn(nil)
+	var features Features
+	features.Set(Ping)
+	conn.recvQueue <- &AuthResponseMsg{
+		Address:  peerAcc.Address(),
+		Version:  ProtocolVersion,
+		Features: features,
+	}
+
+	info, err := Handshake(context.Background(), acc, conn)
+	require.NoError(t, err)
+	assert.True(t, info.Address.Equals(peerAcc.Address()))
+	assert.Equal(t, ProtocolVersion, info.Version)
+	assert.Equal(t, features, info.Features)
+}
+
+func TestHandshake_OldVersion(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xfeA8))
+	acc, peerAcc := wallettest.NewRandomAccount(rng), wallettest.NewRandomAccount(rng)
+	conn := newMockConn(nil)
+	conn.recvQueue <- &AuthResponseMsg{
+		Address:  peerAcc.Address(),
+		Version:  MinProtocolVersion - 1,
+		Features: LocalFeatures(),
+	}
+
+	info, err := Handshake(context.Background(), acc, conn)
+	assert.Error(t, err, "Handshake should error when peer speaks a too old protocol version")
+	assert.Nil(t, info.Address)
+}
+
+func TestAuthResponseMsg_Legacy(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xfeA9))
+	addr := wallettest.NewRandomAddress(rng)
+	var buf bytes.Buffer
+	require.NoError(t, addr.Encode(&buf))
+
+	var m AuthResponseMsg
+	require.NoError(t, m.Decode(&buf), "address-only handshakes must be decodable")
+	assert.True(t, addr.Equals(m.Address))
+	assert.Equal(t, LegacyProtocolVersion, m.Version)
+
+	conn := newMockConn(nil)
+	conn.recvQueue <- &m
+	_, err := Handshake(context.Background(), wallettest.NewRandomAccount(rng), conn)
+	assert.Error(t, err, "Handshake should error on legacy peers")
+}
@@ -7,6 +7,7 @@ package wire
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -30,7 +31,12 @@ import (
 type Endpoint struct {
 	PerunAddress Address // The peer's perun address.
 
-	conn Conn // The peer's connection.
+	conn     Conn     // The peer's connection.
+	version  uint16   // The negotiated protocol version.
+	features Features // The message types the peer understands.
+
+	limiter   *rateLimiter                 // Limits inbound messages, if set.
+	onForward func(*Endpoint, *ForwardMsg) // Handles ForwardMsgs, if set.
 
 	creating sync.Mutex // Prevent races when concurrently creating the peer.
 	sending  sync.Mutex // Blocks multiple Send calls.
@@ -57,6 +63,20 @@ func (p *Endpoint) recvLoop() {
 			log.WithError(err).Errorf("Ending recvLoop on closed connection of peer %v", p.PerunAddress)
 			return
 		}
+		if p.limiter != nil && !p.limiter.allow(m.Type(), time.Now()) {
+			if p.limiter.exceeded() {
+				log.Warnf("Disconnecting peer %v: rate limits exceeded", p.PerunAddress)
+				p.limiter.onExceeded()
+				p.Close()
+				return
+			}
+			log.Debugf("Dropping %v message of peer %v: rate limit exceeded", m.Type(), p.PerunAddress)
+			continue
+		}
+		if fwd, ok := m.(*ForwardMsg); ok && p.onForward != nil {
+			p.onForward(p, fwd)
+			continue
+		}
 		// Broadcast the received message to all interested subscribers.
 		p.produce(m, p)
 	}
@@ -65,13 +85,15 @@ func (p *Endpoint) recvLoop() {
 // create finishes a peer that does not yet have a connection.
 // This is needed in the registry when a peer is still being dialed, but
 // already registered. This wakes up all operations that were started on the
-// unfinished peer object.
-func (p *Endpoint) create(conn Conn) {
+// unfinished peer object. The protocol version and Features learned during
+// the handshake are taken from info.
+func (p *Endpoint) create(conn Conn, info PeerInfo) {
 	p.creating.Lock()
 	defer p.creating.Unlock()
 
 	if p.conn == nil {
 		p.conn = conn
+		p.version, p.features = info.Version, info.Features
 		p.created.Close()
 	} else {
 		conn.Close()
@@ -118,8 +140,33 @@ func (p *Endpoint) OnCreateAlways(fn func()) bool {
 	return p.created.OnCloseAlways(fn)
 }
 
+// Version returns the protocol version negotiated with the peer during the
+// handshake.
+func (p *Endpoint) Version() uint16 {
+	p.creating.Lock()
+	defer p.creating.Unlock()
+	return p.version
+}
+
+// info returns the protocol version and Features of the peer.
+func (p *Endpoint) info() PeerInfo {
+	p.creating.Lock()
+	defer p.creating.Unlock()
+	return PeerInfo{Address: p.PerunAddress, Version: p.version, Features: p.features}
+}
+
+// Features returns the Features that the peer announced during the handshake.
+func (p *Endpoint) Features() Features {
+	p.creating.Lock()
+	defer p.creating.Unlock()
+	return p.features
+}
+
 // Send sends a single message to a peer.
 // Fails if the peer is closed via Close() or the transmission fails.
+// If the message's Type is not known in the negotiated protocol version or
+// the peer did not announce support for it, Send fails with an
+// UnsupportedMsgError without closing the peer.
 //
 // The passed context is used to timeout the send operation. If the context
 // times out, the peer is closed.
@@ -130,6 +177,10 @@ func (p *Endpoint) Send(ctx context.Context, m Msg) error {
 		return errors.New("peer closed") // closed before connection set
 	}
 
+	if !p.info().Supports(m.Type()) {
+		return newUnsupportedMsgError(p.PerunAddress, m.Type())
+	}
+
 	if !p.sending.TryLockCtx(ctx) {
 		p.Close() // replace with p.conn.Close() when reintroducing repair.
 		return errors.New("aborted manually")
@@ -171,11 +222,15 @@ func (p *Endpoint) Close() (err error) {
 }
 
 // newEndpoint creates a new peer from a peer address and connection.
+// If the connection is already set, the peer is assumed to speak the own
+// protocol version and to support the own Features.
 func newEndpoint(addr Address, conn Conn, _ Dialer) *Endpoint {
 	p := &Endpoint{
 		PerunAddress: addr,
 
 		conn:     conn,
+		version:  ProtocolVersion,
+		features: LocalFeatures(),
 		producer: makeProducer(),
 	}
 
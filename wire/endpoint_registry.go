@@ -14,6 +14,7 @@ import (
 
 	"perun.network/go-perun/log"
 	perunsync "perun.network/go-perun/pkg/sync"
+	"perun.network/go-perun/wallet"
 )
 
 // EndpointRegistry is a peer EndpointRegistry.
@@ -26,11 +27,28 @@ type EndpointRegistry struct {
 	dialer    Dialer          // Used for dialing peers (and later: repairing).
 	subscribe func(*Endpoint) // Sets up peer subscriptions.
 
+	rateLimits *RateLimitConfig             // Limits on inbound messages, if set.
+	banned     map[wallet.AddrKey]time.Time // Banned peers and ban expiry.
+
+	forwarding    bool                           // Whether to relay messages for other peers.
+	forwardQueues map[*Endpoint]chan *ForwardMsg // Messages to forward by next hop.
+	relayConns    map[wallet.AddrKey]*relayConn  // Connections over relays by remote peer.
+
 	log log.Logger
 	perunsync.Closer
 }
 
-const exchangeAddrsTimeout = 10 * time.Second
+const (
+	exchangeAddrsTimeout = 10 * time.Second
+	forwardTimeout       = 10 * time.Second
+
+	// forwardQueueSize is the number of messages that are queued for each next
+	// hop. Further messages are dropped until the queue drains.
+	forwardQueueSize = 64
+	// maxRelayConnsPerRelay is the maximum number of relayed connections over a
+	// single relay. Forwarded messages of further senders are dropped.
+	maxRelayConnsPerRelay = 64
+)
 
 // NewEndpointRegistry creates a new registry.
 // The provided callback is used to set up new peer's subscriptions and it is
@@ -40,6 +58,10 @@ func NewEndpointRegistry(id Account, subscribe func(*Endpoint), dialer Dialer) *
 		id:        id,
 		subscribe: subscribe,
 		dialer:    dialer,
+		banned:    make(map[wallet.AddrKey]time.Time),
+
+		forwardQueues: make(map[*Endpoint]chan *ForwardMsg),
+		relayConns:    make(map[wallet.AddrKey]*relayConn),
 
 		log: log.WithField("id", id.Address()),
 	}
@@ -110,20 +132,30 @@ func (r *EndpointRegistry) setupConn(conn Conn) error {
 	unfinishedPeer := r.addPeer(nil, nil)
 	r.mutex.Unlock()
 
-	var peerAddr Address
-	var err error
-	if peerAddr, err = ExchangeAddrs(ctx, r.id, conn); err != nil {
+	info, err := Handshake(ctx, r.id, conn)
+	if err != nil {
 		conn.Close()
 		return errors.WithMessage(err, "could not authenticate peer")
 	}
+	if rc, ok := conn.(*relayConn); ok && !info.Address.Equals(rc.remote) {
+		conn.Close()
+		return errors.New("relayed peer announced foreign address")
+	}
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if peer, _ := r.find(peerAddr); peer == nil {
-		unfinishedPeer.PerunAddress = peerAddr
-		unfinishedPeer.create(conn)
+	if r.isBanned(info.Address) {
+		conn.Close()
+		r.delete(unfinishedPeer)
+		unfinishedPeer.Close()
+		return errors.New("peer is banned")
+	}
+
+	if peer, _ := r.find(info.Address); peer == nil {
+		unfinishedPeer.PerunAddress = info.Address
+		unfinishedPeer.create(conn, info)
 	} else {
-		peer.create(conn)
+		peer.create(conn, info)
 		r.delete(unfinishedPeer)
 	}
 	return nil
@@ -173,6 +205,10 @@ func (r *EndpointRegistry) Get(ctx context.Context, addr Address) (*Endpoint, er
 	log := r.log.WithField("peer", addr)
 	log.Trace("Registry.Get")
 	r.mutex.Lock()
+	if r.isBanned(addr) {
+		r.mutex.Unlock()
+		return nil, errors.New("peer is banned")
+	}
 	if p, i := r.find(addr); i != -1 {
 		r.mutex.Unlock()
 		log.Trace("Registry.Get: peer found, waiting for conn...")
@@ -196,8 +232,33 @@ func (r *EndpointRegistry) Get(ctx context.Context, addr Address) (*Endpoint, er
 	return peer, nil
 }
 
+// dial dials the peer with the given address. If the registry's dialer has a
+// route hint for the peer, a connection over its relay is created instead.
+// Relays must be reachable directly, so routes over this node, over the peer
+// itself or over relays with route hints are rejected.
+func (r *EndpointRegistry) dial(ctx context.Context, addr Address) (Conn, error) {
+	if hinter, ok := r.dialer.(RouteHinter); ok {
+		if relayAddr, ok := hinter.Route(addr); ok {
+			if relayAddr.Equals(addr) || relayAddr.Equals(r.id.Address()) {
+				return nil, errors.New("route over the peer itself or over this node")
+			} else if _, ok := hinter.Route(relayAddr); ok {
+				return nil, errors.New("relay has a route hint itself")
+			}
+			relay, err := r.Get(ctx, relayAddr)
+			if err != nil {
+				return nil, errors.WithMessage(err, "getting relay")
+			}
+
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			return r.addRelayConn(relay, addr), nil
+		}
+	}
+	return r.dialer.Dial(ctx, addr)
+}
+
 func (r *EndpointRegistry) authenticatedDial(ctx context.Context, peer *Endpoint, addr Address) error {
-	conn, err := r.dialer.Dial(ctx, addr)
+	conn, err := r.dial(ctx, addr)
 
 	if peer.exists() {
 		if conn != nil {
@@ -208,20 +269,20 @@ func (r *EndpointRegistry) authenticatedDial(ctx context.Context, peer *Endpoint
 		return errors.WithMessage(err, "failed to dial")
 	}
 
-	a, err := ExchangeAddrs(ctx, r.id, conn)
-	if err != nil || !a.Equals(addr) {
+	info, err := Handshake(ctx, r.id, conn)
+	if err != nil || !info.Address.Equals(addr) {
 		conn.Close()
 		if !peer.exists() {
 			peer.Close()
 			if err != nil {
-				return errors.WithMessage(err, "ExchangeAddrs failed")
+				return errors.WithMessage(err, "Handshake failed")
 			}
 			return errors.New("dialed impersonator")
 		}
 		return nil
 	}
 
-	peer.create(conn)
+	peer.create(conn, info)
 	return nil
 }
 
@@ -260,6 +321,15 @@ func (r *EndpointRegistry) addPeer(addr Address, conn Conn) *Endpoint {
 	r.log.WithField("peer", addr).Trace("Registry.addPeer")
 	// Create and register a new peer.
 	peer := newEndpoint(addr, conn, r.dialer)
+	peer.onForward = r.handleForward
+	if r.rateLimits != nil {
+		banDuration := r.rateLimits.BanDuration
+		peer.limiter = newRateLimiter(*r.rateLimits, time.Now(), func() {
+			if banDuration != 0 {
+				r.Ban(peer.PerunAddress, banDuration)
+			}
+		})
+	}
 	r.peers = append(r.peers, peer)
 	// Setup the peer's subscriptions.
 	r.subscribe(peer)
@@ -284,3 +354,218 @@ func (r *EndpointRegistry) delete(peer *Endpoint) {
 	}
 	log.Panic("tried to delete non-existent peer!")
 }
+
+// SetRateLimits sets the limits on inbound messages for all peers that are
+// added to the registry afterwards. Peers that exceed the limits too often are
+// disconnected and, depending on the config, banned.
+func (r *EndpointRegistry) SetRateLimits(cfg RateLimitConfig) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.rateLimits = &cfg
+}
+
+// Ban bans the peer with the given address for the given duration. Banned
+// peers are neither accepted nor dialed. Existing connections to the peer are
+// not closed.
+func (r *EndpointRegistry) Ban(addr Address, d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.log.WithField("peer", addr).Infof("Registry.Ban: banning peer for %v", d)
+	r.banned[wallet.Key(addr)] = time.Now().Add(d)
+}
+
+// isBanned returns whether the peer with the given address is currently
+// banned. Expired bans are removed.
+// isBanned is not thread safe and is assumed to be called from a method which
+// has the r.mutex lock.
+func (r *EndpointRegistry) isBanned(addr Address) bool {
+	key := wallet.Key(addr)
+	until, ok := r.banned[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(r.banned, key)
+		return false
+	}
+	return true
+}
+
+// SetForwarding sets whether the registry acts as a relay for other peers,
+// that is, whether ForwardMsgs for other recipients are passed on. Messages
+// are only forwarded to peers that are connected or have a route hint.
+func (r *EndpointRegistry) SetForwarding(enabled bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.forwarding = enabled
+}
+
+// handleForward handles a ForwardMsg received from peer p. Messages for this
+// node are delivered to the relayed connection of their sender. The sequence
+// numbers of a relayed connection start at 1 with the AuthResponse of the
+// sender's handshake. Unless it answers the handshake of a connection that
+// this node dialed, such a message sets up a new relayed connection like an
+// accepted connection, replacing an existing one, because the sender
+// reconnected. Other messages whose sequence number is not greater than the
+// last one received on the open relayed connection of their sender are
+// dropped as replays. Messages for other recipients are passed on towards
+// them if forwarding is enabled.
+// handleForward does not block, so that forwarding does not stall the other
+// messages of p.
+func (r *EndpointRegistry) handleForward(p *Endpoint, m *ForwardMsg) {
+	log := r.log.WithField("peer", p.PerunAddress)
+	if err := m.Verify(); err != nil {
+		log.Warnf("Registry: dropping forwarded message: %v", err)
+		return
+	}
+	env := &m.Envelope
+	if !env.Recipient.Equals(r.id.Address()) {
+		r.forward(p, m)
+		return
+	}
+
+	log = log.WithField("sender", env.Sender)
+	r.mutex.Lock()
+	conn, ok := r.relayConns[wallet.Key(env.Sender)]
+	open := ok && !conn.IsClosed()
+	_, isAuth := env.Msg.(*AuthResponseMsg)
+	switch {
+	case open && m.Seq > conn.recvSeq:
+		// Next message of the relayed connection, which is the first one if
+		// this node dialed the sender.
+	case m.Seq == 1 && isAuth:
+		if open {
+			log.Debug("Registry: sender reconnected, replacing relayed connection")
+			conn.Close()
+		}
+		if r.numRelayConns(p) >= maxRelayConnsPerRelay {
+			r.mutex.Unlock()
+			log.Warn("Registry: dropping forwarded message, too many relayed connections")
+			return
+		}
+		log.Debug("Registry: setting up relayed connection")
+		conn = r.addRelayConn(p, env.Sender)
+		go r.setupConn(conn)
+	default:
+		r.mutex.Unlock()
+		log.Warnf("Registry: dropping replayed or unconnected forwarded message with sequence number %d", m.Seq)
+		return
+	}
+	conn.recvSeq = m.Seq
+	r.mutex.Unlock()
+
+	conn.deliver(env.Msg)
+}
+
+// forward queues a ForwardMsg received from peer from to be passed on towards
+// its recipient. The message is dropped if the queue of the next hop is full.
+func (r *EndpointRegistry) forward(from *Endpoint, m *ForwardMsg) {
+	log := r.log.WithField("peer", from.PerunAddress).WithField("recipient", m.Envelope.Recipient)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.forwarding {
+		log.Debug("Registry: dropping message for other recipient, forwarding disabled")
+		return
+	}
+	next := r.nextHop(m.Envelope.Recipient)
+	if next == nil || next == from {
+		log.Debug("Registry: dropping message for unreachable recipient")
+		return
+	}
+
+	queue, ok := r.forwardQueues[next]
+	if !ok {
+		queue = make(chan *ForwardMsg, forwardQueueSize)
+		r.forwardQueues[next] = queue
+		go r.forwardLoop(next, queue)
+	}
+	select {
+	case queue <- m:
+	default:
+		log.Warn("Registry: dropping message, forwarding queue full")
+	}
+}
+
+// forwardLoop sends the queued messages to the next hop until it or the
+// registry is closed.
+func (r *EndpointRegistry) forwardLoop(next *Endpoint, queue chan *ForwardMsg) {
+	defer func() {
+		r.mutex.Lock()
+		delete(r.forwardQueues, next)
+		r.mutex.Unlock()
+	}()
+
+	for {
+		select {
+		case m := <-queue:
+			ctx, cancel := context.WithTimeout(r.Ctx(), forwardTimeout)
+			if err := next.Send(ctx, m); err != nil {
+				r.log.WithField("peer", next.PerunAddress).Warnf("Registry: forwarding message: %v", err)
+			}
+			cancel()
+		case <-next.Closed():
+			return
+		case <-r.Closed():
+			return
+		}
+	}
+}
+
+// nextHop returns the peer to forward messages for the given recipient to,
+// which is either the recipient itself or its relay, if it has a route hint.
+// If neither is connected, returns nil.
+// nextHop is not thread safe and is assumed to be called from a method which
+// has the r.mutex lock.
+func (r *EndpointRegistry) nextHop(recipient Address) *Endpoint {
+	if p, _ := r.find(recipient); p != nil {
+		return p
+	}
+	if hinter, ok := r.dialer.(RouteHinter); ok {
+		if relay, ok := hinter.Route(recipient); ok {
+			p, _ := r.find(relay)
+			return p
+		}
+	}
+	return nil
+}
+
+// addRelayConn creates a connection to the remote peer over the given relay
+// and registers it so that ForwardMsgs from the remote peer are delivered to
+// it. A previously registered connection to the remote peer is replaced.
+// addRelayConn is not thread safe and is assumed to be called from a method
+// which has the r.mutex lock.
+func (r *EndpointRegistry) addRelayConn(relay *Endpoint, remote Address) *relayConn {
+	r.pruneRelayConns()
+	conn := newRelayConn(r.id, relay, remote)
+	r.relayConns[wallet.Key(remote)] = conn
+	return conn
+}
+
+// numRelayConns returns the number of open relayed connections over relay.
+// numRelayConns is not thread safe and is assumed to be called from a method
+// which has the r.mutex lock.
+func (r *EndpointRegistry) numRelayConns(relay *Endpoint) int {
+	r.pruneRelayConns()
+	n := 0
+	for _, conn := range r.relayConns {
+		if conn.relay == relay {
+			n++
+		}
+	}
+	return n
+}
+
+// pruneRelayConns removes closed relayed connections.
+// pruneRelayConns is not thread safe and is assumed to be called from a
+// method which has the r.mutex lock.
+func (r *EndpointRegistry) pruneRelayConns() {
+	for key, conn := range r.relayConns {
+		if conn.IsClosed() {
+			delete(r.relayConns, key)
+		}
+	}
+}
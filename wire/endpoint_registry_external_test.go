@@ -132,3 +132,52 @@ func TestEndpointRegistry_Get_Multiple(t *testing.T) {
 	assert.True(sync.IsAlreadyClosedError(listener.Close()))
 	test.AssertTerminates(t, timeout, func() { <-done })
 }
+
+// A node dials a node behind a firewall via a relay node that forwards their
+// messages.
+func TestEndpointRegistry_Forward(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	rng := rand.New(rand.NewSource(4))
+	var hub wiretest.ConnHub
+	aliceId := wallettest.NewRandomAccount(rng)
+	relayId := wallettest.NewRandomAccount(rng)
+	bobId := wallettest.NewRandomAccount(rng)
+
+	bobRecv := wire.NewReceiver()
+	subscribe := func(p *wire.Endpoint) {
+		p.OnCreateAlways(func() { p.Subscribe(bobRecv, func(wire.Msg) bool { return true }) })
+	}
+	relayReg := wire.NewEndpointRegistry(relayId, func(*wire.Endpoint) {}, nil)
+	relayReg.SetForwarding(true)
+	go relayReg.Listen(hub.NewNetListener(relayId.Address()))
+	defer relayReg.Close()
+
+	// Bob cannot be dialed, so he connects to the relay himself.
+	bobReg := wire.NewEndpointRegistry(bobId, subscribe, hub.NewNetDialer())
+	defer bobReg.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*timeout)
+	defer cancel()
+	_, err := bobReg.Get(ctx, relayId.Address())
+	require.NoError(err)
+	test.Within1s.Eventually(t, func(t test.T) {
+		if !relayReg.Has(bobId.Address()) {
+			t.Errorf("relay must have added Bob")
+		}
+	})
+
+	aliceDialer := hub.NewNetDialer()
+	aliceDialer.RegisterRoute(bobId.Address(), relayId.Address())
+	aliceReg := wire.NewEndpointRegistry(aliceId, func(*wire.Endpoint) {}, aliceDialer)
+	defer aliceReg.Close()
+	bob, err := aliceReg.Get(ctx, bobId.Address())
+	require.NoError(err)
+	assert.True(bob.PerunAddress.Equals(bobId.Address()))
+
+	ping := wire.NewPingMsg()
+	require.NoError(bob.Send(ctx, ping))
+	alice, m := bobRecv.Next(ctx)
+	require.NotNil(alice)
+	assert.True(alice.PerunAddress.Equals(aliceId.Address()))
+	assert.Equal(ping, m)
+}
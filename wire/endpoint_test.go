@@ -185,7 +185,7 @@ func TestEndpoint_create(t *testing.T) {
 	assert.False(t, p.exists(), "peer must not yet exist")
 
 	conn := newMockConn(nil)
-	p.create(conn)
+	p.create(conn, PeerInfo{Version: ProtocolVersion, Features: LocalFeatures()})
 
 	assert.True(t, p.exists(), "peer must exist")
 
@@ -193,7 +193,7 @@ func TestEndpoint_create(t *testing.T) {
 		"Peer.create() on nonexisting peers must not close the new connection")
 
 	conn2 := newMockConn(nil)
-	p.create(conn2)
+	p.create(conn2, PeerInfo{Version: ProtocolVersion, Features: LocalFeatures()})
 	assert.True(t, conn2.closed.IsSet(),
 		"Peer.create() on existing peers must close the new connection")
 }
@@ -247,3 +247,19 @@ func TestEndpoint_WaitExists_2nd_Close(t *testing.T) {
 	}()
 	assert.False(t, p.waitExists(context.Background()))
 }
+
+func TestEndpoint_Send_Unsupported(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0xfea7))
+	p := newEndpoint(wallettest.NewRandomAddress(rng), nil, nil)
+	var features Features
+	features.Set(Ping)
+	p.create(newMockConn(nil), PeerInfo{Version: ProtocolVersion, Features: features})
+	assert.Equal(t, features, p.Features())
+	assert.Equal(t, ProtocolVersion, p.Version())
+
+	err := p.Send(context.Background(), NewPongMsg())
+	assert.True(t, IsUnsupportedMsgError(err), "unsupported messages must be refused")
+	assert.False(t, p.IsClosed(), "refusing a message must not close the peer")
+	assert.NoError(t, p.Send(context.Background(), NewPingMsg()))
+}
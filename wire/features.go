@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+const (
+	// ProtocolVersion is the version of the Perun wire protocol that is spoken
+	// by this implementation. It is announced during the handshake.
+	ProtocolVersion uint16 = 3
+	// MinProtocolVersion is the oldest protocol version that this
+	// implementation can still communicate with. Peers announcing an older
+	// version are rejected during the handshake. Messages whose encoding
+	// changed since are gated per peer, see PeerInfo.Supports.
+	MinProtocolVersion uint16 = 1
+	// LegacyProtocolVersion is the version of nodes that predate the version
+	// negotiation. Their handshake only announces their address and they send
+	// unframed messages. They are detected and rejected during the handshake.
+	LegacyProtocolVersion uint16 = 0
+)
+
+// msgVersions maps message types to the protocol version that introduced
+// their current encoding. Messages of these types are neither sent to nor
+// accepted from peers of an older negotiated version. All other types are
+// encoded as in the first protocol version.
+var msgVersions = map[Type]uint16{
+	// Version 2 added the backend IDs to channels, version 3 the length of
+	// the app data in states and channel proposals, see channel.EncodeData.
+	// Forwarded envelopes may contain any of these messages.
+	ChannelProposal:    3,
+	ChannelProposalAcc: 2,
+	ChannelUpdate:      3,
+	ChannelUpdateAcc:   2,
+	ChannelSync:        3,
+	Forward:            3,
+}
+
+// knownIn returns whether messages of Type t are encoded as in the given
+// protocol version.
+func knownIn(t Type, version uint16) bool {
+	return version >= msgVersions[t]
+}
+
+var _ perunio.Serializer = (*Features)(nil)
+
+// Features is a bitset of the message types that a node is able to decode.
+// Each message Type corresponds to the bit at its numerical value. The
+// Features of a node are exchanged during the handshake so that messages that
+// a peer does not understand can be refused before they are sent.
+type Features [4]uint64
+
+// LocalFeatures returns the Features of this node, that is, the set of all
+// message types for which a decoder is registered.
+func LocalFeatures() (f Features) {
+	for t := range decoders {
+		f.Set(t)
+	}
+	return
+}
+
+// Supports returns whether the message Type t is contained in the bitset.
+func (f Features) Supports(t Type) bool {
+	return f[t/64]&(1<<(t%64)) != 0
+}
+
+// Set adds the message Type t to the bitset.
+func (f *Features) Set(t Type) {
+	f[t/64] |= 1 << (t % 64)
+}
+
+// Encode encodes the Features into an io.Writer.
+func (f Features) Encode(w io.Writer) error {
+	return perunio.Encode(w, f[0], f[1], f[2], f[3])
+}
+
+// Decode decodes Features from an io.Reader.
+func (f *Features) Decode(r io.Reader) error {
+	return perunio.Decode(r, &f[0], &f[1], &f[2], &f[3])
+}
+
+// PeerInfo contains everything that is learned about a peer during the
+// handshake.
+type PeerInfo struct {
+	Address  Address  // The peer's Perun address.
+	Version  uint16   // The negotiated protocol version.
+	Features Features // The message types the peer understands.
+}
+
+// Supports returns whether the peer can decode messages of Type t, that is,
+// whether t is known in the negotiated protocol version and contained in the
+// peer's Features.
+func (i PeerInfo) Supports(t Type) bool {
+	return knownIn(t, i.Version) && i.Features.Supports(t)
+}
+
+// negotiateVersion returns the protocol version to use with a peer that
+// announced the given version, or an error if it is not supported.
+func negotiateVersion(peer uint16) (uint16, error) {
+	if peer == LegacyProtocolVersion {
+		return 0, errors.New("peer uses the legacy handshake without protocol version, it must be upgraded")
+	}
+	if peer < MinProtocolVersion {
+		return 0, errors.Errorf(
+			"peer protocol version %d is too old, need at least %d",
+			peer, MinProtocolVersion)
+	}
+	if peer < ProtocolVersion {
+		return peer, nil
+	}
+	return ProtocolVersion, nil
+}
+
+// UnsupportedMsgError is returned when a message is sent to a peer that did
+// not announce support for its Type during the handshake.
+type UnsupportedMsgError struct {
+	Peer Address // The peer that the message was supposed to be sent to.
+	Type Type    // The Type of the refused message.
+}
+
+func (e *UnsupportedMsgError) Error() string {
+	return fmt.Sprintf("peer %v does not support message type %v", e.Peer, e.Type)
+}
+
+func newUnsupportedMsgError(peer Address, t Type) error {
+	return errors.WithStack(&UnsupportedMsgError{Peer: peer, Type: t})
+}
+
+// IsUnsupportedMsgError returns true if the error was an UnsupportedMsgError.
+func IsUnsupportedMsgError(err error) bool {
+	cause := errors.Cause(err)
+	_, ok := cause.(*UnsupportedMsgError)
+	return ok
+}
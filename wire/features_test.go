@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iotest "perun.network/go-perun/pkg/io/test"
+)
+
+func TestFeatures(t *testing.T) {
+	var f Features
+	for _, typ := range []Type{Ping, ChannelSync, 63, 64, 255} {
+		assert.False(t, f.Supports(typ))
+		f.Set(typ)
+		assert.True(t, f.Supports(typ))
+	}
+	assert.False(t, f.Supports(Pong))
+	iotest.GenericSerializerTest(t, &f)
+}
+
+func TestLocalFeatures(t *testing.T) {
+	f := LocalFeatures()
+	for _, typ := range []Type{Ping, Pong, Shutdown, AuthResponse} {
+		assert.True(t, f.Supports(typ), "type %v must be supported", typ)
+	}
+	assert.False(t, f.Supports(LastType), "types without decoder must not be supported")
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	v, err := negotiateVersion(ProtocolVersion)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, v)
+
+	v, err = negotiateVersion(ProtocolVersion + 1)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, v, "newer peers must use our version")
+
+	v, err = negotiateVersion(MinProtocolVersion)
+	require.NoError(t, err)
+	assert.Equal(t, MinProtocolVersion, v, "older supported peers must use their version")
+
+	_, err = negotiateVersion(MinProtocolVersion - 1)
+	assert.Error(t, err, "too old versions must be rejected")
+
+	_, err = negotiateVersion(LegacyProtocolVersion)
+	assert.Error(t, err, "legacy peers must be rejected")
+}
+
+func TestPeerInfo_Supports(t *testing.T) {
+	info := PeerInfo{Version: ProtocolVersion, Features: LocalFeatures()}
+	assert.True(t, info.Supports(Ping))
+
+	info.Version = msgVersions[Forward] - 1
+	info.Features.Set(Forward)
+	assert.False(t, info.Supports(Forward), "types newer than the negotiated version must not be supported")
+	assert.True(t, info.Supports(Ping))
+
+	info.Version = msgVersions[ChannelProposal] - 1
+	info.Features.Set(ChannelProposal)
+	assert.False(t, info.Supports(ChannelProposal), "types encoded differently in the negotiated version must not be supported")
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	stdsync "sync"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+	"perun.network/go-perun/pkg/sync"
+	"perun.network/go-perun/wallet"
+)
+
+func init() {
+	RegisterDecoder(Forward,
+		func(r io.Reader) (Msg, error) {
+			var m ForwardMsg
+			return &m, m.Decode(r)
+		})
+}
+
+var _ Msg = (*ForwardMsg)(nil)
+
+// ForwardMsg carries an Envelope to a recipient that the sender cannot reach
+// directly. Relay nodes pass it on towards the Envelope's Recipient. The
+// Envelope is signed by its Sender together with a sequence number so that the
+// recipient can authenticate the original sender independently of the relays
+// and reject replayed messages.
+type ForwardMsg struct {
+	Envelope Envelope   // The forwarded message and its end-to-end routing information.
+	Seq      uint64     // The Sender's sequence number, starting at 1 on each relayed connection.
+	Sig      wallet.Sig // The Sender's signature on the encoded Envelope and Seq.
+}
+
+// NewForwardMsg creates a ForwardMsg for the Envelope with sequence number
+// seq, signed by id, which has to be the Envelope's Sender.
+func NewForwardMsg(id Account, env Envelope, seq uint64) (*ForwardMsg, error) {
+	if !env.Sender.Equals(id.Address()) {
+		return nil, errors.New("only the sender can sign an envelope")
+	}
+	data, err := encodeSigned(&env, seq)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := id.SignData(data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing envelope")
+	}
+	return &ForwardMsg{Envelope: env, Seq: seq, Sig: sig}, nil
+}
+
+// Verify verifies that the Envelope and sequence number were signed by the
+// Envelope's Sender.
+func (m *ForwardMsg) Verify() error {
+	data, err := encodeSigned(&m.Envelope, m.Seq)
+	if err != nil {
+		return err
+	}
+	if ok, err := wallet.VerifySignature(data, m.Sig, m.Envelope.Sender); err != nil {
+		return errors.WithMessage(err, "verifying envelope signature")
+	} else if !ok {
+		return errors.New("invalid envelope signature")
+	}
+	return nil
+}
+
+// Type returns Forward.
+func (m *ForwardMsg) Type() Type {
+	return Forward
+}
+
+// Encode encodes a ForwardMsg into an io.Writer.
+func (m *ForwardMsg) Encode(w io.Writer) error {
+	return perunio.Encode(w, &m.Envelope, m.Seq, m.Sig)
+}
+
+// Decode decodes a ForwardMsg from an io.Reader. Nested ForwardMsgs are
+// rejected.
+func (m *ForwardMsg) Decode(r io.Reader) (err error) {
+	if err = m.Envelope.Decode(r); err != nil {
+		return errors.WithMessage(err, "decoding envelope")
+	}
+	if m.Envelope.Msg.Type() == Forward {
+		return errors.New("nested forward message")
+	}
+	if err = perunio.Decode(r, &m.Seq); err != nil {
+		return errors.WithMessage(err, "decoding sequence number")
+	}
+	m.Sig, err = wallet.DecodeSig(r)
+	return errors.WithMessage(err, "decoding signature")
+}
+
+// encodeSigned encodes the signed part of a ForwardMsg.
+func encodeSigned(env *Envelope, seq uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := perunio.Encode(&buf, env, seq); err != nil {
+		return nil, errors.WithMessage(err, "encoding envelope")
+	}
+	return buf.Bytes(), nil
+}
+
+// A RouteHinter knows over which relay a peer can be reached. If the Dialer
+// of an EndpointRegistry is a RouteHinter, peers with a route hint are not
+// dialed directly but reached via ForwardMsgs over their relay.
+type RouteHinter interface {
+	// Route returns the relay of the given peer, if there is one.
+	Route(addr Address) (relay Address, ok bool)
+}
+
+var _ RouteHinter = (*Routes)(nil)
+
+// Routes is a thread-safe table of route hints. It can be embedded into
+// Dialers to make them RouteHinters.
+type Routes struct {
+	mutex  stdsync.RWMutex
+	routes map[wallet.AddrKey]Address
+}
+
+// RegisterRoute registers that the peer with address addr can be reached via
+// the relay node with address relay.
+func (r *Routes) RegisterRoute(addr, relay Address) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.routes == nil {
+		r.routes = make(map[wallet.AddrKey]Address)
+	}
+	r.routes[wallet.Key(addr)] = relay
+}
+
+// Route implements RouteHinter.Route().
+func (r *Routes) Route(addr Address) (Address, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	relay, ok := r.routes[wallet.Key(addr)]
+	return relay, ok
+}
+
+var _ Conn = (*relayConn)(nil)
+
+// relayConn is a virtual connection to a remote peer whose messages are sent
+// and received as ForwardMsgs over a relay peer.
+type relayConn struct {
+	id     Account   // Own identity, used to sign forwarded messages.
+	relay  *Endpoint // The relay the messages are sent over.
+	remote Address   // The authenticated remote peer.
+	msgs   chan Msg  // Queued received messages.
+
+	sending stdsync.Mutex // Sends in order of the sequence numbers.
+	seq     uint64        // The sequence number of the last sent message.
+	recvSeq uint64        // The sequence number of the last received message, guarded by the registry's mutex.
+
+	sync.Closer
+}
+
+func newRelayConn(id Account, relay *Endpoint, remote Address) *relayConn {
+	c := &relayConn{
+		id:     id,
+		relay:  relay,
+		remote: remote,
+		msgs:   make(chan Msg, receiverBufferSize),
+	}
+	relay.OnCloseAlways(func() { c.Close() })
+	return c
+}
+
+func (c *relayConn) Send(m Msg) error {
+	c.sending.Lock()
+	defer c.sending.Unlock()
+
+	c.seq++
+	fwd, err := NewForwardMsg(c.id, Envelope{Sender: c.id.Address(), Recipient: c.remote, Msg: m}, c.seq)
+	if err != nil {
+		c.Close()
+		return err
+	}
+	if err := c.relay.Send(c.Ctx(), fwd); err != nil {
+		c.Close()
+		return errors.WithMessage(err, "sending to relay")
+	}
+	return nil
+}
+
+func (c *relayConn) Recv() (Msg, error) {
+	select {
+	case m := <-c.msgs:
+		return m, nil
+	case <-c.Closed():
+		return nil, errors.New("relayed connection closed")
+	}
+}
+
+// deliver queues a message received from the remote peer without blocking.
+// If the queue is full, the connection is closed because messages must not be
+// lost silently.
+func (c *relayConn) deliver(m Msg) {
+	select {
+	case c.msgs <- m:
+	case <-c.Closed():
+	default:
+		log.WithField("peer", c.remote).Warn("relayConn: receive queue full, closing")
+		c.Close()
+	}
+}
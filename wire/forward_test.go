@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestForwardMsg(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xf0))
+	sender := wallettest.NewRandomAccount(rng)
+	env := Envelope{
+		Sender:    sender.Address(),
+		Recipient: wallettest.NewRandomAddress(rng),
+		Msg:       NewPingMsg(),
+	}
+
+	_, err := NewForwardMsg(wallettest.NewRandomAccount(rng), env, 1)
+	assert.Error(t, err, "only the sender may sign")
+
+	m, err := NewForwardMsg(sender, env, 1)
+	require.NoError(t, err)
+	assert.NoError(t, m.Verify())
+	TestMsg(t, m)
+
+	m.Seq++
+	assert.Error(t, m.Verify(), "modified sequence number")
+	m.Seq--
+	m.Envelope.Recipient = wallettest.NewRandomAddress(rng)
+	assert.Error(t, m.Verify(), "modified envelope")
+}
+
+func TestRoutes(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xf1))
+	addr, relay := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+	var r Routes
+
+	_, ok := r.Route(addr)
+	assert.False(t, ok)
+	r.RegisterRoute(addr, relay)
+	hint, ok := r.Route(addr)
+	assert.True(t, ok)
+	assert.True(t, hint.Equals(relay))
+}
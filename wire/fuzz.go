@@ -0,0 +1,29 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// FuzzDecode decodes data as the content of a single message frame, as it
+// would be received by an ioConn. It is an entry point for go-fuzz and
+// returns 1 if data decoded to a valid message and 0 otherwise. It panics if a
+// successfully decoded message cannot be encoded again.
+func FuzzDecode(data []byte) int {
+	r := bytes.NewReader(data)
+	m, err := Decode(r)
+	if err != nil || r.Len() != 0 {
+		return 0
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(m, &buf); err != nil {
+		panic(fmt.Sprintf("re-encoding decoded %v message: %v", m.Type(), err))
+	}
+	return 1
+}
@@ -6,33 +6,100 @@
 package wire
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	"perun.network/go-perun/wallet"
 )
 
-var _ Conn = (*ioConn)(nil)
+// DefaultMaxMsgSize is the default maximum size in bytes of an encoded
+// message that is sent or received over an io stream.
+const DefaultMaxMsgSize = 1 << 20
+
+// frameHeaderLen is the length of the message size prefix of each frame.
+const frameHeaderLen = 4
+
+// framePreamble is sent before the first frame of a connection. Its first byte
+// is no valid message Type, so legacy nodes, which predate the framing, refuse
+// the connection. Conversely, legacy nodes start the connection with an
+// unframed AuthResponse message, which is detected by its Type byte.
+var framePreamble = [...]byte{0xff, 'p', 'r', 'n'}
+
+var _ versionedConn = (*ioConn)(nil)
 
 // IoConn is a connection that communicates its messages over an io stream.
+// Each message is sent in a frame that is prefixed with its length, so that
+// the receiver can reject oversized messages before reading them.
 type ioConn struct {
-	conn io.ReadWriteCloser
+	conn       io.ReadWriteCloser
+	maxMsgSize uint32
+
+	sentPreamble bool // Only accessed by Send, which is not called concurrently.
+	recvPreamble bool // Only accessed by Recv, which is not called concurrently.
+
+	// version is the negotiated protocol version, zero during the handshake.
+	// It is set before the Recv calls after the handshake.
+	version uint16
 }
 
-// NewIoConn creates a peer message connection from an io stream.
+// versionedConn is a Conn that drops received messages which are not encoded
+// as in the protocol version negotiated during the handshake.
+type versionedConn interface {
+	Conn
+	setVersion(version uint16)
+}
+
+// NewIoConn creates a peer message connection from an io stream. Messages
+// larger than DefaultMaxMsgSize are rejected.
 func NewIoConn(conn io.ReadWriteCloser) Conn {
+	return NewIoConnWithMaxMsgSize(conn, DefaultMaxMsgSize)
+}
+
+// NewIoConnWithMaxMsgSize creates a peer message connection from an io stream
+// that neither sends nor receives messages larger than maxMsgSize bytes.
+func NewIoConnWithMaxMsgSize(conn io.ReadWriteCloser, maxMsgSize uint32) Conn {
 	return &ioConn{
-		conn: conn,
+		conn:       conn,
+		maxMsgSize: maxMsgSize,
 	}
 }
 
 func (c *ioConn) Send(m Msg) error {
-	if err := Encode(m, c.conn); err != nil {
+	var buf bytes.Buffer
+	var offset int
+	if !c.sentPreamble {
+		buf.Write(framePreamble[:])
+		offset = len(framePreamble)
+	}
+	buf.Write(make([]byte, frameHeaderLen)) // reserve space for the length.
+	if err := Encode(m, &buf); err != nil {
 		c.conn.Close()
 		return err
 	}
+
+	data := buf.Bytes()
+	frame := data[offset:]
+	size := len(frame) - frameHeaderLen
+	if size > int(c.maxMsgSize) {
+		c.conn.Close()
+		return errors.Errorf("message size %d exceeds maximum %d", size, c.maxMsgSize)
+	}
+	binary.LittleEndian.PutUint32(frame, uint32(size))
+
+	if _, err := c.conn.Write(data); err != nil {
+		c.conn.Close()
+		return errors.Wrap(err, "writing frame")
+	}
+	c.sentPreamble = true
 	return nil
 }
 
 func (c *ioConn) Recv() (Msg, error) {
-	m, err := Decode(c.conn)
+	m, err := c.recv()
 	if err != nil {
 		c.conn.Close()
 		return nil, err
@@ -40,6 +107,86 @@ func (c *ioConn) Recv() (Msg, error) {
 	return m, nil
 }
 
+func (c *ioConn) setVersion(version uint16) {
+	c.version = version
+}
+
+func (c *ioConn) recv() (Msg, error) {
+	if !c.recvPreamble {
+		if m, err := c.recvPreambleOrLegacy(); m != nil || err != nil {
+			return m, err
+		}
+		c.recvPreamble = true
+	}
+
+	for {
+		frame, err := c.recvFrame()
+		if err != nil {
+			return nil, err
+		}
+		// Messages that the peer encodes in an older format are dropped.
+		if t := Type(frame[0]); c.version != 0 && !knownIn(t, c.version) {
+			log.Warnf("Dropping %v message of protocol version %d", t, c.version)
+			continue
+		}
+
+		// Decoding from a bytes.Reader bounds the decoding budget by the frame size.
+		r := bytes.NewReader(frame)
+		m, err := Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		if r.Len() != 0 {
+			return nil, errors.Errorf("%d trailing bytes after %v message", r.Len(), m.Type())
+		}
+		return m, nil
+	}
+}
+
+// recvFrame reads the next non-empty frame.
+func (c *ioConn) recvFrame() ([]byte, error) {
+	var header [frameHeaderLen]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		return nil, errors.Wrap(err, "reading frame header")
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	if size > c.maxMsgSize {
+		return nil, errors.Errorf("message size %d exceeds maximum %d", size, c.maxMsgSize)
+	} else if size == 0 {
+		return nil, errors.New("empty frame")
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, frame); err != nil {
+		return nil, errors.Wrap(err, "reading frame")
+	}
+	return frame, nil
+}
+
+// recvPreambleOrLegacy reads the frame preamble. If the peer is a legacy node,
+// it instead decodes its unframed AuthResponse message, which announces the
+// LegacyProtocolVersion.
+func (c *ioConn) recvPreambleOrLegacy() (Msg, error) {
+	var preamble [len(framePreamble)]byte
+	if _, err := io.ReadFull(c.conn, preamble[:1]); err != nil {
+		return nil, errors.Wrap(err, "reading frame preamble")
+	}
+	if preamble[0] == byte(AuthResponse) {
+		addr, err := wallet.DecodeAddress(c.conn)
+		if err != nil {
+			return nil, errors.WithMessage(err, "decoding legacy AuthResponse")
+		}
+		return &AuthResponseMsg{Address: addr, Version: LegacyProtocolVersion}, nil
+	}
+	if _, err := io.ReadFull(c.conn, preamble[1:]); err != nil {
+		return nil, errors.Wrap(err, "reading frame preamble")
+	}
+	if preamble != framePreamble {
+		return nil, errors.Errorf("invalid frame preamble %x", preamble)
+	}
+	return nil, nil
+}
+
 func (c *ioConn) Close() error {
 	return c.conn.Close()
 }
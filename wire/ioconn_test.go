@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestIoConn_SendRecv(t *testing.T) {
+	a, b := newPipeConnPair()
+	defer a.Close()
+	defer b.Close()
+
+	msg := &ShutdownMsg{"bye"}
+	go func() { assert.NoError(t, a.Send(msg)) }()
+	m, err := b.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, msg, m)
+}
+
+func TestIoConn_MaxMsgSize(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		c0, c1 := net.Pipe()
+		defer c1.Close()
+		a := NewIoConnWithMaxMsgSize(c0, 8)
+		assert.Error(t, a.Send(&ShutdownMsg{"this reason is too long"}))
+		assert.Error(t, a.Send(NewPingMsg()), "conn must be closed after failure")
+	})
+
+	t.Run("recv", func(t *testing.T) {
+		c0, c1 := net.Pipe()
+		defer c1.Close()
+		a := NewIoConnWithMaxMsgSize(c0, 8)
+
+		// Announce a huge message, the receiver must not wait for its content.
+		go func() {
+			var header [frameHeaderLen]byte
+			binary.LittleEndian.PutUint32(header[:], 1<<31)
+			c1.Write(append(framePreamble[:], header[:]...)) //nolint:errcheck
+		}()
+		_, err := a.Recv()
+		assert.Error(t, err)
+	})
+}
+
+func TestIoConn_TrailingBytes(t *testing.T) {
+	c0, c1 := net.Pipe()
+	defer c1.Close()
+	a := NewIoConn(c0)
+
+	go func() {
+		frame := []byte{0, 0, 0, 0, byte(Shutdown), 0, 0, 42}
+		binary.LittleEndian.PutUint32(frame, uint32(len(frame)-frameHeaderLen))
+		c1.Write(append(framePreamble[:], frame...)) //nolint:errcheck
+	}()
+	_, err := a.Recv()
+	assert.Error(t, err)
+}
+
+func TestIoConn_InvalidPreamble(t *testing.T) {
+	c0, c1 := net.Pipe()
+	defer c1.Close()
+	a := NewIoConn(c0)
+
+	go c1.Write([]byte{0xff, 'a', 'b', 'c'}) //nolint:errcheck
+	_, err := a.Recv()
+	assert.Error(t, err)
+}
+
+func TestIoConn_Legacy(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xDDDD))
+	c0, c1 := net.Pipe()
+	defer c1.Close()
+	a := NewIoConn(c0)
+
+	// Legacy nodes send an unframed AuthResponse that only holds the address.
+	addr := wallettest.NewRandomAddress(rng)
+	go func() {
+		c1.Write([]byte{byte(AuthResponse)}) //nolint:errcheck
+		addr.Encode(c1)                      //nolint:errcheck
+	}()
+	m, err := a.Recv()
+	require.NoError(t, err)
+	require.IsType(t, (*AuthResponseMsg)(nil), m)
+	assert.True(t, addr.Equals(m.(*AuthResponseMsg).Address))
+	assert.Equal(t, LegacyProtocolVersion, m.(*AuthResponseMsg).Version)
+}
+
+func TestIoConn_OldVersion(t *testing.T) {
+	c0, c1 := net.Pipe()
+	defer c1.Close()
+	a := NewIoConn(c0)
+	a.(versionedConn).setVersion(msgVersions[ChannelProposal] - 1)
+
+	msg := &ShutdownMsg{"bye"}
+	go func() {
+		// The ChannelProposal is encoded in the older format and dropped.
+		c1.Write(append(framePreamble[:], newFrame([]byte{byte(ChannelProposal), 1, 2, 3})...)) //nolint:errcheck
+		var buf bytes.Buffer
+		require.NoError(t, Encode(msg, &buf))
+		c1.Write(newFrame(buf.Bytes())) //nolint:errcheck
+	}()
+	m, err := a.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, msg, m)
+}
+
+// newFrame prefixes the encoded message with its size.
+func newFrame(msg []byte) []byte {
+	frame := make([]byte, frameHeaderLen, frameHeaderLen+len(msg))
+	binary.LittleEndian.PutUint32(frame, uint32(len(msg)))
+	return append(frame, msg...)
+}
@@ -119,6 +119,7 @@ const (
 	ChannelUpdateAcc
 	ChannelUpdateRej
 	ChannelSync
+	Forward
 	LastType // upper bound on the message types of the Perun wire protocol
 )
 
@@ -134,6 +135,7 @@ var typeNames = map[Type]string{
 	ChannelUpdateAcc:   "ChannelUpdateAcc",
 	ChannelUpdateRej:   "ChannelUpdateRej",
 	ChannelSync:        "ChannelSync",
+	Forward:            "Forward",
 }
 
 // String returns the name of a message type if it is valid and name known
@@ -16,13 +16,17 @@ import (
 )
 
 // NetDialer is a simple lookup-table based dialer that can dial known peers.
-// New peer addresses can be added via Register().
+// New peer addresses can be added via Register(). Peers that cannot be dialed
+// directly, e.g., because they are behind a firewall, can be reached via a
+// relay node registered with RegisterRoute().
 type NetDialer struct {
-	mutex   sync.RWMutex       // Protects peers.
-	peers   map[Address]string // Known peer addresses.
-	dialer  net.Dialer         // Used to dial connections.
-	network string             // The socket type.
+	mutex      sync.RWMutex       // Protects peers and maxMsgSize.
+	peers      map[Address]string // Known peer addresses.
+	dialer     net.Dialer         // Used to dial connections.
+	network    string             // The socket type.
+	maxMsgSize uint32             // Maximum message size of dialed connections.
 
+	Routes // Route hints for peers that can only be reached via relays.
 	pkgsync.Closer
 }
 
@@ -34,9 +38,10 @@ var _ Dialer = (*NetDialer)(nil)
 // controls the type of connection that the dialer can dial.
 func NewNetDialer(network string, defaultTimeout time.Duration) *NetDialer {
 	return &NetDialer{
-		peers:   make(map[Address]string),
-		dialer:  net.Dialer{Timeout: defaultTimeout},
-		network: network,
+		peers:      make(map[Address]string),
+		dialer:     net.Dialer{Timeout: defaultTimeout},
+		network:    network,
+		maxMsgSize: DefaultMaxMsgSize,
 	}
 }
 
@@ -58,6 +63,13 @@ func (d *NetDialer) get(addr Address) (string, bool) {
 	return host, ok
 }
 
+func (d *NetDialer) getMaxMsgSize() uint32 {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.maxMsgSize
+}
+
 // Dial implements Dialer.Dial().
 func (d *NetDialer) Dial(ctx context.Context, addr Address) (Conn, error) {
 	done := make(chan struct{})
@@ -85,7 +97,7 @@ func (d *NetDialer) Dial(ctx context.Context, addr Address) (Conn, error) {
 		return nil, errors.Wrap(err, "failed to dial peer")
 	}
 
-	return NewIoConn(conn), nil
+	return NewIoConnWithMaxMsgSize(conn, d.getMaxMsgSize()), nil
 }
 
 // Register registers a network address for a peer address.
@@ -95,3 +107,12 @@ func (d *NetDialer) Register(addr Address, address string) {
 
 	d.peers[addr] = address
 }
+
+// SetMaxMsgSize sets the maximum size of messages sent or received over
+// connections that are dialed afterwards. It defaults to DefaultMaxMsgSize.
+func (d *NetDialer) SetMaxMsgSize(size uint32) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.maxMsgSize = size
+}
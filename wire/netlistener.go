@@ -14,6 +14,7 @@ import (
 // NetListener is a TCP Listener.
 type NetListener struct {
 	net.Listener
+	maxMsgSize uint32 // Maximum message size of accepted connections.
 }
 
 var _ Listener = (*NetListener)(nil)
@@ -26,7 +27,7 @@ func NewNetListener(network string, address string) (*NetListener, error) {
 			"failed to create listener for '%s'", address)
 	}
 
-	return &NetListener{Listener: l}, nil
+	return &NetListener{Listener: l, maxMsgSize: DefaultMaxMsgSize}, nil
 }
 
 // NewTCPListener is a short-hand version of NewNetListener for TCP listeners.
@@ -46,5 +47,12 @@ func (l *NetListener) Accept() (Conn, error) {
 		return nil, errors.Wrap(err, "accept failed")
 	}
 
-	return NewIoConn(conn), nil
+	return NewIoConnWithMaxMsgSize(conn, l.maxMsgSize), nil
+}
+
+// SetMaxMsgSize sets the maximum size of messages sent or received over
+// accepted connections. It defaults to DefaultMaxMsgSize. It must not be
+// called concurrently to Accept.
+func (l *NetListener) SetMaxMsgSize(size uint32) {
+	l.maxMsgSize = size
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"time"
+)
+
+type (
+	// RateLimit is a token bucket limit on the number of messages of a Type
+	// that are accepted from a single peer. The bucket holds at most Burst
+	// tokens and is refilled with Rate tokens per second. Each received message
+	// consumes one token.
+	RateLimit struct {
+		Rate  float64 // Tokens refilled per second.
+		Burst int     // Capacity of the bucket.
+	}
+
+	// RateLimitConfig configures the per-peer limits on inbound messages.
+	// Messages that exceed their limit are dropped. A peer is disconnected
+	// once more than MaxViolations of its messages have been dropped. If
+	// BanDuration is not zero, the disconnected peer is also banned for that
+	// long, that is, neither accepted nor dialed.
+	RateLimitConfig struct {
+		Limits        map[Type]RateLimit // Types without entry are unlimited.
+		MaxViolations int
+		BanDuration   time.Duration
+	}
+
+	// rateLimiter enforces a RateLimitConfig on the messages of a single peer.
+	// It is only used from the peer's receive loop and not thread-safe.
+	rateLimiter struct {
+		buckets       map[Type]*tokenBucket
+		violations    int
+		maxViolations int
+		onExceeded    func() // Called before the peer is disconnected.
+	}
+
+	tokenBucket struct {
+		RateLimit
+		tokens float64
+		last   time.Time
+	}
+)
+
+// newRateLimiter creates a limiter with full buckets for the given config.
+// onExceeded is called when the peer is disconnected because it violated the
+// limits too often.
+func newRateLimiter(cfg RateLimitConfig, now time.Time, onExceeded func()) *rateLimiter {
+	l := &rateLimiter{
+		buckets:       make(map[Type]*tokenBucket, len(cfg.Limits)),
+		maxViolations: cfg.MaxViolations,
+		onExceeded:    onExceeded,
+	}
+	for t, lim := range cfg.Limits {
+		l.buckets[t] = &tokenBucket{RateLimit: lim, tokens: float64(lim.Burst), last: now}
+	}
+	return l
+}
+
+// allow returns whether a message of Type t that is received at time now is
+// within the limits. Otherwise, the violation is counted.
+func (l *rateLimiter) allow(t Type, now time.Time) bool {
+	b, ok := l.buckets[t]
+	if !ok || b.take(now) {
+		return true
+	}
+	l.violations++
+	return false
+}
+
+// exceeded returns whether the peer violated the limits too often.
+func (l *rateLimiter) exceeded() bool {
+	return l.violations > l.maxViolations
+}
+
+// take refills the bucket for the time passed since the last call and then
+// tries to take a token from it.
+func (b *tokenBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.Rate
+		if b.tokens > float64(b.Burst) {
+			b.tokens = float64(b.Burst)
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	cfg := RateLimitConfig{
+		Limits:        map[Type]RateLimit{Ping: {Rate: 1, Burst: 2}},
+		MaxViolations: 1,
+	}
+	l := newRateLimiter(cfg, now, func() {})
+
+	assert.True(t, l.allow(Ping, now))
+	assert.True(t, l.allow(Ping, now))
+	assert.False(t, l.allow(Ping, now), "burst must be exhausted")
+	assert.False(t, l.exceeded())
+	assert.True(t, l.allow(Pong, now), "unlimited types must be allowed")
+
+	now = now.Add(time.Second)
+	assert.True(t, l.allow(Ping, now), "bucket must be refilled")
+	assert.False(t, l.allow(Ping, now))
+	assert.True(t, l.exceeded())
+
+	now = now.Add(time.Hour)
+	assert.True(t, l.allow(Ping, now))
+	assert.True(t, l.allow(Ping, now))
+	assert.False(t, l.allow(Ping, now), "refill must be capped by burst")
+}
+
+func TestEndpoint_RateLimitExceeded(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0x1337))
+	addr := wallettest.NewRandomAddress(rng)
+	conn := newMockConn(nil)
+	p := newEndpoint(addr, conn, nil)
+	exceeded := make(chan struct{})
+	p.limiter = newRateLimiter(RateLimitConfig{
+		Limits: map[Type]RateLimit{Ping: {Rate: 0, Burst: 1}},
+	}, time.Now(), func() { close(exceeded) })
+	recv := NewReceiver()
+	assert.NoError(t, p.Subscribe(recv, func(Msg) bool { return true }))
+	go p.recvLoop()
+
+	conn.recvQueue <- NewPingMsg()
+	conn.recvQueue <- NewPingMsg()
+
+	select {
+	case <-exceeded:
+	case <-time.After(timeout):
+		t.Fatal("exceeding rate limits must be detected")
+	}
+	<-p.Closed()
+	assert.True(t, p.IsClosed(), "peer must be disconnected")
+}
+
+func TestRegistry_Ban(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0xba11))
+	r := NewEndpointRegistry(wallettest.NewRandomAccount(rng), func(*Endpoint) {}, newMockDialer())
+	addr := wallettest.NewRandomAddress(rng)
+
+	r.Ban(addr, time.Hour)
+	_, err := r.Get(context.Background(), addr)
+	assert.Error(t, err, "banned peers must not be dialed")
+
+	r.Ban(addr, -time.Second) // expired ban
+	r.mutex.Lock()
+	assert.False(t, r.isBanned(addr))
+	r.mutex.Unlock()
+}
+
+func TestRegistry_setupConn_Banned(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0xba12))
+	r := NewEndpointRegistry(wallettest.NewRandomAccount(rng), func(*Endpoint) {}, newMockDialer())
+	remoteID := wallettest.NewRandomAccount(rng)
+	r.Ban(remoteID.Address(), time.Hour)
+
+	a, b := newPipeConnPair()
+	go ExchangeAddrs(context.Background(), remoteID, b)
+	assert.Error(t, r.setupConn(a))
+	assert.False(t, r.Has(remoteID.Address()))
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/log"
+	perunio "perun.network/go-perun/pkg/io"
+)
+
+// A Record is an Envelope that was sent or received at a certain time.
+type Record struct {
+	Time time.Time
+	Envelope
+}
+
+// Encode encodes a Record into an io.Writer.
+func (r *Record) Encode(w io.Writer) error {
+	return perunio.Encode(w, r.Time, &r.Envelope)
+}
+
+// Decode decodes a Record from an io.Reader.
+func (r *Record) Decode(rd io.Reader) error {
+	return perunio.Decode(rd, &r.Time, &r.Envelope)
+}
+
+// ReadRecords reads all Records from an io.Reader until it is exhausted, e.g.,
+// from a file that was written by a Recorder.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var recs []Record
+	for {
+		var rec Record
+		if err := rec.Decode(r); err != nil {
+			if errors.Cause(err) == io.EOF {
+				return recs, nil
+			}
+			return recs, errors.WithMessagef(err, "decoding record %d", len(recs))
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// A Recorder writes timestamped Envelopes to an io.Writer, e.g., a file. It
+// can be shared by multiple connections and is thread-safe.
+type Recorder struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// NewRecorder creates a Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Record writes an Envelope together with the current time.
+func (r *Recorder) Record(env *Envelope) error {
+	return r.write(&Record{Time: now(), Envelope: *env})
+}
+
+func (r *Recorder) write(rec *Record) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return rec.Encode(r.w)
+}
+
+// now returns the current time without monotonic clock reading, see
+// newPingPongMsg.
+func now() time.Time {
+	return time.Unix(0, time.Now().UnixNano())
+}
+
+var _ Conn = (*recordingConn)(nil)
+
+// recordingConn is a Conn that records all messages it sends and receives.
+// The peer's address is learned from the AuthResponse message it receives.
+// Until then, sent messages are held back from the Recorder.
+type recordingConn struct {
+	Conn
+	rec   *Recorder
+	local Address
+
+	mutex   sync.Mutex
+	remote  Address
+	pending []Record // Sent messages before the remote address was known.
+}
+
+// NewRecordingConn wraps a Conn of the node with the given local address so
+// that all of its messages are written to the Recorder.
+func NewRecordingConn(conn Conn, local Address, rec *Recorder) Conn {
+	return &recordingConn{Conn: conn, rec: rec, local: local}
+}
+
+func (c *recordingConn) Send(m Msg) error {
+	if err := c.Conn.Send(m); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.remote == nil {
+		c.pending = append(c.pending, Record{Time: now(), Envelope: Envelope{Sender: c.local, Msg: m}})
+		return nil
+	}
+	c.record(&Envelope{Sender: c.local, Recipient: c.remote, Msg: m})
+	return nil
+}
+
+func (c *recordingConn) Recv() (Msg, error) {
+	m, err := c.Conn.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if auth, ok := m.(*AuthResponseMsg); ok && c.remote == nil {
+		c.remote = auth.Address
+		for i := range c.pending {
+			c.pending[i].Recipient = c.remote
+			c.write(&c.pending[i])
+		}
+		c.pending = nil
+	}
+	if c.remote != nil {
+		c.record(&Envelope{Sender: c.remote, Recipient: c.local, Msg: m})
+	}
+	return m, nil
+}
+
+// record records an Envelope. Errors are only logged so that recording
+// failures do not affect the connection.
+func (c *recordingConn) record(env *Envelope) {
+	c.write(&Record{Time: now(), Envelope: *env})
+}
+
+// write writes a timestamped Record, logging errors like record.
+func (c *recordingConn) write(rec *Record) {
+	if err := c.rec.write(rec); err != nil {
+		log.WithError(err).Warnf("Failed to record %v message", rec.Msg.Type())
+	}
+}
+
+var _ Dialer = (*RecordingDialer)(nil)
+
+// RecordingDialer is a Dialer whose connections are recorded.
+type RecordingDialer struct {
+	Dialer
+	rec   *Recorder
+	local Address
+}
+
+// NewRecordingDialer wraps a Dialer of the node with the given local address
+// so that all messages of dialed connections are written to the Recorder.
+func NewRecordingDialer(d Dialer, local Address, rec *Recorder) *RecordingDialer {
+	return &RecordingDialer{Dialer: d, rec: rec, local: local}
+}
+
+// Dial implements Dialer.Dial().
+func (d *RecordingDialer) Dial(ctx context.Context, addr Address) (Conn, error) {
+	conn, err := d.Dialer.Dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecordingConn(conn, d.local, d.rec), nil
+}
+
+var _ Listener = (*RecordingListener)(nil)
+
+// RecordingListener is a Listener whose connections are recorded.
+type RecordingListener struct {
+	Listener
+	rec   *Recorder
+	local Address
+}
+
+// NewRecordingListener wraps a Listener of the node with the given local
+// address so that all messages of accepted connections are written to the
+// Recorder.
+func NewRecordingListener(l Listener, local Address, rec *Recorder) *RecordingListener {
+	return &RecordingListener{Listener: l, rec: rec, local: local}
+}
+
+// Accept implements Listener.Accept().
+func (l *RecordingListener) Accept() (Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewRecordingConn(conn, l.local, l.rec), nil
+}
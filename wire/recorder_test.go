@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wallettest "perun.network/go-perun/wallet/test"
+)
+
+func TestRecordingConn(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7ec))
+	acc0, acc1 := wallettest.NewRandomAccount(rng), wallettest.NewRandomAccount(rng)
+	var file bytes.Buffer
+	a, b := newPipeConnPair()
+	a = NewRecordingConn(a, acc0.Address(), NewRecorder(&file))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := ExchangeAddrs(context.Background(), acc1, b)
+		assert.NoError(t, err)
+		assert.NoError(t, b.Send(NewPingMsg()))
+	}()
+	_, err := ExchangeAddrs(context.Background(), acc0, a)
+	require.NoError(t, err)
+	m, err := a.Recv()
+	require.NoError(t, err)
+	<-done
+	require.NoError(t, a.Close())
+
+	recs, err := ReadRecords(&file)
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	for _, rec := range recs[:2] {
+		assert.Equal(t, AuthResponse, rec.Msg.Type())
+		assert.False(t, rec.Time.IsZero())
+	}
+	ping := recs[2]
+	assert.Equal(t, m, ping.Msg)
+	assert.True(t, ping.Sender.Equals(acc1.Address()))
+	assert.True(t, ping.Recipient.Equals(acc0.Address()))
+}
+
+func TestReadRecords_Truncated(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7ed))
+	var file bytes.Buffer
+	rec := NewRecorder(&file)
+	env := &Envelope{
+		Sender:    wallettest.NewRandomAddress(rng),
+		Recipient: wallettest.NewRandomAddress(rng),
+		Msg:       NewPingMsg(),
+	}
+	require.NoError(t, rec.Record(env))
+	require.NoError(t, rec.Record(env))
+
+	data := file.Bytes()
+	recs, err := ReadRecords(bytes.NewReader(data[:len(data)-1]))
+	assert.Error(t, err)
+	assert.Len(t, recs, 1)
+}
+
+func TestRecordingConn_PendingTime(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x7ed))
+	acc := wallettest.NewRandomAccount(rng)
+	var file bytes.Buffer
+	conn := newMockConn(nil)
+	a := NewRecordingConn(conn, wallettest.NewRandomAddress(rng), NewRecorder(&file))
+
+	require.NoError(t, a.Send(NewPingMsg()))
+	sent := time.Now()
+	conn.recvQueue <- NewAuthResponseMsg(acc)
+	_, err := a.Recv()
+	require.NoError(t, err)
+
+	recs, err := ReadRecords(&file)
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, Ping, recs[0].Msg.Type())
+	assert.False(t, recs[0].Time.After(sent), "pending messages must be stamped at send time")
+}
@@ -18,6 +18,7 @@ import (
 
 	"perun.network/go-perun/pkg/sync/atomic"
 	"perun.network/go-perun/pkg/test"
+	"perun.network/go-perun/wallet"
 	wallettest "perun.network/go-perun/wallet/test"
 )
 
@@ -187,7 +188,7 @@ func TestRegistry_authenticatedDial(t *testing.T) {
 
 	t.Run("dial fail, existing peer", func(t *testing.T) {
 		p := newEndpoint(nil, nil, nil)
-		p.create(newMockConn(nil))
+		p.create(newMockConn(nil), PeerInfo{Version: ProtocolVersion, Features: LocalFeatures()})
 		go d.put(nil)
 		test.AssertTerminates(t, timeout, func() {
 			err := r.authenticatedDial(context.Background(), p, wallettest.NewRandomAddress(rng))
@@ -417,3 +418,117 @@ func TestRegistry_Close(t *testing.T) {
 		assert.Error(t, r.Close())
 	})
 }
+
+type routingDialer struct {
+	*mockDialer
+	Routes
+}
+
+func TestRegistry_dial_Routes(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0xf07))
+	id := wallettest.NewRandomAccount(rng)
+	addr, relay := wallettest.NewRandomAddress(rng), wallettest.NewRandomAddress(rng)
+	d := &routingDialer{mockDialer: newMockDialer()}
+	r := NewEndpointRegistry(id, func(*Endpoint) {}, d)
+	defer r.Close()
+
+	d.RegisterRoute(addr, addr)
+	_, err := r.dial(context.Background(), addr)
+	assert.Error(t, err, "routes over the peer itself must be rejected")
+
+	d.RegisterRoute(addr, id.Address())
+	_, err = r.dial(context.Background(), addr)
+	assert.Error(t, err, "routes over the own node must be rejected")
+
+	d.RegisterRoute(addr, relay)
+	d.RegisterRoute(relay, wallettest.NewRandomAddress(rng))
+	_, err = r.dial(context.Background(), addr)
+	assert.Error(t, err, "relays with route hints must be rejected")
+}
+
+func TestRegistry_handleForward(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(0xf08))
+	id := wallettest.NewRandomAccount(rng)
+	// Forwarded AuthResponses keep the handshakes of relayed connections
+	// pending, so that the connections stay open.
+	newForwardMsg := func(sender Account, recipient Address, seq uint64) *ForwardMsg {
+		env := Envelope{Sender: sender.Address(), Recipient: recipient, Msg: NewAuthResponseMsg(sender)}
+		m, err := NewForwardMsg(sender, env, seq)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		r := NewEndpointRegistry(id, func(*Endpoint) {}, nil)
+		defer r.Close()
+		relay := newEndpoint(wallettest.NewRandomAddress(rng), nil, nil)
+		defer relay.Close()
+		sender := wallettest.NewRandomAccount(rng)
+		key := wallet.Key(sender.Address())
+		relayConn := func() *relayConn {
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			return r.relayConns[key]
+		}
+
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 5))
+		assert.Nil(t, relayConn(), "connections must start with sequence number 1")
+
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 1))
+		conn := relayConn()
+		require.NotNil(t, conn)
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 5))
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 5))
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 4))
+		r.mutex.Lock()
+		assert.Equal(t, uint64(5), conn.recvSeq, "replays must be dropped")
+		r.mutex.Unlock()
+
+		conn.Close()
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 6))
+		assert.Same(t, conn, relayConn(), "messages of closed connections must not set up connections")
+
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 1))
+		reconn := relayConn()
+		require.NotNil(t, reconn)
+		assert.NotSame(t, conn, reconn, "reconnecting senders must get a new connection")
+		r.handleForward(relay, newForwardMsg(sender, id.Address(), 1))
+		assert.True(t, reconn.IsClosed(), "reconnecting senders must replace open connections")
+		assert.NotSame(t, reconn, relayConn())
+	})
+
+	t.Run("relayConn limit", func(t *testing.T) {
+		r := NewEndpointRegistry(id, func(*Endpoint) {}, nil)
+		defer r.Close()
+		relay := newEndpoint(wallettest.NewRandomAddress(rng), nil, nil)
+		defer relay.Close()
+
+		for i := 0; i <= maxRelayConnsPerRelay; i++ {
+			r.handleForward(relay, newForwardMsg(wallettest.NewRandomAccount(rng), id.Address(), 1))
+		}
+		r.mutex.Lock()
+		assert.Equal(t, maxRelayConnsPerRelay, r.numRelayConns(relay))
+		r.mutex.Unlock()
+	})
+
+	t.Run("non-blocking forwarding", func(t *testing.T) {
+		r := NewEndpointRegistry(id, func(*Endpoint) {}, nil)
+		defer r.Close()
+		r.SetForwarding(true)
+		from := newEndpoint(wallettest.NewRandomAddress(rng), nil, nil)
+		defer from.Close()
+		recipient := wallettest.NewRandomAddress(rng)
+		r.mutex.Lock()
+		r.addPeer(recipient, nil) // Sending to a peer without connection blocks.
+		r.mutex.Unlock()
+
+		sender := wallettest.NewRandomAccount(rng)
+		test.AssertTerminates(t, timeout, func() {
+			for i := 0; i < 2*forwardQueueSize; i++ {
+				r.handleForward(from, newForwardMsg(sender, recipient, uint64(i+1)))
+			}
+		})
+	})
+}
@@ -23,6 +23,7 @@ type Dialer struct {
 	hub    *ConnHub
 	dialed int32
 
+	wire.Routes
 	sync.Closer
 }
 
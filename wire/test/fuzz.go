@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"perun.network/go-perun/wire"
+)
+
+// maxFuzzPayloadLen is the maximum length of random message payloads that are
+// generated by FuzzDecoders.
+const maxFuzzPayloadLen = 512
+
+// FuzzDecoders runs wire.FuzzDecode on random payloads for every registered
+// message Type and on random mutations of the encodings of the given sample
+// messages. It fails the test if any decoder panics. The number of random
+// inputs per Type and sample is controlled by n.
+func FuzzDecoders(t *testing.T, rng *rand.Rand, n int, samples ...wire.Msg) {
+	features := wire.LocalFeatures()
+	for i := 0; i < 256; i++ {
+		typ := wire.Type(i)
+		if !features.Supports(typ) {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			data := make([]byte, 1+rng.Intn(maxFuzzPayloadLen))
+			rng.Read(data[1:])
+			data[0] = byte(typ)
+			fuzzDecode(t, data)
+		}
+	}
+
+	for _, m := range samples {
+		var buf bytes.Buffer
+		if err := wire.Encode(m, &buf); err != nil {
+			t.Fatalf("encoding sample %v message: %v", m.Type(), err)
+		}
+		if wire.FuzzDecode(buf.Bytes()) != 1 {
+			t.Errorf("sample %v message does not decode", m.Type())
+		}
+		for j := 0; j < n; j++ {
+			fuzzDecode(t, mutate(rng, buf.Bytes()))
+		}
+	}
+}
+
+func fuzzDecode(t *testing.T, data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("decoding panicked on input %x: %v", data, r)
+		}
+	}()
+	wire.FuzzDecode(data)
+}
+
+// mutate returns a randomly mutated copy of data. The message Type in the
+// first byte is left unchanged.
+func mutate(rng *rand.Rand, data []byte) []byte {
+	mut := append([]byte(nil), data...)
+	switch rng.Intn(4) {
+	case 0: // flip a bit
+		if len(mut) > 1 {
+			i := 1 + rng.Intn(len(mut)-1)
+			mut[i] ^= 1 << uint(rng.Intn(8))
+		}
+	case 1: // truncate
+		mut = mut[:1+rng.Intn(len(mut))]
+	case 2: // overwrite a byte with a large value, e.g., in a length field
+		if len(mut) > 1 {
+			mut[1+rng.Intn(len(mut)-1)] = 0xff
+		}
+	case 3: // append garbage
+		garbage := make([]byte, 1+rng.Intn(16))
+		rng.Read(garbage)
+		mut = append(mut, garbage...)
+	}
+	return mut
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"math/rand"
+	"testing"
+
+	wallettest "perun.network/go-perun/wallet/test"
+	"perun.network/go-perun/wire"
+)
+
+func TestFuzzDecoders(t *testing.T) {
+	rng := rand.New(rand.NewSource(0xF022))
+	FuzzDecoders(t, rng, 64,
+		wire.NewPingMsg(),
+		wire.NewPongMsg(),
+		&wire.ShutdownMsg{Reason: "fuzz"},
+		wire.NewAuthResponseMsg(wallettest.NewRandomAccount(rng)))
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Chair of Applied Cryptography, Technische Universität
+// Darmstadt, Germany. All rights reserved. This file is part of go-perun. Use
+// of this source code is governed by the Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package test
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/wire"
+)
+
+// Replay reproduces a recorded session between the node with address local
+// and its peer with address remote. It dials local using the given dialer,
+// usually created by a ConnHub on which a client.Client listens, and then
+// impersonates remote: Recorded messages of remote are sent in their
+// recorded order, and before each of them, Replay waits until local sent all
+// messages that it sent before in the recording. Records of other sessions
+// are ignored.
+//
+// Replay returns the messages that were received from local. It fails if the
+// Types of the received messages diverge from the recording or if the context
+// expires, in which case only the context's error is returned. The connection
+// is closed when Replay returns, which also stops the replay.
+func Replay(
+	ctx context.Context,
+	dialer wire.Dialer,
+	local, remote wire.Address,
+	records []wire.Record,
+) ([]wire.Msg, error) {
+	conn, err := dialer.Dial(ctx, local)
+	if err != nil {
+		return nil, errors.WithMessage(err, "dialing local node")
+	}
+	defer conn.Close()
+
+	type result struct {
+		received []wire.Msg
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		received, err := replay(conn, local, remote, records)
+		done <- result{received, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.received, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func replay(conn wire.Conn, local, remote wire.Address, records []wire.Record) ([]wire.Msg, error) {
+	var received []wire.Msg
+	for i, rec := range records {
+		switch {
+		case rec.Sender.Equals(remote) && rec.Recipient.Equals(local):
+			if err := conn.Send(rec.Msg); err != nil {
+				return received, errors.WithMessagef(err, "sending record %d", i)
+			}
+		case rec.Sender.Equals(local) && rec.Recipient.Equals(remote):
+			m, err := conn.Recv()
+			if err != nil {
+				return received, errors.WithMessagef(err, "receiving record %d", i)
+			}
+			received = append(received, m)
+			if m.Type() != rec.Msg.Type() {
+				return received, errors.Errorf(
+					"replay diverged at record %d: expected %v message, got %v",
+					i, rec.Msg.Type(), m.Type())
+			}
+		}
+	}
+	return received, nil
+}